@@ -0,0 +1,80 @@
+// admins grants and revokes support-admin rights. Admins may impersonate
+// users (POST /admin/impersonations) from a session in sudo mode; other
+// admins cannot be impersonated.
+//
+//	go run ./cmd/admins grant -email support@example.com
+//	go run ./cmd/admins revoke -email support@example.com
+//	go run ./cmd/admins list
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("❌ Usage: admins grant|revoke|list [flags]")
+	}
+
+	database.InitDB()
+	database.Migrate()
+
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "grant":
+		setAdmin("grant", args, true)
+	case "revoke":
+		setAdmin("revoke", args, false)
+	case "list":
+		list()
+	default:
+		log.Fatalf("❌ Unknown command %q (want grant, revoke or list)", command)
+	}
+}
+
+func setAdmin(command string, args []string, admin bool) {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	email := flags.String("email", "", "Email address of the account")
+	flags.Parse(args)
+	if *email == "" {
+		log.Fatal("❌ -email is required")
+	}
+
+	result := database.DB.Model(&models.User{}).
+		Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(*email))).
+		Update("is_admin", admin)
+	if result.Error != nil {
+		log.Fatalf("❌ Failed to update account: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Fatalf("❌ No active account with email %s", *email)
+	}
+
+	if admin {
+		log.Println("✅ Granted admin rights to", *email)
+	} else {
+		log.Println("✅ Revoked admin rights from", *email, "(running impersonations stop at once)")
+	}
+}
+
+func list() {
+	var admins []models.User
+	if err := database.DB.Where("is_admin").Order("email").Find(&admins).Error; err != nil {
+		log.Fatalf("❌ Failed to list admins: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tUSERNAME\tJOINED")
+	for _, admin := range admins {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", admin.ID, admin.Email, admin.Username, admin.CreatedAt.Format(time.DateOnly))
+	}
+	w.Flush()
+}
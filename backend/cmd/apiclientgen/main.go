@@ -0,0 +1,29 @@
+// apiclientgen writes the typed Go client in pkg/apiclient from the OpenAPI
+// document served at /openapi.json. Run it after changing a route, request or
+// response type (the contract test fails until the output is up to date):
+//
+//	go generate ./pkg/apiclient
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/thejpness/ArcadiaGo/internal/handlers"
+)
+
+func main() {
+	out := flag.String("out", "client_gen.go", "Output file")
+	pkg := flag.String("package", "apiclient", "Package name of the generated file")
+	flag.Parse()
+
+	src, err := handlers.OpenAPIDocument().GoClient(*pkg, "apiclientgen")
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	log.Println("✅ Wrote", *out)
+}
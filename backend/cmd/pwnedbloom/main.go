@@ -0,0 +1,73 @@
+// pwnedbloom builds the offline breached-password bloom filter used when
+// PWNED_PASSWORDS_BLOOM is set. Input is the Pwned Passwords SHA-1 list
+// ("HASH:COUNT" per line), e.g. produced by the official downloader.
+//
+//	go run ./cmd/pwnedbloom -in pwnedpasswords.txt -out pwned.bloom -n 900000000
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+)
+
+func main() {
+	in := flag.String("in", "", "Pwned Passwords SHA-1 list (HASH:COUNT per line)")
+	out := flag.String("out", "pwned.bloom", "Output bloom filter file")
+	entries := flag.Uint64("n", 1_000_000, "Expected number of entries")
+	rate := flag.Float64("p", 0.001, "Target false-positive rate")
+	minCount := flag.Int("min-count", 1, "Skip hashes seen fewer times than this")
+	flag.Parse()
+
+	if *in == "" {
+		log.Fatal("❌ -in is required")
+	}
+
+	input, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("❌ Failed to open input: %v", err)
+	}
+	defer input.Close()
+
+	bits, hashes := auth.OptimalBloomSize(*entries, *rate)
+	filter := auth.NewBloomFilter(bits, hashes)
+	log.Printf("🔧 Building filter: %d bits (%d MiB), %d hashes", bits, bits/8/1024/1024, hashes)
+
+	added := 0
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		hash, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if n, err := strconv.Atoi(count); err == nil && n < *minCount {
+			continue
+		}
+
+		raw, err := hex.DecodeString(hash)
+		if err != nil || len(raw) != sha1.Size {
+			continue
+		}
+		filter.AddDigest([sha1.Size]byte(raw))
+		added++
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("❌ Failed to read input: %v", err)
+	}
+
+	output, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("❌ Failed to create output: %v", err)
+	}
+	defer output.Close()
+
+	if _, err := filter.WriteTo(output); err != nil {
+		log.Fatalf("❌ Failed to write filter: %v", err)
+	}
+
+	log.Printf("✅ Wrote %d hashes to %s", added, *out)
+}
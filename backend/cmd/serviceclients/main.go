@@ -0,0 +1,220 @@
+// serviceclients registers the backends allowed to call the OAuth endpoints
+// (/token, /introspect, /revoke). A client authenticates with a secret
+// (printed once, only its hash is stored), a private_key_jwt public key or a
+// pinned mTLS certificate. Rotate credentials by creating a new client, then
+// disabling the old one. Public clients (-public, e.g. a CLI) have no
+// credentials and may only sign users in through the device flow.
+//
+//	go run ./cmd/serviceclients create -name "orders-api" -scopes "orders:read orders:write"
+//	go run ./cmd/serviceclients create -name "billing-job" -public-key billing.pub.pem
+//	go run ./cmd/serviceclients create -name "ledger" -cert ledger.crt.pem
+//	go run ./cmd/serviceclients create -name "arcadia-cli" -public
+//	go run ./cmd/serviceclients scopes -id svc_0123abcd -set "orders:read"
+//	go run ./cmd/serviceclients list
+//	go run ./cmd/serviceclients disable -id svc_0123abcd
+package main
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("❌ Usage: serviceclients create|scopes|list|disable [flags]")
+	}
+
+	database.InitDB()
+	database.Migrate()
+
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "create":
+		create(args)
+	case "scopes":
+		setScopes(args)
+	case "list":
+		list()
+	case "disable":
+		disable(args)
+	default:
+		log.Fatalf("❌ Unknown command %q (want create, scopes, list or disable)", command)
+	}
+}
+
+func create(args []string) {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	name := flags.String("name", "", "What the client is (e.g. the service name)")
+	scopes := flags.String("scopes", "", "Space-separated scopes the client may request")
+	publicKey := flags.String("public-key", "", "PEM public key file: authenticate with private_key_jwt instead of a secret")
+	certFile := flags.String("cert", "", "PEM certificate file: authenticate with this mTLS certificate instead of a secret")
+	public := flags.Bool("public", false, "Public client without credentials (device sign-in only, e.g. a CLI)")
+	flags.Parse(args)
+	if *name == "" {
+		log.Fatal("❌ -name is required")
+	}
+	methods := 0
+	for _, set := range []bool{*publicKey != "", *certFile != "", *public} {
+		if set {
+			methods++
+		}
+	}
+	if methods > 1 {
+		log.Fatal("❌ Use at most one of -public-key, -cert and -public")
+	}
+	if *public && *scopes != "" {
+		log.Fatal("❌ Public clients cannot get service tokens, so -scopes does not apply")
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		log.Fatalf("❌ Failed to generate client ID: %v", err)
+	}
+	client := models.ServiceClient{
+		ID:            "svc_" + hex.EncodeToString(id),
+		Name:          *name,
+		AllowedScopes: normalizeScopes(*scopes),
+	}
+
+	var secret string
+	switch {
+	case *public:
+		client.AuthMethod = models.ClientAuthNone
+	case *publicKey != "":
+		data, err := os.ReadFile(*publicKey)
+		if err != nil {
+			log.Fatalf("❌ Failed to read public key: %v", err)
+		}
+		if _, err := auth.ParseClientPublicKey(string(data)); err != nil {
+			log.Fatalf("❌ Invalid public key: %v", err)
+		}
+		client.AuthMethod, client.PublicKeyPEM = models.ClientAuthPrivateKeyJWT, string(data)
+	case *certFile != "":
+		data, err := os.ReadFile(*certFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to read certificate: %v", err)
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			log.Fatal("❌ No PEM block in the certificate file")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			log.Fatalf("❌ Invalid certificate: %v", err)
+		}
+		client.AuthMethod, client.CertThumbprint = models.ClientAuthTLS, auth.CertThumbprint(cert)
+	default:
+		var hash string
+		var err error
+		if secret, hash, err = auth.GenerateURLToken(); err != nil {
+			log.Fatalf("❌ Failed to generate client secret: %v", err)
+		}
+		client.AuthMethod, client.SecretHash = models.ClientAuthSecret, hash
+	}
+
+	if err := database.DB.Create(&client).Error; err != nil {
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	log.Printf("✅ Created client %q (%s)", client.Name, client.AuthMethod)
+	fmt.Println("client_id:    ", client.ID)
+	if secret != "" {
+		fmt.Println("client_secret:", secret)
+		log.Println("⚠️ Store the secret now, it cannot be shown again")
+	}
+}
+
+func setScopes(args []string) {
+	flags := flag.NewFlagSet("scopes", flag.ExitOnError)
+	id := flags.String("id", "", "Client ID")
+	scopes := flags.String("set", "", "Space-separated scopes the client may request (replaces the current ones)")
+	flags.Parse(args)
+	if *id == "" {
+		log.Fatal("❌ -id is required")
+	}
+
+	result := database.DB.Model(&models.ServiceClient{}).Where("id = ?", *id).Update("allowed_scopes", normalizeScopes(*scopes))
+	if result.Error != nil {
+		log.Fatalf("❌ Failed to update scopes: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Fatalf("❌ No client %s", *id)
+	}
+	log.Println("✅ Updated scopes of client", *id)
+}
+
+// ✅ RFC 6749 §3.3 scope tokens: printable ASCII except space, " and \
+var scopeToken = regexp.MustCompile(`^[\x21\x23-\x5B\x5D-\x7E]+$`)
+
+// ✅ Validate and de-duplicate a space-separated scope list
+func normalizeScopes(scopes string) string {
+	var out []string
+	for _, scope := range strings.Fields(scopes) {
+		if !scopeToken.MatchString(scope) {
+			log.Fatalf("❌ Invalid scope %q", scope)
+		}
+		if !slices.Contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func list() {
+	var clients []models.ServiceClient
+	if err := database.DB.Order("created_at").Find(&clients).Error; err != nil {
+		log.Fatalf("❌ Failed to list clients: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tAUTH\tSCOPES\tCREATED\tLAST USED\tSTATUS")
+	for _, client := range clients {
+		status := "active"
+		if client.DisabledAt != nil {
+			status = "disabled " + client.DisabledAt.Format(time.DateOnly)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", client.ID, client.Name, client.AuthMethod, client.AllowedScopes, client.CreatedAt.Format(time.DateOnly), formatOptional(client.LastUsedAt), status)
+	}
+	w.Flush()
+}
+
+func disable(args []string) {
+	flags := flag.NewFlagSet("disable", flag.ExitOnError)
+	id := flags.String("id", "", "Client ID to disable")
+	flags.Parse(args)
+	if *id == "" {
+		log.Fatal("❌ -id is required")
+	}
+
+	result := database.DB.Model(&models.ServiceClient{}).
+		Where("id = ? AND disabled_at IS NULL", *id).
+		Update("disabled_at", time.Now())
+	if result.Error != nil {
+		log.Fatalf("❌ Failed to disable client: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Fatalf("❌ No active client %s", *id)
+	}
+	log.Println("✅ Disabled client", *id)
+}
+
+func formatOptional(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format(time.DateTime)
+}
@@ -0,0 +1,1037 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/handlers"
+	"github.com/thejpness/ArcadiaGo/internal/jobs"
+	"github.com/thejpness/ArcadiaGo/internal/lifecycle"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"github.com/thejpness/ArcadiaGo/internal/webhooks"
+	"github.com/thejpness/ArcadiaGo/pkg/arcadia"
+)
+
+const testPassword = "correct-Horse-battery-42"
+
+// Register an account and sign it in on a fresh client
+func signUp(t *testing.T, env *testEnv, email, username string) *testClient {
+	t.Helper()
+	c := env.client()
+	c.post("/register", handlers.RegisterRequest{Email: email, Username: username, Password: testPassword}).expect(http.StatusCreated)
+	c.post("/login", handlers.LoginRequest{Email: email, Password: testPassword}).expect(http.StatusOK)
+	return c
+}
+
+func TestAccountLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	c := env.client()
+
+	// Register
+	c.post("/register", handlers.RegisterRequest{Email: "ada@example.com", Username: "ada", Password: testPassword}).
+		expect(http.StatusCreated)
+	c.post("/register", handlers.RegisterRequest{Email: "ADA@example.com", Username: "ada2", Password: testPassword}).
+		expectProblem(http.StatusConflict, "user.email_taken")
+
+	// Not signed in yet
+	c.get("/user").expectProblem(http.StatusUnauthorized, "auth.unauthenticated")
+
+	// Login
+	c.post("/login", handlers.LoginRequest{Email: "ada@example.com", Password: "wrong-password"}).
+		expectProblem(http.StatusUnauthorized, "auth.invalid_credentials")
+	c.post("/login", handlers.LoginRequest{Email: "ada@example.com", Password: testPassword}).expect(http.StatusOK)
+	if !c.hasCookie("auth_token") || !c.hasCookie("refresh_token") {
+		t.Fatalf("login did not set both auth cookies: %v", c.cookies)
+	}
+	outbox.await(t, "ada@example.com", "new device signed in")
+
+	// Refresh
+	c.post("/refresh", nil).expect(http.StatusOK)
+	if !c.hasCookie("auth_token") {
+		t.Fatal("refresh did not set a new access token")
+	}
+
+	// Profile
+	var profile handlers.UserProfile
+	c.get("/user").expect(http.StatusOK).decode(&profile)
+	if profile.Email != "ada@example.com" || profile.Username != "ada" || profile.EmailVerified {
+		t.Fatalf("unexpected profile after registration: %+v", profile)
+	}
+
+	// Update
+	c.patch("/user", map[string]interface{}{"display_name": "Ada Lovelace", "timezone": "Europe/London"}).
+		expect(http.StatusOK).decode(&profile)
+	if profile.DisplayName != "Ada Lovelace" || profile.Timezone != "Europe/London" {
+		t.Fatalf("PATCH /user returned %+v", profile)
+	}
+	c.patch("/user", map[string]interface{}{"timezone": "Mars/Olympus_Mons"}).
+		expectProblem(http.StatusUnprocessableEntity, "request.validation_failed")
+	c.patch("/user", map[string]interface{}{"display_name": nil}).expect(http.StatusOK)
+	c.get("/user").expect(http.StatusOK).decode(&profile)
+	if profile.DisplayName != "" || profile.Timezone != "Europe/London" {
+		t.Fatalf("merge patch with null did not clear only display_name: %+v", profile)
+	}
+
+	// Sessions
+	var sessions handlers.SessionListResponse
+	c.get("/active-sessions").expect(http.StatusOK).decode(&sessions)
+	if len(sessions.Sessions) != 1 || !sessions.Sessions[0].Current {
+		t.Fatalf("want exactly the current session, got %+v", sessions.Sessions)
+	}
+
+	// Logout revokes the session, even for a copy of the old cookies
+	stolen := map[string]*http.Cookie{}
+	for name, cookie := range c.cookies {
+		stolen[name] = cookie
+	}
+	c.post("/logout", nil).expect(http.StatusOK)
+	if c.hasCookie("auth_token") || c.hasCookie("refresh_token") {
+		t.Errorf("logout left cookies behind: %v", c.cookies)
+	}
+	c.get("/user").expectProblem(http.StatusUnauthorized, "auth.unauthenticated")
+
+	c.cookies = stolen
+	c.get("/user").expectProblem(http.StatusUnauthorized, "auth.session_revoked")
+
+	// Emails match in any case
+	env.client().post("/login", handlers.LoginRequest{Email: "Ada@Example.COM", Password: testPassword}).expect(http.StatusOK)
+}
+
+func TestEmailChangeConfirmation(t *testing.T) {
+	env := newTestEnv(t)
+	c := signUp(t, env, "grace@example.com", "grace")
+
+	// Taken addresses are refused up front
+	signUp(t, env, "taken@example.com", "taken")
+	c.post("/update-email", handlers.EmailChangeRequest{NewEmail: "taken@example.com"}).
+		expectProblem(http.StatusConflict, "user.email_taken")
+
+	// The confirmation link goes to the new address
+	c.post("/update-email", handlers.EmailChangeRequest{NewEmail: "grace.hopper@example.com"}).expect(http.StatusOK)
+	token := outbox.await(t, "grace.hopper@example.com", "Confirm Email Change").token(t)
+
+	// Nothing changes until the link is followed
+	var profile handlers.UserProfile
+	c.get("/user").expect(http.StatusOK).decode(&profile)
+	if profile.Email != "grace@example.com" {
+		t.Fatalf("email changed before confirmation: %s", profile.Email)
+	}
+
+	// Links are checked (the link is followed from the mail client, not the signed-in browser)
+	visitor := env.client()
+	visitor.get("/confirm-email?token=not-a-real-token").expectProblem(http.StatusBadRequest, "auth.invalid_link")
+	visitor.get("/confirm-email?token=" + url.QueryEscape(token)).expect(http.StatusOK)
+	visitor.get("/confirm-email?token="+url.QueryEscape(token)).expectProblem(http.StatusBadRequest, "auth.invalid_link")
+
+	c.get("/user").expect(http.StatusOK).decode(&profile)
+	if profile.Email != "grace.hopper@example.com" || !profile.EmailVerified {
+		t.Fatalf("email not updated and verified after confirmation: %+v", profile)
+	}
+
+	// Sign-in follows the new address
+	fresh := env.client()
+	fresh.post("/login", handlers.LoginRequest{Email: "grace@example.com", Password: testPassword}).
+		expectProblem(http.StatusUnauthorized, "auth.invalid_credentials")
+	fresh.post("/login", handlers.LoginRequest{Email: "grace.hopper@example.com", Password: testPassword}).
+		expect(http.StatusOK)
+}
+
+func TestUsernameLookup(t *testing.T) {
+	env := newTestEnv(t)
+	ada := signUp(t, env, "ada@example.com", "ada")
+	grace := signUp(t, env, "grace@example.com", "grace")
+
+	var profile handlers.PublicProfile
+	grace.get("/users/ADA").expect(http.StatusOK).decode(&profile)
+	if profile.Username != "ada" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+
+	// Reserved names and their look-alikes are refused, real words that merely resemble one are not
+	ada.post("/update-username", handlers.UpdateUsernameRequest{NewUsername: "ma1l"}).
+		expectProblem(http.StatusUnprocessableEntity, "request.validation_failed")
+	ada.post("/update-username", handlers.UpdateUsernameRequest{NewUsername: "mall"}).expect(http.StatusOK)
+
+	// The old name redirects to the new one while it is on hold
+	res := grace.get("/users/ada").expect(http.StatusFound)
+	var redirect handlers.UsernameRedirectResponse
+	res.decode(&redirect)
+	if redirect.Username != "mall" || res.Header.Get("Location") != redirect.Location || !strings.HasSuffix(redirect.Location, "/users/mall") {
+		t.Fatalf("unexpected redirect: %+v (Location %q)", redirect, res.Header.Get("Location"))
+	}
+	grace.get("/users/mall").expect(http.StatusOK)
+	grace.get("/users/nobody").expectProblem(http.StatusNotFound, "user.not_found")
+}
+
+func TestAccountRestore(t *testing.T) {
+	env := newTestEnv(t)
+	c := signUp(t, env, "ada@example.com", "ada")
+	c.post("/delete-account", nil).expect(http.StatusOK)
+	token := outbox.await(t, "ada@example.com", "Your account has been deleted").token(t)
+
+	// Following the emailed link only shows what would be restored
+	visitor := env.client()
+	var info handlers.RestoreAccountInfo
+	for range 2 {
+		visitor.get("/restore-account?token=" + url.QueryEscape(token)).expect(http.StatusOK).decode(&info)
+	}
+	if info.Username != "ada" || info.Email != "ada@example.com" || !info.RestoreBefore.After(time.Now()) {
+		t.Fatalf("unexpected restore preview: %+v", info)
+	}
+	visitor.get("/restore-account?token=not-a-real-token").expectProblem(http.StatusBadRequest, "auth.invalid_link")
+	visitor.post("/login", handlers.LoginRequest{Email: "ada@example.com", Password: testPassword}).
+		expectProblem(http.StatusUnauthorized, "auth.invalid_credentials")
+
+	// Confirming restores the account, once
+	visitor.post("/restore-account", handlers.RestoreAccountRequest{Token: "not-a-real-token"}).
+		expectProblem(http.StatusBadRequest, "auth.invalid_link")
+	visitor.post("/restore-account", handlers.RestoreAccountRequest{Token: token}).expect(http.StatusOK)
+	visitor.post("/restore-account", handlers.RestoreAccountRequest{Token: token}).
+		expectProblem(http.StatusBadRequest, "auth.invalid_link")
+	visitor.get("/restore-account?token="+url.QueryEscape(token)).expectProblem(http.StatusBadRequest, "auth.invalid_link")
+	visitor.post("/login", handlers.LoginRequest{Email: "ada@example.com", Password: testPassword}).expect(http.StatusOK)
+}
+
+func TestReportNotMe(t *testing.T) {
+	env := newTestEnv(t)
+	c := signUp(t, env, "ada@example.com", "ada")
+	token := outbox.await(t, "ada@example.com", "new device signed in").token(t)
+
+	// The link works once: it signs the device out, forgets it and starts a password reset
+	reporter := env.client()
+	reporter.post("/not-me", handlers.ReportNotMeRequest{Token: "not-a-real-token"}).expectProblem(http.StatusBadRequest, "auth.invalid_link")
+	reporter.post("/not-me", handlers.ReportNotMeRequest{Token: token}).expect(http.StatusOK)
+	outbox.await(t, "ada@example.com", "Reset your password")
+	reporter.post("/not-me", handlers.ReportNotMeRequest{Token: token}).expectProblem(http.StatusBadRequest, "auth.invalid_link")
+
+	c.get("/user").expectProblem(http.StatusUnauthorized, "auth.session_revoked")
+	var devices int64
+	database.DB.Model(&models.TrustedDevice{}).Count(&devices)
+	if devices != 0 {
+		t.Errorf("reported device still trusted (%d devices)", devices)
+	}
+	resets := 0
+	outbox.mu.Lock()
+	for _, mail := range outbox.sent {
+		if mail.Subject == "Reset your password" {
+			resets++
+		}
+	}
+	outbox.mu.Unlock()
+	if resets != 1 {
+		t.Errorf("want 1 password reset email, got %d", resets)
+	}
+}
+
+func TestMagicLink(t *testing.T) {
+	env := newTestEnv(t)
+	signUp(t, env, "ada@example.com", "ada")
+
+	// Unknown and known emails get the same answer
+	env.client().post("/login/magic-link", handlers.MagicLinkRequest{Email: "nobody@example.com"}).expect(http.StatusOK)
+	browser := env.client()
+	browser.post("/login/magic-link", handlers.MagicLinkRequest{Email: "Ada@Example.com"}).expect(http.StatusOK)
+	token := outbox.await(t, "ada@example.com", "Your sign-in link").token(t)
+	verify := "/login/magic-link/verify?token=" + url.QueryEscape(token)
+	nonce := browser.cookies["magic_link_nonce"]
+	if nonce == nil {
+		t.Fatal("no nonce cookie set for the requesting browser")
+	}
+
+	// The link only works in the browser that asked for it
+	elsewhere := env.client()
+	elsewhere.get(verify).expectProblem(http.StatusUnauthorized, "auth.magic_link_invalid")
+	elsewhere.cookies["magic_link_nonce"] = &http.Cookie{Name: "magic_link_nonce", Value: "forged"}
+	elsewhere.get(verify).expectProblem(http.StatusUnauthorized, "auth.magic_link_wrong_browser")
+	elsewhere.get("/user").expectProblem(http.StatusUnauthorized, "auth.unauthenticated")
+
+	// It signs in once, and proves the email address
+	browser.get(verify).expect(http.StatusOK)
+	if browser.hasCookie("magic_link_nonce") {
+		t.Error("nonce cookie survived the sign-in")
+	}
+	var profile handlers.UserProfile
+	browser.get("/user").expect(http.StatusOK).decode(&profile)
+	if profile.Email != "ada@example.com" || !profile.EmailVerified {
+		t.Fatalf("unexpected profile after magic link sign-in: %+v", profile)
+	}
+	replay := env.client()
+	replay.cookies["magic_link_nonce"] = nonce
+	replay.get(verify).expectProblem(http.StatusUnauthorized, "auth.magic_link_invalid")
+
+	// Three links per email, whatever its case and wherever they are asked from
+	env.client().post("/login/magic-link", handlers.MagicLinkRequest{Email: "ada@example.com"}).expect(http.StatusOK)
+	env.client().post("/login/magic-link", handlers.MagicLinkRequest{Email: "ADA@example.com"}).expect(http.StatusOK)
+	env.client().post("/login/magic-link", handlers.MagicLinkRequest{Email: "ada@example.com"}).
+		expectProblem(http.StatusTooManyRequests, "request.rate_limited")
+}
+
+func TestOrganizationOwnership(t *testing.T) {
+	env := newTestEnv(t)
+	ada := signUp(t, env, "ada@example.com", "ada")
+	grace := signUp(t, env, "grace@example.com", "grace")
+	signUp(t, env, "alan@example.com", "alan")
+
+	// Names go into email subjects, so they must be a single line
+	ada.post("/orgs", handlers.CreateOrganizationRequest{Name: "Engines\r\nBcc: mallory@example.com", Slug: "engines"}).
+		expectProblem(http.StatusUnprocessableEntity, "request.validation_failed")
+
+	var created handlers.OrganizationResponse
+	ada.post("/orgs", handlers.CreateOrganizationRequest{Name: "Analytical Engines", Slug: "analytical-engines"}).
+		expect(http.StatusCreated).decode(&created)
+	org := "/orgs/" + created.Organization.ID.String()
+	ada.post(org+"/members", handlers.AddOrgMemberRequest{Email: "Alan@Example.com"}).expect(http.StatusCreated)
+	ada.post(org+"/members", handlers.AddOrgMemberRequest{Email: "grace@example.com", Role: models.OrgRoleAdmin}).
+		expect(http.StatusCreated)
+
+	// The only owner can neither step down nor be removed
+	var adaProfile, graceProfile handlers.UserProfile
+	ada.get("/user").expect(http.StatusOK).decode(&adaProfile)
+	grace.get("/user").expect(http.StatusOK).decode(&graceProfile)
+	ada.patch(org+"/members/"+adaProfile.ID.String(), handlers.UpdateOrgMemberRoleRequest{Role: models.OrgRoleAdmin}).
+		expectProblem(http.StatusConflict, "org.last_owner")
+	ada.do(http.MethodDelete, org+"/members/"+adaProfile.ID.String(), nil).expectProblem(http.StatusConflict, "org.last_owner")
+
+	// Purging the only owner hands the organization to the highest-ranked member
+	if err := lifecycle.PurgeUser(adaProfile.ID); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	var roles []models.Membership
+	if err := database.DB.Where("organization_id = ?", created.Organization.ID).Order("role").Find(&roles).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 2 || roles[1].UserID != graceProfile.ID || roles[1].Role != models.OrgRoleOwner || roles[0].Role != models.OrgRoleMember {
+		t.Fatalf("want grace to own the organization after the purge, got %+v", roles)
+	}
+
+	// An organization nobody is left in goes with its last member
+	grace.post("/orgs", handlers.CreateOrganizationRequest{Name: "Compilers", Slug: "compilers"}).
+		expect(http.StatusCreated).decode(&created)
+	if err := lifecycle.PurgeUser(graceProfile.ID); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	var remaining int64
+	database.DB.Model(&models.Organization{}).Where("id = ?", created.Organization.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Fatal("organization without members was kept")
+	}
+}
+
+func TestOrganizationInvitations(t *testing.T) {
+	env := newTestEnv(t)
+	ada := signUp(t, env, "ada@example.com", "ada")
+	signUp(t, env, "grace@example.com", "grace")
+	alan := signUp(t, env, "alan@example.com", "alan")
+	alan.post("/delete-account", nil).expect(http.StatusOK)
+
+	var created handlers.OrganizationResponse
+	ada.post("/orgs", handlers.CreateOrganizationRequest{Name: "Analytical Engines", Slug: "analytical-engines"}).
+		expect(http.StatusCreated).decode(&created)
+	org := "/orgs/" + created.Organization.ID.String()
+	invite := func(email string) string {
+		t.Helper()
+		ada.post(org+"/invitations", handlers.CreateInvitationRequest{Email: email}).expect(http.StatusCreated)
+		return outbox.await(t, email, "You're invited to join Analytical Engines").token(t)
+	}
+	joined := func(c *testClient) bool {
+		t.Helper()
+		var orgs handlers.OrganizationListResponse
+		c.get("/orgs").expect(http.StatusOK).decode(&orgs)
+		for _, o := range orgs.Organizations {
+			if o.ID == created.Organization.ID {
+				return true
+			}
+		}
+		return false
+	}
+
+	// One pending invitation per email, and none for members
+	graceToken := invite("grace@example.com")
+	ada.post(org+"/invitations", handlers.CreateInvitationRequest{Email: "Grace@Example.com"}).
+		expectProblem(http.StatusConflict, "org.invitation_pending")
+	ada.post(org+"/invitations", handlers.CreateInvitationRequest{Email: "ada@example.com"}).
+		expectProblem(http.StatusConflict, "org.already_member")
+
+	// An existing account confirms with its password and is linked, once
+	var preview handlers.InvitationPreview
+	visitor := env.client()
+	visitor.get("/invitations?token=" + url.QueryEscape(graceToken)).expect(http.StatusOK).decode(&preview)
+	if preview.Organization != "Analytical Engines" || preview.Email != "grace@example.com" || preview.Role != models.OrgRoleMember || !preview.AccountExists {
+		t.Fatalf("unexpected invitation preview: %+v", preview)
+	}
+	visitor.post("/invitations/accept", handlers.AcceptInvitationRequest{Token: graceToken, Password: "wrong-password"}).
+		expectProblem(http.StatusUnauthorized, "auth.invalid_credentials")
+	visitor.post("/invitations/accept", handlers.AcceptInvitationRequest{Token: graceToken, Password: testPassword}).expect(http.StatusOK)
+	if !joined(visitor) {
+		t.Fatal("accepting did not make grace a member")
+	}
+	visitor.post("/invitations/accept", handlers.AcceptInvitationRequest{Token: graceToken, Password: testPassword}).
+		expectProblem(http.StatusNotFound, "org.invitation_invalid")
+	visitor.get("/invitations?token="+url.QueryEscape(graceToken)).expectProblem(http.StatusNotFound, "org.invitation_invalid")
+
+	// Anyone else registers through the link, with the invited email already verified
+	newToken := invite("lin@example.com")
+	newcomer := env.client()
+	newcomer.get("/invitations?token=" + url.QueryEscape(newToken)).expect(http.StatusOK).decode(&preview)
+	if preview.AccountExists {
+		t.Fatalf("unexpected invitation preview: %+v", preview)
+	}
+	newcomer.post("/invitations/accept", handlers.AcceptInvitationRequest{Token: newToken, Username: "lin", Password: "short"}).
+		expectProblem(http.StatusUnprocessableEntity, "auth.password_policy")
+	newcomer.post("/invitations/accept", handlers.AcceptInvitationRequest{Token: newToken, Username: "lin", Password: testPassword}).
+		expect(http.StatusOK)
+	var profile handlers.UserProfile
+	newcomer.get("/user").expect(http.StatusOK).decode(&profile)
+	if profile.Email != "lin@example.com" || !profile.EmailVerified || !joined(newcomer) {
+		t.Fatalf("unexpected profile after registering through an invitation: %+v", profile)
+	}
+	env.client().post("/login", handlers.LoginRequest{Email: "lin@example.com", Password: testPassword}).expect(http.StatusOK)
+
+	// Expired links stop working
+	lateToken := invite("late@example.com")
+	database.DB.Model(&models.OrgInvitation{}).Where("email = ?", "late@example.com").Update("expires_at", time.Now().Add(-time.Second))
+	visitor.get("/invitations?token="+url.QueryEscape(lateToken)).expectProblem(http.StatusNotFound, "org.invitation_invalid")
+	visitor.post("/invitations/accept", handlers.AcceptInvitationRequest{Token: lateToken, Username: "late", Password: testPassword}).
+		expectProblem(http.StatusNotFound, "org.invitation_invalid")
+
+	// A deleted account keeps its email until it is purged: restore it first
+	alanToken := invite("alan@example.com")
+	visitor.post("/invitations/accept", handlers.AcceptInvitationRequest{Token: alanToken, Username: "alan2", Password: testPassword}).
+		expectProblem(http.StatusConflict, "org.invitation_account_deleted")
+	var accounts int64
+	database.DB.Unscoped().Model(&models.User{}).Where("email = ?", "alan@example.com").Count(&accounts)
+	if accounts != 1 {
+		t.Fatalf("want alan's one deleted account, got %d accounts", accounts)
+	}
+}
+
+func TestTokenIntrospectionAndRevocation(t *testing.T) {
+	env := newTestEnv(t)
+	user := signUp(t, env, "linus@example.com", "linus")
+	access, refresh := user.cookies["auth_token"].Value, user.cookies["refresh_token"].Value
+	svc, record := env.serviceClient("orders-api")
+
+	// Only registered, enabled clients may ask
+	env.client().post("/introspect", url.Values{"token": {access}}).
+		expectProblem(http.StatusUnauthorized, "oauth.invalid_client")
+	impostor := env.client()
+	impostor.basicAuth = url.UserPassword(record.ID, "not-the-secret")
+	impostor.post("/introspect", url.Values{"token": {access}}).
+		expectProblem(http.StatusUnauthorized, "oauth.invalid_client")
+	svc.post("/introspect", url.Values{}).expectProblem(http.StatusBadRequest, "oauth.invalid_request")
+
+	introspect := func(form url.Values) (handlers.IntrospectionResponse, *testResponse) {
+		t.Helper()
+		var got handlers.IntrospectionResponse
+		resp := svc.post("/introspect", form).expect(http.StatusOK)
+		resp.decode(&got)
+		return got, resp
+	}
+
+	// Live tokens are active, with their session
+	got, resp := introspect(url.Values{"token": {access}})
+	if !got.Active || got.TokenType != "access_token" || got.UserID == "" || got.Subject != got.UserID || got.SessionID == "" || got.ExpiresAt == 0 {
+		t.Fatalf("access token introspection: %+v", got)
+	}
+	if cc := resp.Header.Get("Cache-Control"); !strings.HasPrefix(cc, "private, max-age=") {
+		t.Errorf("introspection Cache-Control = %q", cc)
+	}
+	got, _ = introspect(url.Values{"token": {refresh}, "token_type_hint": {"refresh_token"}})
+	if !got.Active || got.TokenType != "refresh_token" {
+		t.Fatalf("refresh token introspection: %+v", got)
+	}
+	if _, resp := introspect(url.Values{"token": {"not.a.token"}}); string(resp.Body) != `{"active":false}` {
+		t.Fatalf("garbage token introspection: %s", resp.Body)
+	}
+
+	// Revoking the refresh token ends the whole session
+	svc.post("/revoke", url.Values{"token": {refresh}}).expect(http.StatusOK)
+	if got, _ := introspect(url.Values{"token": {access}}); got.Active {
+		t.Fatalf("access token still active after revocation: %+v", got)
+	}
+	user.get("/user").expectProblem(http.StatusUnauthorized, "auth.session_revoked")
+	svc.post("/revoke", url.Values{"token": {refresh}}).expect(http.StatusOK) // Already revoked: still 200
+
+	// Disabled clients are locked out
+	now := time.Now()
+	database.DB.Model(record).Update("disabled_at", &now)
+	svc.post("/introspect", url.Values{"token": {access}}).expectProblem(http.StatusUnauthorized, "oauth.invalid_client")
+}
+
+func TestClientCredentialsGrant(t *testing.T) {
+	env := newTestEnv(t)
+	svc, record := env.serviceClient("billing-job", "orders:read", "orders:write")
+
+	// Grant and scope checks
+	svc.post("/token", url.Values{"grant_type": {"password"}}).
+		expectProblem(http.StatusBadRequest, "oauth.unsupported_grant_type")
+	svc.post("/token", url.Values{"grant_type": {"client_credentials"}, "scope": {"orders:read users:delete"}}).
+		expectProblem(http.StatusBadRequest, "oauth.invalid_scope")
+
+	var token handlers.TokenResponse
+	resp := svc.post("/token", url.Values{"grant_type": {"client_credentials"}, "scope": {"orders:read"}}).expect(http.StatusOK)
+	resp.decode(&token)
+	if token.TokenType != "Bearer" || token.Scope != "orders:read" || token.ExpiresIn <= 0 || token.AccessToken == "" {
+		t.Fatalf("unexpected token response: %+v", token)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("token response Cache-Control = %q", cc)
+	}
+
+	// Service tokens carry the client, not a user, and user endpoints refuse them
+	var got handlers.IntrospectionResponse
+	svc.post("/introspect", url.Values{"token": {token.AccessToken}}).expect(http.StatusOK).decode(&got)
+	if !got.Active || got.ClientID != record.ID || got.Subject != record.ID || got.UserID != "" || got.Scope != "orders:read" {
+		t.Fatalf("service token introspection: %+v", got)
+	}
+	caller := env.client()
+	caller.cookies["auth_token"] = &http.Cookie{Name: "auth_token", Value: token.AccessToken}
+	caller.get("/user").expectProblem(http.StatusForbidden, "auth.user_token_required")
+
+	// A client registered for private_key_jwt can't fall back to a secret, and vice versa
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyClient := &models.ServiceClient{
+		ID:           "svc_" + randomHex(8),
+		Name:         "signed-job",
+		AuthMethod:   models.ClientAuthPrivateKeyJWT,
+		PublicKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}
+	if err := database.DB.Create(keyClient).Error; err != nil {
+		t.Fatal(err)
+	}
+	assertion := func(audience string) string {
+		t.Helper()
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+			Issuer:    keyClient.ID,
+			Subject:   keyClient.ID,
+			Audience:  jwt.ClaimStrings{audience},
+			ID:        randomHex(8),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	grant := func(assertion string) *testResponse {
+		return env.client().post("/token", url.Values{
+			"grant_type":            {"client_credentials"},
+			"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+			"client_assertion":      {assertion},
+		})
+	}
+
+	good := assertion("http://example.com/token") // httptest requests go to example.com
+	grant(good).expect(http.StatusOK)
+	grant(good).expectProblem(http.StatusUnauthorized, "oauth.invalid_client") // Replayed
+	grant(assertion("https://elsewhere.example/token")).expectProblem(http.StatusUnauthorized, "oauth.invalid_client")
+	env.client().post("/token", url.Values{"grant_type": {"client_credentials"}, "client_id": {keyClient.ID}, "client_secret": {"guess"}}).
+		expectProblem(http.StatusUnauthorized, "oauth.invalid_client")
+
+	// Disabling the client cuts its tokens off
+	now := time.Now()
+	database.DB.Model(record).Update("disabled_at", &now)
+	other, _ := env.serviceClient("introspector")
+	other.post("/introspect", url.Values{"token": {token.AccessToken}}).expect(http.StatusOK).decode(&got)
+	if got.Active {
+		t.Fatalf("token of a disabled client is still active: %+v", got)
+	}
+}
+
+func TestDeviceAuthorizationGrant(t *testing.T) {
+	env := newTestEnv(t)
+	user := signUp(t, env, "ada@example.com", "ada")
+	cli := &models.ServiceClient{ID: "svc_" + randomHex(8), Name: "arcadia-cli", AuthMethod: models.ClientAuthNone}
+	if err := database.DB.Create(cli).Error; err != nil {
+		t.Fatal(err)
+	}
+	device := env.client()
+
+	start := func(name string) handlers.DeviceCodeResponse {
+		t.Helper()
+		var code handlers.DeviceCodeResponse
+		device.post("/device/code", url.Values{"client_id": {cli.ID}, "device_name": {name}}).expect(http.StatusOK).decode(&code)
+		return code
+	}
+	poll := func(code handlers.DeviceCodeResponse) *testResponse {
+		return device.post("/token", url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"client_id":   {cli.ID},
+			"device_code": {code.DeviceCode},
+		})
+	}
+	pollNow := func(code handlers.DeviceCodeResponse) *testResponse { // Skip the wait between polls
+		database.DB.Model(&models.DeviceAuthorization{}).Where("device_code_hash = ?", auth.HashURLToken(code.DeviceCode)).Update("last_polled_at", nil)
+		return poll(code)
+	}
+
+	// Public clients only sign users in
+	device.post("/token", url.Values{"grant_type": {"client_credentials"}, "client_id": {cli.ID}}).
+		expectProblem(http.StatusBadRequest, "oauth.unauthorized_client")
+	device.post("/introspect", url.Values{"client_id": {cli.ID}, "token": {"x"}}).
+		expectProblem(http.StatusUnauthorized, "oauth.invalid_client")
+
+	code := start("build-01")
+	if len(code.UserCode) != 9 || code.Interval <= 0 || code.ExpiresIn <= 0 || !strings.HasSuffix(code.VerificationURI, "/device") {
+		t.Fatalf("unexpected device code response: %+v", code)
+	}
+	poll(code).expectProblem(http.StatusBadRequest, "oauth.authorization_pending")
+	poll(code).expectProblem(http.StatusBadRequest, "oauth.slow_down")
+
+	// The user checks and approves the code (typed loosely)
+	typed := strings.ToLower(strings.ReplaceAll(code.UserCode, "-", ""))
+	var info handlers.DeviceAuthorizationInfo
+	user.get("/device?user_code=" + typed).expect(http.StatusOK).decode(&info)
+	if info.ClientName != "arcadia-cli" || info.DeviceName != "build-01" {
+		t.Fatalf("unexpected device authorization info: %+v", info)
+	}
+	user.post("/device", handlers.DeviceApprovalRequest{UserCode: typed, Approve: true}).expect(http.StatusOK)
+	user.post("/device", handlers.DeviceApprovalRequest{UserCode: typed, Approve: true}).
+		expectProblem(http.StatusNotFound, "user.device_code_invalid")
+
+	// The device collects its tokens, once
+	var token handlers.TokenResponse
+	pollNow(code).expect(http.StatusOK).decode(&token)
+	if token.AccessToken == "" || token.RefreshToken == "" || token.TokenType != "Bearer" {
+		t.Fatalf("unexpected token response: %+v", token)
+	}
+	pollNow(code).expectProblem(http.StatusBadRequest, "oauth.invalid_grant")
+
+	// It is a normal session, labelled with the device name
+	var sessions handlers.SessionListResponse
+	user.get("/active-sessions").expect(http.StatusOK).decode(&sessions)
+	labelled := 0
+	for _, s := range sessions.Sessions {
+		if s.DeviceName == "build-01" {
+			labelled++
+		}
+	}
+	if len(sessions.Sessions) != 2 || labelled != 1 {
+		t.Fatalf("expected the browser session and one device session: %+v", sessions.Sessions)
+	}
+	cliUser := env.client()
+	cliUser.cookies["auth_token"] = &http.Cookie{Name: "auth_token", Value: token.AccessToken}
+	cliUser.get("/user").expect(http.StatusOK)
+
+	// Refreshing works for the client the session was issued to, and only device sessions
+	var refreshed handlers.TokenResponse
+	device.post("/token", url.Values{"grant_type": {"refresh_token"}, "client_id": {cli.ID}, "refresh_token": {token.RefreshToken}}).
+		expect(http.StatusOK).decode(&refreshed)
+	if refreshed.AccessToken == "" || refreshed.RefreshToken != "" {
+		t.Fatalf("unexpected refresh response: %+v", refreshed)
+	}
+	device.post("/token", url.Values{"grant_type": {"refresh_token"}, "client_id": {cli.ID}, "refresh_token": {user.cookies["refresh_token"].Value}}).
+		expectProblem(http.StatusBadRequest, "oauth.invalid_grant")
+	other, _ := env.serviceClient("other-app")
+	other.post("/token", url.Values{"grant_type": {"refresh_token"}, "refresh_token": {token.RefreshToken}}).
+		expectProblem(http.StatusBadRequest, "oauth.invalid_grant")
+
+	// Declined and expired sign-ins
+	declined := start("kiosk")
+	user.post("/device", handlers.DeviceApprovalRequest{UserCode: declined.UserCode}).expect(http.StatusOK)
+	pollNow(declined).expectProblem(http.StatusBadRequest, "oauth.access_denied")
+
+	expired := start("tv")
+	database.DB.Model(&models.DeviceAuthorization{}).Where("device_code_hash = ?", auth.HashURLToken(expired.DeviceCode)).
+		Update("expires_at", time.Now().Add(-time.Second))
+	pollNow(expired).expectProblem(http.StatusBadRequest, "oauth.expired_token")
+	user.get("/device?user_code="+expired.UserCode).expectProblem(http.StatusNotFound, "user.device_code_invalid")
+
+	// The maintenance job clears expired sign-ins away
+	if n, err := lifecycle.PruneDeviceAuthorizations(time.Now()); err != nil || n != 1 {
+		t.Fatalf("PruneDeviceAuthorizations = %d, %v", n, err)
+	}
+}
+
+func TestImpersonation(t *testing.T) {
+	env := newTestEnv(t)
+	support := signUp(t, env, "support@example.com", "support")
+	user := signUp(t, env, "ada@example.com", "ada")
+	colleague := signUp(t, env, "grace@example.com", "grace")
+
+	var profile handlers.UserProfile
+	user.get("/user").expect(http.StatusOK).decode(&profile)
+	start := handlers.StartImpersonationRequest{UserID: profile.ID, Reason: "Ticket #1234: avatar upload fails"}
+	var colleagueProfile handlers.UserProfile
+	colleague.get("/user").expect(http.StatusOK).decode(&colleagueProfile)
+	var created handlers.OrganizationResponse
+	user.post("/orgs", handlers.CreateOrganizationRequest{Name: "Analytical Engines", Slug: "analytical-engines"}).
+		expect(http.StatusCreated).decode(&created)
+	org := "/orgs/" + created.Organization.ID.String()
+	user.post(org+"/members", handlers.AddOrgMemberRequest{Email: "grace@example.com"}).expect(http.StatusCreated)
+
+	// Only admins may impersonate, and never other admins
+	support.post("/admin/impersonations", start).expectProblem(http.StatusForbidden, "auth.admin_required")
+	env.setAdmin("support@example.com", true)
+	env.setAdmin("grace@example.com", true)
+	support.post("/admin/impersonations", handlers.StartImpersonationRequest{UserID: colleagueProfile.ID, Reason: "curious"}).
+		expectProblem(http.StatusForbidden, "auth.impersonation_not_allowed")
+
+	var started handlers.ImpersonationResponse
+	support.post("/admin/impersonations", start).expect(http.StatusCreated).decode(&started)
+	if started.AccessToken == "" || started.UserID != profile.ID || started.ExpiresIn <= 0 {
+		t.Fatalf("unexpected impersonation response: %+v", started)
+	}
+	outbox.await(t, "ada@example.com", "Support accessed your account")
+
+	// The token sees what the user sees
+	impersonator := env.client()
+	impersonator.cookies["auth_token"] = &http.Cookie{Name: "auth_token", Value: started.AccessToken}
+	var seen handlers.UserProfile
+	impersonator.get("/user").expect(http.StatusOK).decode(&seen)
+	if seen.ID != profile.ID {
+		t.Fatalf("impersonation token resolved to %s, want %s", seen.ID, profile.ID)
+	}
+	impersonator.get(org + "/members").expect(http.StatusOK)
+
+	// Anything that changes the account or its organizations, or takes data out, is blocked
+	impersonator.post("/update-password", handlers.UpdatePasswordRequest{OldPassword: testPassword, NewPassword: "another-Horse-battery-43"}).
+		expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.post("/update-email", handlers.EmailChangeRequest{NewEmail: "mallory@example.com"}).
+		expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.post("/delete-account", nil).expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.do(http.MethodDelete, "/user/avatar", nil).expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.post("/update-username", handlers.UpdateUsernameRequest{NewUsername: "mallory"}).
+		expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.patch("/user", map[string]string{"display_name": "Mallory"}).expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.post("/user/avatar", nil).expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.get("/user/export").expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.post("/logout-session", handlers.LogoutSessionRequest{SessionID: uuid.New()}).
+		expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.post("/orgs", handlers.CreateOrganizationRequest{Name: "Mallory Inc", Slug: "mallory"}).
+		expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.patch(org+"/members/"+colleagueProfile.ID.String(), handlers.UpdateOrgMemberRoleRequest{Role: models.OrgRoleOwner}).
+		expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+	impersonator.post(org+"/invitations", handlers.CreateInvitationRequest{Email: "mallory@example.com"}).
+		expectProblem(http.StatusForbidden, "auth.impersonation_blocked")
+
+	// Every request is on the user's audit trail, with the admin as actor
+	var supportProfile handlers.UserProfile
+	support.get("/user").expect(http.StatusOK).decode(&supportProfile)
+	var audited int64
+	database.DB.Model(&models.AuditEvent{}).
+		Where("user_id = ? AND actor_id = ? AND action = ?", profile.ID, supportProfile.ID, "impersonation.request").
+		Count(&audited)
+	if audited != 14 {
+		t.Fatalf("want 14 audited impersonated requests, got %d", audited)
+	}
+
+	// Stopping ends the token at once; ordinary sessions have nothing to stop
+	user.post("/impersonation/stop", nil).expectProblem(http.StatusConflict, "auth.not_impersonating")
+	impersonator.post("/impersonation/stop", nil).expect(http.StatusOK)
+	impersonator.get("/user").expectProblem(http.StatusUnauthorized, "auth.session_revoked")
+
+	// Losing admin rights ends running impersonations too
+	support.post("/admin/impersonations", start).expect(http.StatusCreated).decode(&started)
+	impersonator.cookies["auth_token"] = &http.Cookie{Name: "auth_token", Value: started.AccessToken}
+	impersonator.get("/user").expect(http.StatusOK)
+	env.setAdmin("support@example.com", false)
+	impersonator.get("/user").expectProblem(http.StatusUnauthorized, "auth.session_revoked")
+
+	// The record of support access goes with the account
+	if err := lifecycle.PurgeUser(profile.ID); err != nil {
+		t.Fatalf("PurgeUser: %v", err)
+	}
+	var impersonations int64
+	database.DB.Model(&models.Impersonation{}).Where("user_id = ?", profile.ID).Count(&impersonations)
+	if impersonations != 0 {
+		t.Fatalf("%d impersonations survived the purge", impersonations)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	env := newTestEnv(t)
+	admin := signUp(t, env, "support@example.com", "support")
+
+	// A receiver that records deliveries and answers with a configurable status
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	answer := http.StatusOK
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received, bodies = append(received, r), append(bodies, body)
+		w.WriteHeader(answer)
+	}))
+	defer receiver.Close()
+	setAnswer := func(status int) {
+		mu.Lock()
+		answer = status
+		mu.Unlock()
+	}
+	lastEvent := func(secret string) (*arcadia.WebhookEvent, *http.Request) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		if len(received) == 0 {
+			t.Fatal("no webhook delivered")
+		}
+		r, body := received[len(received)-1], bodies[len(bodies)-1]
+		event, err := arcadia.VerifyWebhook(secret, r.Header.Get(arcadia.WebhookSignatureHeader), body, 5*time.Minute)
+		if err != nil {
+			t.Fatalf("verify webhook: %v", err)
+		}
+		if _, err := arcadia.VerifyWebhook("whsec_wrong", r.Header.Get(arcadia.WebhookSignatureHeader), body, 5*time.Minute); err == nil {
+			t.Fatal("webhook verified with the wrong secret")
+		}
+		return event, r
+	}
+	deliver := func(want int) {
+		t.Helper()
+		if n, err := webhooks.DeliverDue(context.Background(), time.Now()); err != nil || n != want {
+			t.Fatalf("DeliverDue = %d, %v; want %d deliveries", n, err, want)
+		}
+	}
+
+	// Admins only, and only https (or local http) URLs and known events
+	hook := handlers.CreateWebhookRequest{URL: receiver.URL, Events: []string{"user.registered", "user.deleted"}}
+	admin.post("/admin/webhooks", hook).expectProblem(http.StatusForbidden, "auth.admin_required")
+	env.setAdmin("support@example.com", true)
+	admin.post("/admin/webhooks", handlers.CreateWebhookRequest{URL: "http://hooks.example.com/arcadia"}).
+		expectProblem(http.StatusUnprocessableEntity, "request.validation_failed")
+	admin.post("/admin/webhooks", handlers.CreateWebhookRequest{URL: receiver.URL, Events: []string{"user.exploded"}}).
+		expectProblem(http.StatusUnprocessableEntity, "request.validation_failed")
+
+	var created handlers.WebhookCreatedResponse
+	admin.post("/admin/webhooks", hook).expect(http.StatusCreated).decode(&created)
+	if !strings.HasPrefix(created.Secret, "whsec_") || !created.Webhook.Enabled || len(created.Webhook.Events) != 2 {
+		t.Fatalf("unexpected webhook: %+v", created)
+	}
+	deliveries := "/admin/webhooks/" + created.Webhook.ID.String() + "/deliveries"
+
+	// A registration is delivered, signed
+	signUp(t, env, "ada@example.com", "ada")
+	deliver(1)
+	event, r := lastEvent(created.Secret)
+	if event.Type != "user.registered" || !strings.Contains(string(event.Data), `"email":"ada@example.com"`) || r.Header.Get("Arcadia-Webhook-Id") != event.ID {
+		t.Fatalf("unexpected delivery %+v (headers %v)", event, r.Header)
+	}
+
+	// A change that does not commit queues nothing
+	env.client().post("/register", handlers.RegisterRequest{Email: "ada@example.com", Username: "ada2", Password: testPassword}).
+		expectProblem(http.StatusConflict, "user.email_taken")
+	deliver(0)
+
+	// Failures back off, then go dead after the last attempt
+	setAnswer(http.StatusServiceUnavailable)
+	grace := signUp(t, env, "grace@example.com", "grace")
+	deliver(1)
+	var history handlers.WebhookDeliveryListResponse
+	admin.get(deliveries + "?status=pending").expect(http.StatusOK).decode(&history)
+	if len(history.Deliveries) != 1 || history.Deliveries[0].Attempts != 1 || history.Deliveries[0].ResponseStatus != http.StatusServiceUnavailable ||
+		history.Deliveries[0].NextAttemptAt == nil || time.Until(*history.Deliveries[0].NextAttemptAt) < 20*time.Second {
+		t.Fatalf("expected one delivery waiting to be retried: %+v", history.Deliveries)
+	}
+	failed := history.Deliveries[0]
+	deliver(0) // Not due yet
+
+	database.DB.Model(&models.WebhookDelivery{}).Where("id = ?", failed.ID).
+		Updates(map[string]interface{}{"attempts": webhooks.MaxAttempts() - 1, "next_attempt_at": time.Now()})
+	deliver(1)
+	admin.get(deliveries + "?status=dead").expect(http.StatusOK).decode(&history)
+	if len(history.Deliveries) != 1 || history.Deliveries[0].ID != failed.ID || history.Deliveries[0].LastError == "" {
+		t.Fatalf("expected the delivery to be dead: %+v", history.Deliveries)
+	}
+
+	// Manual redelivery sends the same event again
+	setAnswer(http.StatusNoContent)
+	admin.post(deliveries+"/"+uuid.NewString()+"/redeliver", nil).expectProblem(http.StatusNotFound, "webhook.delivery_not_found")
+	var redelivered handlers.WebhookDeliveryInfo
+	admin.post(deliveries+"/"+failed.ID.String()+"/redeliver", nil).expect(http.StatusOK).decode(&redelivered)
+	if redelivered.Status != "pending" || redelivered.Attempts != 0 {
+		t.Fatalf("unexpected redelivery: %+v", redelivered)
+	}
+	deliver(1)
+	if event, _ := lastEvent(created.Secret); event.ID != failed.EventID.String() {
+		t.Fatalf("redelivery sent event %s, want %s", event.ID, failed.EventID)
+	}
+
+	// Unsubscribed events and disabled endpoints get nothing
+	var sessions handlers.SessionListResponse
+	grace.get("/active-sessions").expect(http.StatusOK).decode(&sessions)
+	grace.post("/logout-session", handlers.LogoutSessionRequest{SessionID: sessions.Sessions[0].ID}).expect(http.StatusOK)
+	disabled := false
+	admin.patch("/admin/webhooks/"+created.Webhook.ID.String(), handlers.UpdateWebhookRequest{Enabled: &disabled}).expect(http.StatusOK)
+	signUp(t, env, "linus@example.com", "linus")
+	deliver(0)
+
+	admin.get(deliveries).expect(http.StatusOK).decode(&history)
+	if len(history.Deliveries) != 2 {
+		t.Fatalf("want 2 deliveries in the log, got %+v", history.Deliveries)
+	}
+}
+
+func TestDomainEvents(t *testing.T) {
+	env := newTestEnv(t)
+	admin := env.admin()
+	c := signUp(t, env, "ada@example.com", "ada")
+	user := env.user("ada@example.com")
+
+	// A password change is published with the change and audited from the request that made it
+	c.post("/update-password", handlers.UpdatePasswordRequest{OldPassword: testPassword, NewPassword: "another-Horse-battery-43"}).expect(http.StatusOK)
+	var record models.OutboxEvent
+	if err := database.DB.Where("name = ?", "user.password_changed").First(&record).Error; err != nil {
+		t.Fatalf("password change not published: %v", err)
+	}
+	var deliveries []models.EventDelivery
+	database.DB.Where("event_id = ?", record.ID).Order("subscriber").Find(&deliveries)
+	if len(deliveries) != 3 {
+		t.Fatalf("want audit, mailer and metrics deliveries, got %+v", deliveries)
+	}
+	for _, delivery := range deliveries {
+		if delivery.Status != models.EventDone || delivery.Attempts != 1 {
+			t.Fatalf("delivery not handled once: %+v", delivery)
+		}
+	}
+
+	var audited []models.AuditEvent
+	database.DB.Where("user_id = ? AND action = ?", user.ID, "user.password_changed").Find(&audited)
+	if len(audited) != 1 || audited[0].UserAgent != c.userAgent {
+		t.Fatalf("want one audit event from the request, got %+v", audited)
+	}
+
+	// Handling an event again (at-least-once delivery) changes nothing
+	database.DB.Model(&models.EventDelivery{}).Where("event_id = ?", record.ID).
+		Updates(map[string]interface{}{"status": models.EventPending, "next_attempt_at": time.Now()})
+	if err := events.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	database.DB.Model(&models.AuditEvent{}).Where("user_id = ? AND action = ?", user.ID, "user.password_changed").Count(&count)
+	if count != 1 {
+		t.Fatalf("redelivered event audited %d times", count)
+	}
+
+	// A redelivered email is not sent twice
+	c.post("/update-email", handlers.EmailChangeRequest{NewEmail: "ada.lovelace@example.com"}).expect(http.StatusOK)
+	outbox.await(t, "ada.lovelace@example.com", "Confirm Email Change")
+	database.DB.Model(&models.EventDelivery{}).
+		Where("subscriber = ? AND event_id IN (?)", "mailer", database.DB.Model(&models.OutboxEvent{}).Select("id").Where("name = ?", "user.email_change_requested")).
+		Updates(map[string]interface{}{"status": models.EventPending, "next_attempt_at": time.Now()})
+	if err := events.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	outbox.mu.Lock()
+	sent := 0
+	for _, mail := range outbox.sent {
+		if mail.To == "ada.lovelace@example.com" {
+			sent++
+		}
+	}
+	outbox.mu.Unlock()
+	if sent != 1 {
+		t.Fatalf("redelivered email change confirmation sent %d times", sent)
+	}
+
+	// Nothing is published when the change fails
+	c.post("/update-password", handlers.UpdatePasswordRequest{OldPassword: testPassword, NewPassword: "third-Horse-battery-44"}).
+		expectProblem(http.StatusUnauthorized, "auth.incorrect_password")
+	database.DB.Model(&models.OutboxEvent{}).Where("name = ?", "user.password_changed").Count(&count)
+	if count != 1 {
+		t.Fatalf("want 1 password change published, got %d", count)
+	}
+
+	// Admins see the counters and the backlog
+	c.get("/admin/events/stats").expectProblem(http.StatusForbidden, "auth.admin_required")
+	var stats handlers.EventStatsResponse
+	admin.get("/admin/events/stats").expect(http.StatusOK).decode(&stats)
+	if stats.Handled["user.registered"] < 2 || stats.Handled["user.password_changed"] < 1 || len(stats.Pending) != 0 {
+		t.Fatalf("unexpected event stats: %+v", stats)
+	}
+}
+
+func TestScheduledJobs(t *testing.T) {
+	env := newTestEnv(t)
+	admin := env.admin()
+	c := signUp(t, env, "ada@example.com", "ada")
+	user := env.user("ada@example.com")
+
+	// Every job is listed, none has run yet
+	c.get("/admin/jobs").expectProblem(http.StatusForbidden, "auth.admin_required")
+	var list handlers.JobListResponse
+	admin.get("/admin/jobs").expect(http.StatusOK).decode(&list)
+	if len(list.Jobs) != len(jobs.Names()) || list.Jobs[0].LastRun != nil || !list.Jobs[0].NextRunAt.After(time.Now()) {
+		t.Fatalf("unexpected job list: %+v", list.Jobs)
+	}
+	if runs, err := jobs.RunDue(time.Now()); err != nil || len(runs) != 0 {
+		t.Fatalf("RunDue before anything is due = %+v, %v", runs, err)
+	}
+
+	// An email change link stops working once it expires, and the job clears it away
+	c.post("/update-email", handlers.EmailChangeRequest{NewEmail: "ada.lovelace@example.com"}).expect(http.StatusOK)
+	token := outbox.await(t, "ada.lovelace@example.com", "Confirm Email Change").token(t)
+	database.DB.Model(&models.UserEmailChange{}).Where("user_id = ?", user.ID).Update("created_at", time.Now().Add(-48*time.Hour))
+	env.client().get("/confirm-email?token="+url.QueryEscape(token)).expectProblem(http.StatusBadRequest, "auth.invalid_link")
+
+	admin.post("/admin/jobs/nope/run", nil).expectProblem(http.StatusNotFound, "job.not_found")
+	var requested handlers.JobInfo
+	admin.post("/admin/jobs/expire-email-changes/run", nil).expect(http.StatusAccepted).decode(&requested)
+	if requested.RequestedAt == nil {
+		t.Fatalf("run not requested: %+v", requested)
+	}
+	runs, err := jobs.RunDue(time.Now())
+	if err != nil || len(runs) != 1 || runs[0].Job != "expire-email-changes" || runs[0].Trigger != models.JobTriggerManual ||
+		runs[0].Status != models.JobSucceeded || runs[0].Affected != 1 {
+		t.Fatalf("unexpected manual run: %+v, %v", runs, err)
+	}
+	var pending int64
+	database.DB.Model(&models.UserEmailChange{}).Where("user_id = ?", user.ID).Count(&pending)
+	if pending != 0 {
+		t.Fatal("expired email change survived the job")
+	}
+
+	// Scheduled runs: expired sessions go, live ones stay; a run left running by a dead instance is closed
+	database.DB.Create(&models.UserSession{ID: uuid.New(), UserID: user.ID, TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Hour)})
+	stale := models.JobRun{ID: uuid.New(), Job: "prune-sessions", Status: models.JobRunning, Trigger: models.JobTriggerSchedule, Instance: "gone:1", StartedAt: time.Now().Add(-time.Hour)}
+	database.DB.Create(&stale)
+	database.DB.Model(&models.ScheduledJob{}).Where("name = ?", "prune-sessions").Update("next_run_at", time.Now().Add(-time.Minute))
+	runs, err = jobs.RunDue(time.Now())
+	if err != nil || len(runs) != 1 || runs[0].Trigger != models.JobTriggerSchedule || runs[0].Affected != 1 {
+		t.Fatalf("unexpected scheduled run: %+v, %v", runs, err)
+	}
+	c.get("/user").expect(http.StatusOK)
+
+	var history handlers.JobRunListResponse
+	admin.get("/admin/jobs/prune-sessions/runs").expect(http.StatusOK).decode(&history)
+	if len(history.Runs) != 2 || history.Runs[0].Status != models.JobSucceeded || history.Runs[1].ID != stale.ID ||
+		history.Runs[1].Status != models.JobFailed || history.Runs[1].Error == "" {
+		t.Fatalf("unexpected run history: %+v", history.Runs)
+	}
+
+	// The next occurrence is scheduled, and the last run shows in the list
+	admin.get("/admin/jobs").expect(http.StatusOK).decode(&list)
+	for _, job := range list.Jobs {
+		if job.Name == "prune-sessions" && (job.LastRun == nil || job.LastRun.ID != runs[0].ID || !job.NextRunAt.After(time.Now())) {
+			t.Fatalf("prune-sessions not rescheduled: %+v", job)
+		}
+	}
+	if runs, err := jobs.RunDue(time.Now()); err != nil || len(runs) != 0 {
+		t.Fatalf("jobs ran twice: %+v, %v", runs, err)
+	}
+}
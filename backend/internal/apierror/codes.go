@@ -0,0 +1,180 @@
+package apierror
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ✅ Code is one entry of the error code catalogue
+//
+// IDs are "<area>.<reason>", never change once published, and always map to
+// the same HTTP status. Clients branch on the ID, not on Title or Detail.
+type Code struct {
+	ID          string `json:"code"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	oauthError  string // RFC 6749 "error" value added to OAuth endpoint problems
+}
+
+var catalogue = map[string]*Code{}
+
+// ✅ Path of the catalogue that problem "type" URIs point at (moves with the API's mount prefix)
+var cataloguePath = "/errors"
+
+// ✅ Point problem "type" URIs at the catalogue under another path (e.g. "/auth/errors")
+func SetCataloguePath(path string) {
+	cataloguePath = path
+}
+
+// ✅ Register a catalogue entry
+func define(id string, status int, title, description string) *Code {
+	code := &Code{ID: id, Status: status, Title: title, Description: description}
+	if _, exists := catalogue[id]; exists {
+		panic("apierror: duplicate code " + id)
+	}
+	catalogue[id] = code
+	return code
+}
+
+// ✅ Register an OAuth catalogue entry (its problems also carry the RFC 6749 "error" member)
+func defineOAuth(id string, status int, oauthError, title, description string) *Code {
+	code := define(id, status, title, description)
+	code.oauthError = oauthError
+	return code
+}
+
+// ✅ New problem for this code (detail defaults to the title)
+func (code *Code) New() *Problem {
+	problem := &Problem{
+		Type:   cataloguePath + "#" + code.ID,
+		Title:  code.Title,
+		Status: code.Status,
+		Code:   code.ID,
+	}
+	if code.oauthError != "" {
+		problem.With("error", code.oauthError) // What OAuth client libraries branch on
+	}
+	return problem
+}
+
+// ✅ New problem with an occurrence-specific, human-readable detail
+func (code *Code) WithDetail(detail string) *Problem {
+	problem := code.New()
+	problem.Detail = detail
+	return problem
+}
+
+// ✅ Request errors
+var (
+	InvalidBody          = define("request.invalid_body", http.StatusBadRequest, "Invalid request body", "The body is not valid JSON, has the wrong shape or contains unknown fields.")
+	InvalidParameter     = define("request.invalid_parameter", http.StatusBadRequest, "Invalid parameter", "A path or query parameter (usually an ID) is malformed.")
+	ValidationFailed     = define("request.validation_failed", http.StatusUnprocessableEntity, "Validation failed", "One or more fields are invalid; see errors[] for each field.")
+	PayloadTooLarge      = define("request.payload_too_large", http.StatusRequestEntityTooLarge, "Payload too large", "The upload exceeds the size limit.")
+	UnsupportedMediaType = define("request.unsupported_media_type", http.StatusUnsupportedMediaType, "Unsupported media type", "The uploaded content is not an accepted type.")
+	RateLimited          = define("request.rate_limited", http.StatusTooManyRequests, "Too many requests", "Slow down and retry later.")
+	RouteNotFound        = define("request.route_not_found", http.StatusNotFound, "Not found", "No endpoint exists at this path.")
+)
+
+// ✅ Authentication errors
+var (
+	Unauthenticated         = define("auth.unauthenticated", http.StatusUnauthorized, "Authentication required", "No valid access token was presented.")
+	InvalidCredentials      = define("auth.invalid_credentials", http.StatusUnauthorized, "Invalid credentials", "The email or password is wrong.")
+	IncorrectPassword       = define("auth.incorrect_password", http.StatusUnauthorized, "Incorrect password", "The current password given to confirm an action is wrong.")
+	InvalidRefreshToken     = define("auth.invalid_refresh_token", http.StatusUnauthorized, "Invalid refresh token", "The refresh token is missing, expired or malformed.")
+	SessionRevoked          = define("auth.session_revoked", http.StatusUnauthorized, "Session has been revoked", "The session was signed out; sign in again.")
+	ReauthRequired          = define("auth.reauthentication_required", http.StatusForbidden, "Re-authentication required", "Confirm your password via POST /reauthenticate, then retry.")
+	PasswordPolicyViolation = define("auth.password_policy", http.StatusUnprocessableEntity, "Password does not meet the policy", "errors[] lists every failed rule.")
+	InvalidLink             = define("auth.invalid_link", http.StatusBadRequest, "Invalid or expired link", "An emailed link (reset, restore, confirmation, \"this wasn't me\") is invalid, used or expired.")
+	MagicLinkInvalid        = define("auth.magic_link_invalid", http.StatusUnauthorized, "Invalid or expired sign-in link", "The magic link is unknown, already used or expired.")
+	MagicLinkWrongBrowser   = define("auth.magic_link_wrong_browser", http.StatusUnauthorized, "Wrong browser", "Magic links only work in the browser that requested them.")
+	UserTokenRequired       = define("auth.user_token_required", http.StatusForbidden, "User token required", "Service (client credentials) tokens cannot call endpoints that act as a user.")
+	AdminRequired           = define("auth.admin_required", http.StatusForbidden, "Administrator required", "Only support staff may call this endpoint.")
+	ImpersonationBlocked    = define("auth.impersonation_blocked", http.StatusForbidden, "Not allowed while impersonating", "Impersonation tokens can only read: changes to the account, its sessions or organizations, data exports, new tokens and further impersonation are blocked.")
+	ImpersonationNotAllowed = define("auth.impersonation_not_allowed", http.StatusForbidden, "User cannot be impersonated", "Administrators (including yourself) cannot be impersonated.")
+	NotImpersonating        = define("auth.not_impersonating", http.StatusConflict, "Not impersonating", "The request was not made with an impersonation token.")
+	TokenGenerationFailed   = define("auth.token_generation_failed", http.StatusInternalServerError, "Token generation failed", "The server could not issue tokens.")
+)
+
+// ✅ User errors
+var (
+	UserNotFound     = define("user.not_found", http.StatusNotFound, "User not found", "No (active) account matches.")
+	EmailTaken       = define("user.email_taken", http.StatusConflict, "Email already registered", "Another account uses this email address.")
+	UsernameTaken    = define("user.username_taken", http.StatusConflict, "Username already taken", "Another account uses this username (case-insensitively).")
+	UsernameHeld     = define("user.username_held", http.StatusConflict, "Username recently released", "The name belonged to another account recently and is on hold.")
+	UsernameCooldown = define("user.username_change_cooldown", http.StatusTooManyRequests, "Username changed recently", "Usernames can only be changed once per cooldown; see retry_after.")
+	AvatarInvalid    = define("user.avatar_invalid", http.StatusUnprocessableEntity, "Invalid avatar", "The image could not be decoded or is too large in pixels.")
+	DeviceNotFound   = define("user.device_not_found", http.StatusNotFound, "Device not found", "No trusted device with this ID belongs to you.")
+	UserCodeInvalid  = define("user.device_code_invalid", http.StatusNotFound, "Invalid or expired device code", "No pending device sign-in has this code; it may have expired or been answered already.")
+)
+
+// ✅ Organization errors
+var (
+	OrgNotFound              = define("org.not_found", http.StatusNotFound, "Organization not found", "No organization with this ID.")
+	OrgNotMember             = define("org.not_member", http.StatusForbidden, "Not a member of this organization", "You are not a member of the organization.")
+	OrgInsufficientRole      = define("org.insufficient_role", http.StatusForbidden, "Insufficient permissions", "Your role in the organization is too low.")
+	OrgRoleNotAssignable     = define("org.role_not_assignable", http.StatusForbidden, "Cannot assign this role", "You may only assign roles up to your own.")
+	OrgMemberNotRemovable    = define("org.member_not_removable", http.StatusForbidden, "Cannot remove this member", "The member outranks you.")
+	OrgSlugTaken             = define("org.slug_taken", http.StatusConflict, "Organization slug already taken", "Pick another slug.")
+	OrgAlreadyMember         = define("org.already_member", http.StatusConflict, "User is already a member", "The user already belongs to the organization.")
+	OrgLastOwner             = define("org.last_owner", http.StatusConflict, "Organization must keep at least one owner", "Promote another owner first.")
+	OrgMemberNotFound        = define("org.member_not_found", http.StatusNotFound, "Member not found", "The user is not a member of the organization.")
+	InvitationNotFound       = define("org.invitation_not_found", http.StatusNotFound, "Invitation not found", "No invitation with this ID in the organization.")
+	InvitationInvalid        = define("org.invitation_invalid", http.StatusNotFound, "Invalid or expired invitation", "The invitation link is unknown, revoked, accepted or expired.")
+	InvitationAlreadyExists  = define("org.invitation_pending", http.StatusConflict, "Invitation already pending", "A pending invitation exists for this email.")
+	InvitationNotPending     = define("org.invitation_not_pending", http.StatusConflict, "Invitation is no longer pending", "The invitation was accepted, revoked or has expired.")
+	InvitationAccountDeleted = define("org.invitation_account_deleted", http.StatusConflict, "Invited account is deleted", "The invited email belongs to a deleted account. Restore it with the link in the deletion email, then accept the invitation.")
+)
+
+// ✅ Webhook errors (admin API)
+var (
+	WebhookNotFound         = define("webhook.not_found", http.StatusNotFound, "Webhook not found", "No webhook endpoint with this ID.")
+	WebhookDeliveryNotFound = define("webhook.delivery_not_found", http.StatusNotFound, "Delivery not found", "No delivery with this ID for the webhook endpoint.")
+)
+
+// ✅ Job errors (admin API)
+var (
+	JobNotFound = define("job.not_found", http.StatusNotFound, "Job not found", "No scheduled job with this name runs on this server.")
+)
+
+// ✅ OAuth errors (clients calling /token, /device/code, /introspect and /revoke)
+var (
+	InvalidClient        = defineOAuth("oauth.invalid_client", http.StatusUnauthorized, "invalid_client", "Client authentication failed", "The client credentials are wrong or use another method than registered, or the client is disabled.")
+	InvalidOAuthRequest  = defineOAuth("oauth.invalid_request", http.StatusBadRequest, "invalid_request", "Invalid OAuth request", "A required form parameter is missing or malformed; see errors[].")
+	UnauthorizedClient   = defineOAuth("oauth.unauthorized_client", http.StatusBadRequest, "unauthorized_client", "Grant not allowed for this client", "Public clients may only use the device_code and refresh_token grants.")
+	UnsupportedGrantType = defineOAuth("oauth.unsupported_grant_type", http.StatusBadRequest, "unsupported_grant_type", "Unsupported grant type", "The token endpoint does not support this grant_type.")
+	InvalidScope         = defineOAuth("oauth.invalid_scope", http.StatusBadRequest, "invalid_scope", "Invalid scope", "A requested scope is malformed or not allowed for this client.")
+	InvalidGrant         = defineOAuth("oauth.invalid_grant", http.StatusBadRequest, "invalid_grant", "Invalid grant", "The device code or refresh token is unknown, already used, revoked or issued to another client.")
+	AuthorizationPending = defineOAuth("oauth.authorization_pending", http.StatusBadRequest, "authorization_pending", "Authorization pending", "The user has not answered the device sign-in yet; keep polling at the interval.")
+	SlowDown             = defineOAuth("oauth.slow_down", http.StatusBadRequest, "slow_down", "Polling too fast", "Poll less often: the interval grew by 5 seconds (RFC 8628 §3.5).")
+	AccessDenied         = defineOAuth("oauth.access_denied", http.StatusBadRequest, "access_denied", "Access denied", "The user declined the device sign-in.")
+	ExpiredToken         = defineOAuth("oauth.expired_token", http.StatusBadRequest, "expired_token", "Device code expired", "The device code expired before the user answered; start over at /device/code.")
+)
+
+// ✅ Server errors
+var (
+	Internal       = define("internal.error", http.StatusInternalServerError, "Internal server error", "Something went wrong on our side; retry later and quote request_id if it persists.")
+	UpstreamFailed = define("internal.upstream_failed", http.StatusBadGateway, "Upstream service failed", "A dependency (object storage, email) failed.")
+)
+
+// ✅ All codes, sorted by ID
+func Catalogue() []*Code {
+	codes := make([]*Code, 0, len(catalogue))
+	for _, code := range catalogue {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].ID < codes[j].ID })
+	return codes
+}
+
+// ✅ Body of GET /errors
+type CatalogueResponse struct {
+	Codes []*Code `json:"codes"`
+}
+
+// ✅ Serve the catalogue (GET /errors); problem "type" URIs point here
+func CatalogueHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, CatalogueResponse{Codes: Catalogue()})
+}
@@ -0,0 +1,130 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ✅ Media type of every error response
+const ContentType = "application/problem+json"
+
+// ✅ Problem is an RFC 7807 problem details object
+//
+// Type points at the code catalogue (GET /errors), Code is the stable
+// machine-readable identifier clients should branch on, and Title / Detail are
+// for humans. Extensions are flattened into the top-level JSON object.
+type Problem struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Status     int                    `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Instance   string                 `json:"instance,omitempty"`
+	Code       string                 `json:"code"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	Errors     []FieldError           `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// ✅ FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Code + ": " + p.Detail
+	}
+	return p.Code + ": " + p.Title
+}
+
+// ✅ Add field-level details
+func (p *Problem) WithFields(fields ...FieldError) *Problem {
+	p.Errors = append(p.Errors, fields...)
+	return p
+}
+
+// ✅ Add an extension member (e.g. "retry_after")
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// ✅ Encode standard members and extensions as one object
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+	base, err := json.Marshal((*plain)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return base, err
+	}
+
+	members := map[string]interface{}{}
+	if err := json.Unmarshal(base, &members); err != nil {
+		return nil, err
+	}
+	for key, value := range p.Extensions {
+		if _, reserved := members[key]; !reserved {
+			members[key] = value
+		}
+	}
+	return json.Marshal(members)
+}
+
+// ✅ Abort the request with an error; Middleware renders it
+//
+// Errors that are not a *Problem are logged and reported as internal.error
+// so implementation details never reach the client.
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// ✅ Render the last error of a request as problem+json
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		var problem *Problem
+		if !errors.As(err, &problem) {
+			log.Println("❌ Unhandled error:", err)
+			problem = Internal.New()
+		}
+		Write(c, problem)
+	}
+}
+
+// ✅ Write a problem immediately (for code outside the Middleware chain)
+func Write(c *gin.Context, problem *Problem) {
+	if problem.Instance == "" {
+		problem.Instance = c.Request.URL.Path
+	}
+	if problem.RequestID == "" {
+		problem.RequestID = c.GetString(RequestIDKey)
+	}
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		log.Println("❌ Failed to encode problem:", err)
+		body = []byte(`{"type":"` + cataloguePath + `#internal.error","title":"Internal server error","status":500,"code":"internal.error"}`)
+		problem.Status = http.StatusInternalServerError
+	}
+	c.Data(problem.Status, ContentType, body)
+}
+
+// ✅ Validation problem for a single invalid field
+func InvalidField(field, code, message string) *Problem {
+	return ValidationFailed.New().WithFields(FieldError{Field: field, Code: code, Message: message})
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ✅ Single-purpose signed link token (e.g. account restore)
+//
+// Signed with a key derived from JWT_SECRET so it can never be replayed as an
+// access token, and scoped to one purpose.
+type ActionClaims struct {
+	UserID  string `json:"user_id"`
+	Purpose string `json:"purpose"`
+	Ref     int64  `json:"ref,omitempty"`    // Caller-defined binding (e.g. the deletion timestamp)
+	Target  string `json:"target,omitempty"` // Optional object the action applies to (e.g. a session ID)
+	jwt.RegisteredClaims
+}
+
+// ✅ Key for action tokens, derived from the access token secret
+var actionSecret = func() []byte {
+	sum := sha256.Sum256(append([]byte("arcadiago-action-token:"), jwtSecret...))
+	return sum[:]
+}()
+
+// ✅ Generate an Action Token valid until expiresAt
+func GenerateActionToken(purpose string, userID uuid.UUID, ref int64, target string, expiresAt time.Time) (string, error) {
+	claims := &ActionClaims{
+		UserID:  userID.String(),
+		Purpose: purpose,
+		Ref:     ref,
+		Target:  target,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(), // Lets single-use links be marked as used
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(actionSecret)
+}
+
+// ✅ Validate an Action Token for a specific purpose
+func ValidateActionToken(tokenString, purpose string) (*ActionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ActionClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return actionSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(*ActionClaims)
+	if !ok || claims.Purpose != purpose {
+		return nil, errors.New("invalid token purpose")
+	}
+	return claims, nil
+}
+
+// ✅ Stable int64 fingerprint of a secret (binds a token to e.g. the current password hash)
+func SecretFingerprint(secret string) int64 {
+	sum := sha256.Sum256([]byte(secret))
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
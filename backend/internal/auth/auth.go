@@ -5,20 +5,34 @@ import (
 	"log"
 	"os"
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // ✅ JWT Claims Struct
+//
+// User tokens carry UserID (and a session); service tokens from the
+// client_credentials grant carry ClientID and no user at all. Impersonation
+// tokens are user tokens that also name the admin behind them in Actor.
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID       string        `json:"user_id,omitempty"`
+	OrgID        string        `json:"org_id,omitempty"`    // Active organization (empty when none)
+	SessionID    string        `json:"sid,omitempty"`       // UserSession the token belongs to
+	AuthTime     int64         `json:"auth_time,omitempty"` // When the user last entered their credentials (Unix seconds)
+	Scope        string        `json:"scope,omitempty"`     // Space-separated scopes (RFC 8693); empty for full user sessions
+	ClientID     string        `json:"client_id,omitempty"` // Service client a client_credentials token was issued to
+	Confirmation *Confirmation `json:"cnf,omitempty"`       // Certificate the token is bound to (mTLS clients)
+	Actor        *Actor        `json:"act,omitempty"`       // Admin acting as the user (impersonation tokens only)
 	jwt.RegisteredClaims
 }
 
+// ✅ Whether this is a service (client_credentials) token rather than a user's
+func (c *Claims) IsService() bool {
+	return c.UserID == "" && c.ClientID != ""
+}
+
 // ✅ Load JWT Secrets Securely
 func getSecret(envVar string, defaultValue string) []byte {
 	secret := os.Getenv(envVar)
@@ -32,62 +46,38 @@ func getSecret(envVar string, defaultValue string) []byte {
 var jwtSecret = getSecret("JWT_SECRET", "default-secret-key-should-be-longer-than-this")
 var jwtRefreshSecret = getSecret("JWT_REFRESH_SECRET", "default-refresh-key-should-be-longer")
 
-// ✅ Regex for password, username, and email validation
+// ✅ Usernames: letters, numbers, _ and . (3-32 chars)
+const UsernamePattern = `^[a-zA-Z0-9_.]{3,32}$`
+
+// ✅ Regex for username and email validation
 var (
-	passwordRegex = regexp.MustCompile(`^[A-Za-z\d@$!%*?&.]{8,64}$`)
-	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.]{3,32}$`) // Allows letters, numbers, _ and . (3-32 chars)
+	usernameRegex = regexp.MustCompile(UsernamePattern)
 	emailRegex    = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 )
 
-// ✅ Validate Password Strength
+// ✅ Validate Password against the configured policy
 func ValidatePassword(password string) error {
-	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters long")
-	}
-	if len(password) > 64 {
-		return errors.New("password must not exceed 64 characters")
-	}
-	if !passwordRegex.MatchString(password) {
-		return errors.New("password contains invalid characters")
-	}
-
-	hasUpper, hasLower, hasNumber, hasSpecial := false, false, false, false
-	specialChars := "@$!%*?&."
-
-	for _, char := range password {
-		switch {
-		case char >= 'A' && char <= 'Z':
-			hasUpper = true
-		case char >= 'a' && char <= 'z':
-			hasLower = true
-		case char >= '0' && char <= '9':
-			hasNumber = true
-		case strings.ContainsRune(specialChars, char):
-			hasSpecial = true
-		}
-	}
+	return passwordPolicy.Check(password)
+}
 
-	if !hasUpper {
-		return errors.New("password must contain at least 1 uppercase letter")
-	}
-	if !hasLower {
-		return errors.New("password must contain at least 1 lowercase letter")
-	}
-	if !hasNumber {
-		return errors.New("password must contain at least 1 number")
-	}
-	if !hasSpecial {
-		return errors.New("password must contain at least 1 special character (@$!%*?&)")
-	}
+// ✅ Validate only the password's encoding and length (cheap enough for request binding)
+func ValidatePasswordSyntax(password string) error {
+	return passwordPolicy.CheckSyntax(password)
+}
 
-	return nil
+// ✅ Validate Password for a specific user (also rejects their username / email)
+func ValidatePasswordForUser(password, username, email string) error {
+	return passwordPolicy.Check(password, username, email)
 }
 
-// ✅ Validate Username
+// ✅ Validate Username (format and reserved / look-alike names)
 func ValidateUsername(username string) error {
 	if !usernameRegex.MatchString(username) {
 		return errors.New("username must be 3-32 characters and only contain letters, numbers, underscores, or dots")
 	}
+	if usernameBlocklist.IsReserved(username) {
+		return ErrUsernameReserved
+	}
 	return nil
 }
 
@@ -99,58 +89,120 @@ func ValidateEmail(email string) error {
 	return nil
 }
 
-// ✅ Hash Password
+// ✅ Hash Password with the active hasher (callers validate against the policy first)
 func HashPassword(password string) (string, error) {
-	if err := ValidatePassword(password); err != nil {
-		return "", err
-	}
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := passwordHasher.Hash(password)
 	if err != nil {
 		log.Println("❌ Error hashing password:", err)
 		return "", err
 	}
-	return string(hashedPassword), nil
+	return hashedPassword, nil
 }
 
-// ✅ Check Password Hash
+// ✅ Check Password Hash (any supported algorithm)
 func CheckPassword(hashedPassword, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	hasher, err := hasherFor(hashedPassword)
+	if err != nil {
+		log.Println("❌ Password verification failed:", err)
+		return false
+	}
+
+	ok, err := hasher.Verify(hashedPassword, password)
 	if err != nil {
 		log.Println("❌ Password verification failed:", err)
 		return false
 	}
-	return true
+	return ok
+}
+
+// ✅ Check whether a stored hash should be upgraded to the active algorithm / parameters
+func PasswordNeedsRehash(hashedPassword string) bool {
+	if !passwordHasher.Supports(hashedPassword) {
+		return true
+	}
+	return passwordHasher.NeedsRehash(hashedPassword)
 }
 
-// ✅ Generate JWT Access Token (1 hour expiry)
-func GenerateAccessToken(userID uuid.UUID) (string, error) {
-	return generateToken(userID.String(), jwtSecret, time.Hour)
+// ✅ Who a token is issued to and which session it belongs to
+type TokenSubject struct {
+	UserID    uuid.UUID
+	OrgID     uuid.UUID // uuid.Nil when no organization is active
+	SessionID uuid.UUID // UserSession row the token is bound to
+	AuthTime  time.Time // Last time the user proved their credentials
 }
 
+// ✅ Generate JWT Access Token (1 hour expiry, signed with the JWKS key when one is configured)
+func GenerateAccessToken(subject TokenSubject) (string, error) {
+	return signAccessClaims(newClaims(subject, AccessTokenTTL))
+}
+
+// ✅ Access Token lifetime
+const AccessTokenTTL = time.Hour
+
 // ✅ Generate JWT Refresh Token (7 days expiry)
-func GenerateRefreshToken(userID uuid.UUID) (string, error) {
-	return generateToken(userID.String(), jwtRefreshSecret, 7*24*time.Hour)
+func GenerateRefreshToken(subject TokenSubject) (string, error) {
+	return generateToken(subject, jwtRefreshSecret, RefreshTokenTTL)
 }
 
-// ✅ Core JWT Token Generation Function
-func generateToken(userID string, secret []byte, expiry time.Duration) (string, error) {
-	if len(secret) < 32 {
-		log.Println("⚠️ WARNING: JWT secret is too short. Use at least 32 characters!")
+// ✅ Refresh Token (and therefore session) lifetime
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// ✅ Encode an optional UUID claim (omitted when nil)
+func optionalClaim(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
 	}
+	return id.String()
+}
 
-	expirationTime := time.Now().Add(expiry)
+// ✅ Encode the auth_time claim (omitted when unknown)
+func authTimeClaim(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
 
-	claims := &Claims{
-		UserID: userID,
+// ✅ Claims for a token issued now
+func newClaims(subject TokenSubject, expiry time.Duration) *Claims {
+	now := time.Now()
+	return &Claims{
+		UserID:    subject.UserID.String(),
+		OrgID:     optionalClaim(subject.OrgID),
+		SessionID: optionalClaim(subject.SessionID),
+		AuthTime:  authTimeClaim(subject.AuthTime),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			Subject:   subject.UserID.String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// ✅ Sign access token claims: with the JWKS key when one is configured, else HS256
+func signAccessClaims(claims *Claims) (string, error) {
+	if accessKey != nil {
+		signed, err := accessKey.sign(claims)
+		if err != nil {
+			log.Println("❌ Error signing JWT:", err)
+		}
+		return signed, err
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	if err != nil {
+		log.Println("❌ Error signing JWT:", err)
+	}
+	return signed, err
+}
+
+// ✅ Core JWT Token Generation Function
+func generateToken(subject TokenSubject, secret []byte, expiry time.Duration) (string, error) {
+	if len(secret) < 32 {
+		log.Println("⚠️ WARNING: JWT secret is too short. Use at least 32 characters!")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims(subject, expiry))
 	signedToken, err := token.SignedString(secret)
 	if err != nil {
 		log.Println("❌ Error signing JWT:", err)
@@ -167,9 +219,19 @@ func ValidateToken(tokenString string, isRefresh bool) (*Claims, error) {
 		secret = jwtRefreshSecret
 	}
 
+	// ✅ Access tokens use the JWKS key when one is configured, everything else HS256
+	method := jwt.SigningMethod(jwt.SigningMethodHS256)
+	var key interface{} = secret
+	if !isRefresh && accessKey != nil {
+		method, key = accessKey.method, accessKey.signer.Public()
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
+		if method != jwt.SigningMethodHS256 && token.Header["kid"] != accessKey.id {
+			return nil, errors.New("unknown signing key")
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{method.Alg()}))
 
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid token")
@@ -185,5 +247,13 @@ func ValidateToken(tokenString string, isRefresh bool) (*Claims, error) {
 		return nil, errors.New("token has expired")
 	}
 
+	// ✅ Every token is issued to someone; refresh tokens only ever to users
+	if claims.UserID == "" && (isRefresh || claims.ClientID == "") {
+		return nil, errors.New("token has no subject")
+	}
+	if claims.Actor != nil && (isRefresh || claims.Actor.Subject == "") {
+		return nil, errors.New("malformed actor claim")
+	}
+
 	return claims, nil
 }
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ✅ BreachChecker reports whether a password appears in a breach corpus
+type BreachChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// ✅ Active Breach Checker (range API, bloom filter, or disabled)
+var breachChecker = loadBreachChecker()
+
+// ✅ Replace the Breach Checker (e.g. with a preloaded filter)
+func SetBreachChecker(checker BreachChecker) {
+	if checker == nil {
+		checker = noBreachChecker{}
+	}
+	breachChecker = checker
+}
+
+// ✅ Pick a Breach Checker from the environment
+//
+// PWNED_PASSWORDS_BLOOM takes precedence so the service works fully offline.
+// PWNED_PASSWORDS_URL may point at api.pwnedpasswords.com or a local mirror
+// that serves the same /range/{prefix} format.
+func loadBreachChecker() BreachChecker {
+	if path := os.Getenv("PWNED_PASSWORDS_BLOOM"); path != "" {
+		filter, err := LoadBloomFilter(path)
+		if err != nil {
+			log.Printf("⚠️ WARNING: Could not load breached password filter %s: %v", path, err)
+		} else {
+			log.Println("✅ Loaded breached password bloom filter:", path)
+			return filter
+		}
+	}
+
+	if baseURL := os.Getenv("PWNED_PASSWORDS_URL"); baseURL != "" {
+		return NewRangeAPIChecker(baseURL)
+	}
+
+	return noBreachChecker{}
+}
+
+// ✅ Disabled Breach Checker
+type noBreachChecker struct{}
+
+func (noBreachChecker) IsBreached(string) (bool, error) { return false, nil }
+
+// ✅ SHA-1 digest of a password, as used by the Pwned Passwords corpus
+func passwordSHA1(password string) [sha1.Size]byte {
+	return sha1.Sum([]byte(password))
+}
+
+// ✅ k-Anonymity Range API Checker
+//
+// Only the first five hex characters of the SHA-1 hash leave the process; the
+// matching suffixes are compared locally.
+type RangeAPIChecker struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// ✅ Create a Range API Checker
+func NewRangeAPIChecker(baseURL string) *RangeAPIChecker {
+	return &RangeAPIChecker{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+func (r *RangeAPIChecker) IsBreached(password string) (bool, error) {
+	digest := passwordSHA1(password)
+	hash := strings.ToUpper(hex.EncodeToString(digest[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequest(http.MethodGet, r.BaseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Add-Padding", "true") // Hide the response size from observers
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("range API returned status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found || !strings.EqualFold(candidate, suffix) {
+			continue
+		}
+		// Padding entries are returned with a count of zero
+		return strings.TrimSpace(count) != "0", nil
+	}
+	return false, scanner.Err()
+}
+
+// ✅ Bloom Filter Checker (offline breach corpus)
+//
+// File format: "PWBF" magic, uint32 hash count, uint64 bit count, then the bit
+// array. Entries are raw SHA-1 digests, so filters can be built straight from
+// the Pwned Passwords SHA-1 download with cmd/pwnedbloom.
+type BloomFilter struct {
+	hashes uint32
+	bits   uint64
+	set    []byte
+}
+
+var bloomMagic = [4]byte{'P', 'W', 'B', 'F'}
+
+// ✅ Upper bound on the hash count (an optimal filter at p = 1e-12 needs 40)
+const maxBloomHashes = 64
+
+// ✅ Optimal bit and hash counts for n entries at false-positive rate p
+func OptimalBloomSize(n uint64, p float64) (uint64, uint32) {
+	if n == 0 {
+		n = 1
+	}
+	bits := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	hashes := math.Round(bits / float64(n) * math.Ln2)
+	return uint64(bits), uint32(math.Max(hashes, 1))
+}
+
+// ✅ Create an empty Bloom Filter
+func NewBloomFilter(bits uint64, hashes uint32) *BloomFilter {
+	if bits == 0 {
+		bits = 8
+	}
+	if hashes == 0 {
+		hashes = 1
+	}
+	return &BloomFilter{hashes: hashes, bits: bits, set: make([]byte, (bits+7)/8)}
+}
+
+// ✅ Bit positions for a SHA-1 digest (Kirsch-Mitzenmacher double hashing)
+func (b *BloomFilter) positions(digest [sha1.Size]byte) []uint64 {
+	h1 := binary.BigEndian.Uint64(digest[0:8])
+	h2 := binary.BigEndian.Uint64(digest[8:16]) | 1
+	positions := make([]uint64, b.hashes)
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % b.bits
+	}
+	return positions
+}
+
+// ✅ Add a raw SHA-1 digest to the filter
+func (b *BloomFilter) AddDigest(digest [sha1.Size]byte) {
+	for _, pos := range b.positions(digest) {
+		b.set[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+// ✅ Test a raw SHA-1 digest against the filter
+func (b *BloomFilter) ContainsDigest(digest [sha1.Size]byte) bool {
+	for _, pos := range b.positions(digest) {
+		if b.set[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BloomFilter) IsBreached(password string) (bool, error) {
+	return b.ContainsDigest(passwordSHA1(password)), nil
+}
+
+// ✅ Serialize the filter
+func (b *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, 16)
+	copy(header[0:4], bloomMagic[:])
+	binary.BigEndian.PutUint32(header[4:8], b.hashes)
+	binary.BigEndian.PutUint64(header[8:16], b.bits)
+
+	n, err := w.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(b.set)
+	return int64(n + m), err
+}
+
+// ✅ Load a serialized filter from disk
+func LoadBloomFilter(path string) (*BloomFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, err
+	}
+	if [4]byte(header[0:4]) != bloomMagic {
+		return nil, errors.New("not a breached password bloom filter")
+	}
+
+	// ✅ Check the header against the file before allocating the bit array
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	bits, hashes := binary.BigEndian.Uint64(header[8:16]), binary.BigEndian.Uint32(header[4:8])
+	if bits == 0 || hashes == 0 || hashes > maxBloomHashes {
+		return nil, fmt.Errorf("invalid bloom filter header (%d bits, %d hashes)", bits, hashes)
+	}
+	if size := uint64(info.Size()) - uint64(len(header)); bits > math.MaxUint64-7 || (bits+7)/8 != size {
+		return nil, fmt.Errorf("bloom filter header claims %d bits but the file holds %d bytes", bits, size)
+	}
+
+	filter := NewBloomFilter(bits, hashes)
+	if _, err := io.ReadFull(file, filter.set); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ✅ Who a client_credentials token is issued to
+type ClientSubject struct {
+	ClientID       string
+	Scope          string // Space-separated granted scopes
+	CertThumbprint string // Set for mTLS clients: the token is bound to their certificate (RFC 8705)
+}
+
+// ✅ Confirmation claim of a certificate-bound token (RFC 8705 §3.1)
+type Confirmation struct {
+	X5tS256 string `json:"x5t#S256"` // SHA-256 thumbprint of the client certificate
+}
+
+// ✅ Generate a service access token (no user, no session)
+func GenerateClientToken(subject ClientSubject, expiry time.Duration) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		ClientID: subject.ClientID,
+		Scope:    subject.Scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject.ClientID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+	if subject.CertThumbprint != "" {
+		claims.Confirmation = &Confirmation{X5tS256: subject.CertThumbprint}
+	}
+	return signAccessClaims(claims)
+}
+
+// ✅ RFC 8705 thumbprint of a certificate (base64url SHA-256 of its DER encoding)
+func CertThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ✅ client_assertion_type of a private_key_jwt assertion (RFC 7523 §2.2)
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// ✅ Longest accepted assertion lifetime (bounds how long used IDs must be remembered)
+const MaxClientAssertionTTL = 5 * time.Minute
+
+// ✅ Parse a PEM public key a client registered for private_key_jwt
+func ParseClientPublicKey(data string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("RSA key must be at least 2048 bits")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return key, nil
+}
+
+// ✅ Issuer of a client assertion, read before its client (and key) is known
+func ClientAssertionIssuer(assertion string) (string, error) {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(assertion, &claims); err != nil {
+		return "", errors.New("malformed client assertion")
+	}
+	return claims.Issuer, nil
+}
+
+// ✅ Verify a private_key_jwt client assertion (RFC 7523 §3)
+//
+// It must be signed with the client's key, issued by and about the client,
+// addressed to one of audiences, short-lived and carry a jti; the caller
+// rejects jti values it has seen before.
+func VerifyClientAssertion(assertion, clientID, publicKeyPEM string, audiences []string) (*jwt.RegisteredClaims, error) {
+	key, err := ParseClientPublicKey(publicKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("client key: %w", err)
+	}
+
+	var algs []string
+	switch key.(type) {
+	case *ecdsa.PublicKey:
+		algs = []string{"ES256", "ES384", "ES512"}
+	case *rsa.PublicKey:
+		algs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case ed25519.PublicKey:
+		algs = []string{"EdDSA"}
+	}
+
+	token, err := jwt.ParseWithClaims(assertion, &jwt.RegisteredClaims{}, func(*jwt.Token) (interface{}, error) {
+		return key, nil
+	}, jwt.WithValidMethods(algs), jwt.WithExpirationRequired(), jwt.WithIssuer(clientID), jwt.WithSubject(clientID), jwt.WithLeeway(30*time.Second))
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid client assertion")
+	}
+
+	claims := token.Claims.(*jwt.RegisteredClaims)
+	if !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return slices.Contains(audiences, strings.TrimRight(aud, "/"))
+	}) {
+		return nil, errors.New("client assertion is for another audience")
+	}
+	if claims.ID == "" {
+		return nil, errors.New("client assertion has no jti")
+	}
+	if time.Until(claims.ExpiresAt.Time) > MaxClientAssertionTTL {
+		return nil, errors.New("client assertion lives too long")
+	}
+	return claims, nil
+}
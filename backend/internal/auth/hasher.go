@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ✅ PasswordHasher hashes and verifies passwords in a self-describing format
+type PasswordHasher interface {
+	// Hash returns an encoded hash that embeds the algorithm and its parameters
+	Hash(password string) (string, error)
+	// Verify reports whether password matches an encoded hash produced by this hasher
+	Verify(encoded, password string) (bool, error)
+	// Supports reports whether the encoded hash was produced by this algorithm
+	Supports(encoded string) bool
+	// NeedsRehash reports whether the encoded hash uses outdated parameters
+	NeedsRehash(encoded string) bool
+}
+
+var errUnknownHashFormat = errors.New("unknown password hash format")
+
+// ✅ Active Password Hasher (new hashes) plus every hasher that can still verify
+var (
+	passwordHasher  = loadPasswordHasher()
+	passwordHashers = []PasswordHasher{
+		passwordHasher,
+		&Argon2idHasher{},
+		&BcryptHasher{Cost: bcrypt.DefaultCost},
+	}
+)
+
+// ✅ Pick the Password Hasher from the environment (Argon2id by default)
+func loadPasswordHasher() PasswordHasher {
+	switch strings.ToLower(os.Getenv("PASSWORD_HASHER")) {
+	case "bcrypt":
+		return &BcryptHasher{Cost: getEnvInt("BCRYPT_COST", bcrypt.DefaultCost)}
+	case "", "argon2id":
+		hasher, err := argon2idFromEnv()
+		if err != nil {
+			log.Fatalf("❌ Invalid Argon2id settings: %v", err)
+		}
+		return hasher
+	default:
+		log.Fatalf("❌ Unsupported PASSWORD_HASHER: %s", os.Getenv("PASSWORD_HASHER"))
+		return nil
+	}
+}
+
+// ✅ Argon2id Hasher from the ARGON2_* environment variables
+func argon2idFromEnv() (*Argon2idHasher, error) {
+	memory, iterations := getEnvInt("ARGON2_MEMORY_KIB", 64*1024), getEnvInt("ARGON2_ITERATIONS", 3)
+	parallelism := getEnvInt("ARGON2_PARALLELISM", 2)
+	saltLength, keyLength := getEnvInt("ARGON2_SALT_LENGTH", 16), getEnvInt("ARGON2_KEY_LENGTH", 32)
+
+	// Range-check before narrowing, so e.g. a parallelism of 256 does not wrap to 0
+	switch {
+	case memory < 0 || memory > maxArgon2Memory:
+		return nil, fmt.Errorf("ARGON2_MEMORY_KIB must be at most %d", maxArgon2Memory)
+	case iterations < 0 || iterations > maxArgon2Iterations:
+		return nil, fmt.Errorf("ARGON2_ITERATIONS must be at most %d", maxArgon2Iterations)
+	case parallelism < 1 || parallelism > math.MaxUint8:
+		return nil, fmt.Errorf("ARGON2_PARALLELISM must be 1-%d", math.MaxUint8)
+	case saltLength < minArgon2SaltLength || saltLength > 1024:
+		return nil, fmt.Errorf("ARGON2_SALT_LENGTH must be %d-1024", minArgon2SaltLength)
+	case keyLength < minArgon2KeyLength || keyLength > 1024:
+		return nil, fmt.Errorf("ARGON2_KEY_LENGTH must be %d-1024", minArgon2KeyLength)
+	}
+
+	hasher := &Argon2idHasher{
+		Memory:      uint32(memory),
+		Iterations:  uint32(iterations),
+		Parallelism: uint8(parallelism),
+		SaltLength:  uint32(saltLength),
+		KeyLength:   uint32(keyLength),
+	}
+	return hasher, hasher.checkCost()
+}
+
+// ✅ Replace the Password Hasher used for new hashes
+func SetPasswordHasher(hasher PasswordHasher) {
+	passwordHasher = hasher
+	passwordHashers[0] = hasher
+}
+
+// ✅ Find the hasher that understands an encoded hash
+func hasherFor(encoded string) (PasswordHasher, error) {
+	for _, hasher := range passwordHashers {
+		if hasher.Supports(encoded) {
+			return hasher, nil
+		}
+	}
+	return nil, errUnknownHashFormat
+}
+
+// ✅ Argon2id Hasher (PHC string format)
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// ✅ Bounds on Argon2id parameters, whether configured or read from a stored hash
+const (
+	maxArgon2Memory     = 1024 * 1024 // KiB (1 GiB)
+	maxArgon2Iterations = 64
+	minArgon2SaltLength = 8
+	minArgon2KeyLength  = 16
+)
+
+// ✅ Check the cost parameters (argon2.IDKey panics when p or t is 0)
+func (h *Argon2idHasher) checkCost() error {
+	switch {
+	case h.Parallelism < 1:
+		return errors.New("argon2 parallelism must be at least 1")
+	case h.Iterations < 1 || h.Iterations > maxArgon2Iterations:
+		return fmt.Errorf("argon2 iterations must be 1-%d", maxArgon2Iterations)
+	case h.Memory < 8*uint32(h.Parallelism) || h.Memory > maxArgon2Memory:
+		return fmt.Errorf("argon2 memory must be %d-%d KiB", 8*uint32(h.Parallelism), maxArgon2Memory)
+	}
+	return nil
+}
+
+// ✅ Parameters decoded from an Argon2id PHC string
+type argon2idHash struct {
+	params Argon2idHasher
+	salt   []byte
+	key    []byte
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	if err := h.checkCost(); err != nil {
+		return "", err
+	}
+	if h.SaltLength < minArgon2SaltLength || h.KeyLength < minArgon2KeyLength {
+		return "", fmt.Errorf("argon2 salt must be at least %d bytes and key at least %d", minArgon2SaltLength, minArgon2KeyLength)
+	}
+
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(encoded, password string) (bool, error) {
+	decoded, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	p := decoded.params
+	key := argon2.IDKey([]byte(password), decoded.salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(decoded.key)))
+	return subtle.ConstantTimeCompare(key, decoded.key) == 1, nil
+}
+
+func (h *Argon2idHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	decoded, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+
+	p := decoded.params
+	return p.Memory != h.Memory || p.Iterations != h.Iterations || p.Parallelism != h.Parallelism ||
+		uint32(len(decoded.salt)) != h.SaltLength || uint32(len(decoded.key)) != h.KeyLength
+}
+
+// ✅ Decode an Argon2id PHC string
+func decodeArgon2id(encoded string) (*argon2idHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, err
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	decoded := &argon2idHash{}
+	p := &decoded.params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return nil, err
+	}
+	if err := p.checkCost(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, err
+	}
+	if decoded.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, err
+	}
+	if len(decoded.salt) < minArgon2SaltLength || len(decoded.key) < minArgon2KeyLength {
+		return nil, errors.New("argon2 salt or key is too short") // An empty key would match any password
+	}
+	p.SaltLength, p.KeyLength = uint32(len(decoded.salt)), uint32(len(decoded.key))
+	return decoded, nil
+}
+
+// ✅ bcrypt Hasher (kept so existing hashes still verify)
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	// bcrypt silently ignores everything past 72 bytes
+	if len(password) > 72 {
+		return "", errors.New("password is too long for bcrypt (72 bytes max)")
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Verify(encoded, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap parameters so the tests stay fast
+var testArgon2 = &Argon2idHasher{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHashAndVerify(t *testing.T) {
+	encoded, err := testArgon2.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("unexpected PHC string %q", encoded)
+	}
+
+	decoded, err := decodeArgon2id(encoded)
+	if err != nil {
+		t.Fatalf("decodeArgon2id: %v", err)
+	}
+	if decoded.params != *testArgon2 {
+		t.Errorf("decoded %+v, want %+v", decoded.params, *testArgon2)
+	}
+
+	for password, want := range map[string]bool{"correct horse battery staple": true, "correct horse battery stapler": false, "": false} {
+		if ok, err := testArgon2.Verify(encoded, password); err != nil || ok != want {
+			t.Errorf("Verify(%q) = %t, %v; want %t", password, ok, err, want)
+		}
+	}
+
+	// Any Argon2id hasher verifies with the parameters in the hash, not its own
+	if ok, err := (&Argon2idHasher{}).Verify(encoded, "correct horse battery staple"); err != nil || !ok {
+		t.Errorf("zero-value hasher: Verify = %t, %v", ok, err)
+	}
+}
+
+func TestDecodeArgon2idRejectsBadStrings(t *testing.T) {
+	const salt, key = "c29tZXNhbHRzb21lc2FsdA", "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	tests := map[string]string{
+		"wrong algorithm":     "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key,
+		"missing field":       "$argon2id$v=19$m=64,t=1,p=1$" + salt,
+		"wrong version":       "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key,
+		"zero parallelism":    "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+		"parallelism too big": "$argon2id$v=19$m=4096,t=1,p=256$" + salt + "$" + key,
+		"zero iterations":     "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+		"too many iterations": "$argon2id$v=19$m=64,t=65,p=1$" + salt + "$" + key,
+		"too much memory":     "$argon2id$v=19$m=4194304,t=1,p=1$" + salt + "$" + key,
+		"too little memory":   "$argon2id$v=19$m=8,t=1,p=2$" + salt + "$" + key,
+		"bad params":          "$argon2id$v=19$t=1,m=64,p=1$" + salt + "$" + key,
+		"bad salt":            "$argon2id$v=19$m=64,t=1,p=1$!!!$" + key,
+		"empty key":           "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+		"short salt":          "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$" + key,
+	}
+	for name, encoded := range tests {
+		if _, err := decodeArgon2id(encoded); err == nil {
+			t.Errorf("%s: decodeArgon2id(%q) succeeded", name, encoded)
+		}
+		// Verify must fail cleanly rather than panic in argon2.IDKey
+		if ok, err := testArgon2.Verify(encoded, "anything"); ok || err == nil {
+			t.Errorf("%s: Verify = %t, %v; want an error", name, ok, err)
+		}
+	}
+}
+
+func TestArgon2idHashRejectsBadParameters(t *testing.T) {
+	for _, h := range []*Argon2idHasher{
+		{Memory: 64, Iterations: 1, Parallelism: 0, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 0, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: maxArgon2Memory + 1, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 0, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 0},
+	} {
+		if _, err := h.Hash("password"); err == nil {
+			t.Errorf("Hash with %+v succeeded", *h)
+		}
+	}
+}
+
+func TestArgon2idFromEnv(t *testing.T) {
+	hasher, err := argon2idFromEnv()
+	if err != nil {
+		t.Fatalf("defaults rejected: %v", err)
+	}
+	if want := (Argon2idHasher{Memory: 64 * 1024, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32}); *hasher != want {
+		t.Errorf("defaults = %+v, want %+v", *hasher, want)
+	}
+
+	for envVar, value := range map[string]string{
+		"ARGON2_PARALLELISM": "0",
+		"ARGON2_ITERATIONS":  "0",
+		"ARGON2_MEMORY_KIB":  "-1",
+		"ARGON2_SALT_LENGTH": "4",
+		"ARGON2_KEY_LENGTH":  "0",
+	} {
+		t.Run(envVar+"="+value, func(t *testing.T) {
+			t.Setenv(envVar, value)
+			if _, err := argon2idFromEnv(); err == nil {
+				t.Errorf("%s=%s accepted", envVar, value)
+			}
+		})
+	}
+	t.Run("ARGON2_PARALLELISM=256", func(t *testing.T) {
+		t.Setenv("ARGON2_PARALLELISM", "256") // Would wrap to 0 as a uint8
+		if _, err := argon2idFromEnv(); err == nil {
+			t.Error("ARGON2_PARALLELISM=256 accepted")
+		}
+	})
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	encoded, err := testArgon2.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testArgon2.NeedsRehash(encoded) {
+		t.Error("a hash with the current parameters needs a rehash")
+	}
+
+	for _, h := range []*Argon2idHasher{
+		{Memory: 128, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 2, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 32, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 64},
+	} {
+		if !h.NeedsRehash(encoded) {
+			t.Errorf("hasher %+v does not rehash %q", *h, encoded)
+		}
+	}
+	if !testArgon2.NeedsRehash("$argon2id$v=19$m=64,t=1,p=0$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5a2V5a2V5") {
+		t.Error("an invalid hash does not need a rehash")
+	}
+}
+
+func TestHasherForMigratesBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hasher, err := hasherFor(string(legacy))
+	if err != nil {
+		t.Fatalf("hasherFor(bcrypt): %v", err)
+	}
+	if ok, err := hasher.Verify(string(legacy), "password"); err != nil || !ok {
+		t.Errorf("bcrypt Verify = %t, %v", ok, err)
+	}
+	if !hasher.NeedsRehash(string(legacy)) {
+		t.Error("a MinCost bcrypt hash does not need a rehash")
+	}
+	if _, err := hasherFor("plaintext"); err != errUnknownHashFormat {
+		t.Errorf("hasherFor(plaintext) = %v, want errUnknownHashFormat", err)
+	}
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ✅ Actor claim: who is acting on the subject's behalf (RFC 8693 §4.1)
+type Actor struct {
+	Subject string `json:"sub"` // User ID of the admin
+}
+
+// ✅ Whether an admin is acting as the user through this token
+func (c *Claims) IsImpersonation() bool {
+	return c.Actor != nil
+}
+
+// ✅ Generate an impersonation access token: the user as subject, the admin as actor
+//
+// subject.SessionID is the Impersonation row, not a UserSession. There is
+// no refresh token, and no auth_time, so sudo mode is out of reach.
+func GenerateImpersonationToken(subject TokenSubject, actorID uuid.UUID, expiry time.Duration) (string, error) {
+	subject.AuthTime = time.Time{}
+	claims := newClaims(subject, expiry)
+	claims.Actor = &Actor{Subject: actorID.String()}
+	return signAccessClaims(claims)
+}
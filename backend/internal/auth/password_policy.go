@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ✅ Password Policy (configurable via environment variables)
+type PasswordPolicy struct {
+	MinLength      int  // Minimum length in characters (not bytes)
+	MaxLength      int  // Maximum length in characters (not bytes)
+	RequireUpper   bool // At least one uppercase letter
+	RequireLower   bool // At least one lowercase letter
+	RequireNumber  bool // At least one digit
+	RequireSpecial bool // At least one symbol, punctuation or space
+	MinStrength    int  // Minimum strength score (0-4) from EstimateStrength
+	CheckBreached  bool // Reject passwords found by the breach checker
+}
+
+// ✅ A Single Failed Policy Rule
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ✅ Policy Error carrying every failed rule
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ✅ Active Password Policy
+var passwordPolicy = loadPasswordPolicy()
+
+// ✅ Load Password Policy from environment
+//
+// Character class rules are off by default (NIST SP 800-63B): length, the
+// strength estimate and the breach check do the work, so passphrases such as
+// "correct horse battery staple" are accepted.
+func loadPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:      getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:      getEnvInt("PASSWORD_MAX_LENGTH", 64),
+		RequireUpper:   getEnvBool("PASSWORD_REQUIRE_UPPER", false),
+		RequireLower:   getEnvBool("PASSWORD_REQUIRE_LOWER", false),
+		RequireNumber:  getEnvBool("PASSWORD_REQUIRE_NUMBER", false),
+		RequireSpecial: getEnvBool("PASSWORD_REQUIRE_SPECIAL", false),
+		MinStrength:    getEnvInt("PASSWORD_MIN_STRENGTH", 2),
+		CheckBreached:  getEnvBool("PASSWORD_CHECK_BREACHED", true),
+	}
+}
+
+// ✅ Current Password Policy (used by clients to render rules)
+func CurrentPasswordPolicy() PasswordPolicy {
+	return passwordPolicy
+}
+
+// ✅ Check only the encoding and length rules (no strength estimate or breach lookup)
+func (p PasswordPolicy) CheckSyntax(password string) error {
+	return policyError(p.syntaxViolations(password))
+}
+
+// ✅ Check a password against every rule, returning all failures at once
+func (p PasswordPolicy) Check(password string, userInputs ...string) error {
+	violations := p.syntaxViolations(password)
+	fail := func(rule, message string) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: message})
+	}
+
+	hasUpper, hasLower, hasNumber, hasSpecial := false, false, false, false
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasNumber = true
+		case unicode.IsPunct(char), unicode.IsSymbol(char), unicode.IsSpace(char):
+			hasSpecial = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		fail("uppercase", "password must contain at least 1 uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		fail("lowercase", "password must contain at least 1 lowercase letter")
+	}
+	if p.RequireNumber && !hasNumber {
+		fail("number", "password must contain at least 1 number")
+	}
+	if p.RequireSpecial && !hasSpecial {
+		fail("special", "password must contain at least 1 symbol or space")
+	}
+
+	// ✅ Reject passwords containing the username or email
+	lowered := strings.ToLower(password)
+	for _, input := range personalTokens(userInputs...) {
+		if strings.Contains(lowered, input) {
+			fail("personal_info", "password must not contain your username or email")
+			break
+		}
+	}
+
+	if score := EstimateStrength(password, userInputs...); score < p.MinStrength {
+		fail("strength", "password is too easy to guess")
+	}
+
+	// ✅ Only hit the breach checker once everything else passes
+	if len(violations) == 0 && p.CheckBreached {
+		breached, err := breachChecker.IsBreached(password)
+		if err != nil {
+			log.Println("⚠️ Breached password check unavailable:", err)
+		} else if breached {
+			fail("breached", "password has appeared in a data breach and cannot be used")
+		}
+	}
+
+	return policyError(violations)
+}
+
+// ✅ Encoding and length failures
+func (p PasswordPolicy) syntaxViolations(password string) []PasswordViolation {
+	var violations []PasswordViolation
+	fail := func(rule, message string) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if !utf8.ValidString(password) {
+		fail("encoding", "password must be valid UTF-8")
+	}
+	if length < p.MinLength {
+		fail("min_length", "password must be at least "+strconv.Itoa(p.MinLength)+" characters long")
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		fail("max_length", "password must not exceed "+strconv.Itoa(p.MaxLength)+" characters")
+	}
+	if _, isBcrypt := passwordHasher.(*BcryptHasher); isBcrypt && len(password) > 72 {
+		fail("max_bytes", "password must not exceed 72 bytes")
+	}
+	return violations
+}
+
+func policyError(violations []PasswordViolation) error {
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// ✅ Split usernames and emails into lowercase tokens worth matching against
+func personalTokens(userInputs ...string) []string {
+	var tokens []string
+	for _, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if input == "" {
+			continue
+		}
+		candidates := []string{input}
+		if at := strings.IndexByte(input, '@'); at > 0 {
+			candidates = append(candidates, input[:at])
+		}
+		for _, candidate := range candidates {
+			if utf8.RuneCountInString(candidate) >= 3 {
+				tokens = append(tokens, candidate)
+			}
+		}
+	}
+	return tokens
+}
+
+// ✅ Read an integer environment variable with a fallback
+func getEnvInt(envVar string, defaultValue int) int {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️ WARNING: %s is not a valid integer, using default %d", envVar, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// ✅ Read a boolean environment variable with a fallback
+func getEnvBool(envVar string, defaultValue bool) bool {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("⚠️ WARNING: %s is not a valid boolean, using default %t", envVar, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
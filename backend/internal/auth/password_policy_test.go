@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// Violated rule names, or nil when the password passes
+func violatedRules(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("Check returned %T (%v), want *PasswordPolicyError", err, err)
+	}
+	rules := make([]string, 0, len(policyErr.Violations))
+	for _, v := range policyErr.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestPasswordPolicyDefaults(t *testing.T) {
+	policy := loadPasswordPolicy()
+	if policy.RequireUpper || policy.RequireLower || policy.RequireNumber || policy.RequireSpecial {
+		t.Errorf("character class rules default on: %+v", policy)
+	}
+	policy.CheckBreached = false
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"correct horse battery staple", nil},
+		{"Tr0ub4dor&3x", nil},
+		{"short", []string{"min_length"}},
+		{"password", []string{"strength"}},
+		{"aaaaaaaaaaaa", []string{"strength"}},
+		{"abcdefghijkl", []string{"strength"}},
+	}
+	for _, tt := range tests {
+		if got := violatedRules(t, policy.Check(tt.password)); !slices.Equal(got, tt.want) {
+			t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestPasswordPolicyRules(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:      10,
+		MaxLength:      20,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireNumber:  true,
+		RequireSpecial: true,
+	}
+
+	tests := []struct {
+		password string
+		inputs   []string
+		want     []string
+	}{
+		{"Zebra-Crossing-42", nil, nil},
+		{"zebra crossing", nil, []string{"uppercase", "number"}},
+		{"ZEBRA-CROSSING-42", nil, []string{"lowercase"}},
+		{"ZebraCrossing42", nil, []string{"special"}},
+		{"Zebra-Crossing-42-and-more", nil, []string{"max_length"}},
+		{"Ada.Lovelace-1815", []string{"ada.lovelace@example.com"}, []string{"personal_info"}},
+		{"Xx-ADALOVELACE-9", []string{"adalovelace"}, []string{"personal_info"}},
+		{"Zebra-Crossing-42", []string{"ada"}, nil},
+		{"Zebra-\xff-Crossing-42", nil, []string{"encoding"}},
+	}
+	for _, tt := range tests {
+		if got := violatedRules(t, policy.Check(tt.password, tt.inputs...)); !slices.Equal(got, tt.want) {
+			t.Errorf("Check(%q, %q) = %v, want %v", tt.password, tt.inputs, got, tt.want)
+		}
+	}
+}
+
+func TestPasswordPolicyBreachCheck(t *testing.T) {
+	filter := NewBloomFilter(OptimalBloomSize(1, 1e-9))
+	filter.AddDigest(passwordSHA1("correct horse battery staple"))
+	SetBreachChecker(filter)
+	t.Cleanup(func() { SetBreachChecker(nil) })
+
+	policy := PasswordPolicy{MinLength: 8, CheckBreached: true}
+	if got := violatedRules(t, policy.Check("correct horse battery staple")); !slices.Equal(got, []string{"breached"}) {
+		t.Errorf("breached passphrase: got %v, want [breached]", got)
+	}
+	if got := violatedRules(t, policy.Check("purple monkey dishwasher")); got != nil {
+		t.Errorf("unbreached passphrase: got %v, want none", got)
+	}
+	// The breach checker is only asked once everything else passes
+	if got := violatedRules(t, policy.Check("x")); slices.Contains(got, "breached") {
+		t.Errorf("short password: got %v, want no breach check", got)
+	}
+
+	// The syntax check (request binding) leaves strength and breaches to the handlers
+	if got := violatedRules(t, policy.CheckSyntax("correct horse battery staple")); got != nil {
+		t.Errorf("CheckSyntax on a breached passphrase: got %v, want none", got)
+	}
+	if got := violatedRules(t, policy.CheckSyntax("x")); !slices.Equal(got, []string{"min_length"}) {
+		t.Errorf("CheckSyntax(\"x\") = %v, want [min_length]", got)
+	}
+}
+
+func TestEstimateStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		inputs   []string
+		min, max int
+	}{
+		{"", nil, 0, 0},
+		{"password", nil, 0, 0},
+		{"123456789", nil, 0, 1},
+		{"aaaaaaaaaaaaaaaa", nil, 0, 1},
+		{"abcdefghijklmnop", nil, 0, 1},
+		{"Tr0ub4dor&3x", nil, 3, 4},
+		{"correct horse battery staple", nil, 4, 4},
+		{"q7$Vz!mK2#pW", nil, 4, 4},
+	}
+	for _, tt := range tests {
+		if got := EstimateStrength(tt.password, tt.inputs...); got < tt.min || got > tt.max {
+			t.Errorf("EstimateStrength(%q) = %d, want %d-%d", tt.password, got, tt.min, tt.max)
+		}
+	}
+
+	// Using your own name is no better than a dictionary word
+	if with, without := EstimateStrength("adalovelace", "adalovelace"), EstimateStrength("adalovelace"); with >= without {
+		t.Errorf("personal info scored %d, without it %d", with, without)
+	}
+}
+
+func TestBloomFilterRoundTrip(t *testing.T) {
+	bits, hashes := OptimalBloomSize(1000, 0.001)
+	filter := NewBloomFilter(bits, hashes)
+	for i := range 1000 {
+		filter.AddDigest(passwordSHA1("breached-" + strconv.Itoa(i)))
+	}
+	filter.AddDigest(passwordSHA1("hunter2"))
+
+	path := filepath.Join(t.TempDir(), "breached.bloom")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filter.WriteTo(file); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	loaded, err := LoadBloomFilter(path)
+	if err != nil {
+		t.Fatalf("LoadBloomFilter: %v", err)
+	}
+	if loaded.bits != bits || loaded.hashes != hashes {
+		t.Errorf("loaded %d bits / %d hashes, want %d / %d", loaded.bits, loaded.hashes, bits, hashes)
+	}
+	if breached, _ := loaded.IsBreached("hunter2"); !breached {
+		t.Error("hunter2 is not in the loaded filter")
+	}
+
+	falsePositives := 0
+	for i := range 10000 {
+		if breached, _ := loaded.IsBreached("never-added-" + strconv.Itoa(i)); breached {
+			falsePositives++
+		}
+	}
+	if falsePositives > 50 { // 0.1% expected
+		t.Errorf("%d false positives in 10000, want about 10", falsePositives)
+	}
+}
+
+func TestLoadBloomFilterRejectsBadHeaders(t *testing.T) {
+	valid := func() []byte {
+		var buf bytes.Buffer
+		if _, err := NewBloomFilter(64, 3).WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := map[string]func([]byte) []byte{
+		"bad magic":        func(b []byte) []byte { b[0] = 'X'; return b },
+		"truncated header": func(b []byte) []byte { return b[:10] },
+		"truncated bits":   func(b []byte) []byte { return b[:len(b)-1] },
+		"trailing bytes":   func(b []byte) []byte { return append(b, 0) },
+		"huge bit count": func(b []byte) []byte {
+			copy(b[8:16], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+			return b
+		},
+		"zero hashes": func(b []byte) []byte { copy(b[4:8], []byte{0, 0, 0, 0}); return b },
+		"many hashes": func(b []byte) []byte { copy(b[4:8], []byte{0, 0, 1, 0}); return b },
+	}
+	for name, corrupt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "corrupt.bloom")
+			if err := os.WriteFile(path, corrupt(valid()), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadBloomFilter(path); err == nil {
+				t.Error("LoadBloomFilter accepted a corrupt file")
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "valid.bloom")
+	if err := os.WriteFile(path, valid(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBloomFilter(path); err != nil {
+		t.Errorf("LoadBloomFilter rejected a valid file: %v", err)
+	}
+}
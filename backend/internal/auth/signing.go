@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ✅ Asymmetric key that signs access tokens, published as a JWKS
+//
+// Configured with JWT_SIGNING_KEY_FILE (or the PEM itself in JWT_SIGNING_KEY):
+// an EC P-256/P-384, RSA or Ed25519 private key in PKCS#8, SEC 1 or PKCS#1
+// form. Other services can then verify access tokens with the public key from
+// /.well-known/jwks.json instead of sharing JWT_SECRET. Without a key, access
+// tokens stay HS256 with JWT_SECRET and the key set is empty.
+type signingKey struct {
+	id     string // "kid" header: RFC 7638 thumbprint of the public key
+	method jwt.SigningMethod
+	signer crypto.Signer
+}
+
+var accessKey = loadSigningKey()
+
+// ✅ Load the access token signing key from the environment (nil when not configured)
+func loadSigningKey() *signingKey {
+	data := []byte(os.Getenv("JWT_SIGNING_KEY"))
+	if path := os.Getenv("JWT_SIGNING_KEY_FILE"); path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			log.Fatalf("❌ Failed to read JWT_SIGNING_KEY_FILE: %v", err)
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	key, err := parseSigningKey(data)
+	if err != nil {
+		log.Fatalf("❌ Invalid JWT signing key: %v", err)
+	}
+	log.Printf("🔑 Access tokens signed with %s key %s", key.method.Alg(), key.id)
+	return key
+}
+
+// ✅ Parse a PEM private key and pick its JWS algorithm
+func parseSigningKey(data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{}
+	switch k := parsed.(type) {
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			key.method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.method = jwt.SigningMethodES384
+		default:
+			return nil, errors.New("unsupported EC curve (use P-256 or P-384)")
+		}
+		key.signer = k
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("RSA key must be at least 2048 bits")
+		}
+		key.method, key.signer = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.signer = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	jwk := publicJWK(key.signer.Public(), key.method.Alg())
+	key.id = jwk.thumbprint()
+	return key, nil
+}
+
+// ✅ JWK is a public key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"` // EC and OKP
+	X   string `json:"x,omitempty"`   // EC and OKP
+	Y   string `json:"y,omitempty"`   // EC
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+}
+
+// ✅ Public keys that verify access tokens (empty when tokens are HS256)
+func PublicJWKs() []JWK {
+	if accessKey == nil {
+		return []JWK{}
+	}
+	jwk := publicJWK(accessKey.signer.Public(), accessKey.method.Alg())
+	jwk.Kid = accessKey.id
+	return []JWK{jwk}
+}
+
+// ✅ Encode a public key as a JWK (without kid)
+func publicJWK(public crypto.PublicKey, alg string) JWK {
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwk := JWK{Use: "sig", Alg: alg}
+	switch k := public.(type) {
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk.Kty, jwk.Crv = "EC", k.Curve.Params().Name
+		jwk.X, jwk.Y = b64(k.X.FillBytes(make([]byte, size))), b64(k.Y.FillBytes(make([]byte, size)))
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N, jwk.E = b64(k.N.Bytes()), b64(big.NewInt(int64(k.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", b64(k)
+	}
+	return jwk
+}
+
+// ✅ RFC 7638 thumbprint: SHA-256 of the required members in lexical order
+func (k JWK) thumbprint() string {
+	var members interface{}
+	switch k.Kty {
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Crv, k.Kty, k.X, k.Y}
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
+	}
+	canonical, _ := json.Marshal(members)
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ✅ Sign access token claims with the configured key
+func (k *signingKey) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.id
+	return token.SignedString(k.signer)
+}
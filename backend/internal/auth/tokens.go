@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// ✅ Generate a random URL-safe token and the hash to store in the database
+//
+// Only the hash is persisted, so a database leak does not expose usable links.
+func GenerateURLToken() (token string, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashURLToken(token), nil
+}
+
+// ✅ Hash a URL token for lookup
+func HashURLToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
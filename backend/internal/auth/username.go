@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"os"
+	"strings"
+)
+
+// ✅ Returned by ValidateUsername for reserved names and their look-alikes
+var ErrUsernameReserved = errors.New("username is reserved")
+
+// ✅ Names nobody may register, matched through look-alikes so "Adm1n" or "a.dmin" are caught too
+var defaultReservedUsernames = []string{
+	"admin", "administrator", "root", "system", "sysadmin", "superuser",
+	"support", "help", "helpdesk", "security", "abuse", "postmaster", "hostmaster", "webmaster",
+	"moderator", "mod", "staff", "official", "team", "owner",
+	"arcadia", "arcadiago", "api", "www", "mail", "email", "noreply", "no_reply",
+	"null", "undefined", "anonymous", "guest", "user", "users", "me", "settings", "login", "logout", "register",
+}
+
+// ✅ Look-alikes ("from", "to" pairs, after case folding) that may stand in for a reserved name's letters
+//
+// Single letters never stand in for another letter, so a real word ("mall")
+// does not collide with a reserved one ("mail"); the only letter sequences
+// listed are the two-letter look-alikes "rn" and "vv". A glyph that imitates
+// several letters is listed once per letter.
+var defaultConfusables = []string{
+	"0", "o",
+	"1", "l",
+	"1", "i",
+	"!", "l",
+	"!", "i",
+	"|", "l",
+	"|", "i",
+	"3", "e",
+	"4", "a",
+	"5", "s",
+	"7", "t",
+	"8", "b",
+	"rn", "m",
+	"vv", "w",
+}
+
+// ✅ Reserved username list with confusable-character matching
+type UsernameBlocklist struct {
+	reserved    map[string]bool
+	confusables [][2]string
+}
+
+// ✅ Active blocklist (defaults + USERNAME_RESERVED / USERNAME_RESERVED_FILE / USERNAME_CONFUSABLES)
+var usernameBlocklist = loadUsernameBlocklist()
+
+// ✅ Replace the active blocklist
+func SetUsernameBlocklist(b *UsernameBlocklist) {
+	usernameBlocklist = b
+}
+
+// ✅ Build a blocklist from reserved words and confusable pairs ("from", "to", ...)
+func NewUsernameBlocklist(reserved []string, confusables []string) *UsernameBlocklist {
+	b := &UsernameBlocklist{reserved: map[string]bool{}}
+	for i := 0; i+1 < len(confusables); i += 2 {
+		if confusables[i] != "" {
+			b.confusables = append(b.confusables, [2]string{confusables[i], confusables[i+1]})
+		}
+	}
+	for _, name := range reserved {
+		if name = strings.TrimSpace(name); name != "" {
+			b.reserved[Skeleton(name)] = true
+		}
+	}
+	return b
+}
+
+// ✅ Load the blocklist from the environment
+//
+// USERNAME_RESERVED is a comma-separated list of extra names, USERNAME_RESERVED_FILE
+// a file with one name per line (# comments allowed), and USERNAME_CONFUSABLES
+// extra comma-separated "from=to" substitutions.
+func loadUsernameBlocklist() *UsernameBlocklist {
+	reserved := append([]string{}, defaultReservedUsernames...)
+	reserved = append(reserved, strings.Split(os.Getenv("USERNAME_RESERVED"), ",")...)
+
+	if path := os.Getenv("USERNAME_RESERVED_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Println("⚠️ WARNING: Could not read USERNAME_RESERVED_FILE:", err)
+		} else {
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+					reserved = append(reserved, line)
+				}
+			}
+			file.Close()
+		}
+	}
+
+	confusables := append([]string{}, defaultConfusables...)
+	for _, pair := range strings.Split(os.Getenv("USERNAME_CONFUSABLES"), ",") {
+		if from, to, ok := strings.Cut(strings.TrimSpace(pair), "="); ok && from != "" {
+			confusables = append(confusables, strings.ToLower(from), strings.ToLower(to))
+		}
+	}
+
+	return NewUsernameBlocklist(reserved, confusables)
+}
+
+// ✅ Skeleton of a username: case-folded, separators removed
+func Skeleton(username string) string {
+	return strings.NewReplacer(".", "", "_", "").Replace(FoldUsername(username))
+}
+
+// ✅ Whether a username is (or looks like) a reserved name
+func (b *UsernameBlocklist) IsReserved(username string) bool {
+	skeleton := Skeleton(username)
+	if b.reserved[skeleton] {
+		return true
+	}
+	for name := range b.reserved {
+		if b.imitates(skeleton, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ✅ Whether skeleton spells name, each character either itself or one of its look-alikes
+func (b *UsernameBlocklist) imitates(skeleton, name string) bool {
+	if skeleton == "" || name == "" {
+		return skeleton == name
+	}
+	if skeleton[0] == name[0] && b.imitates(skeleton[1:], name[1:]) {
+		return true
+	}
+	for _, pair := range b.confusables {
+		if strings.HasPrefix(skeleton, pair[0]) && strings.HasPrefix(name, pair[1]) &&
+			b.imitates(skeleton[len(pair[0]):], name[len(pair[1]):]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ✅ Case-folded form of a username, used for uniqueness
+func FoldUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
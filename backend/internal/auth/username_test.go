@@ -0,0 +1,52 @@
+package auth
+
+import "testing"
+
+func TestUsernameBlocklist(t *testing.T) {
+	b := NewUsernameBlocklist(defaultReservedUsernames, defaultConfusables)
+	tests := map[string]bool{
+		"admin":    true,
+		"Admin":    true,
+		"a.dmin":   true,
+		"ad_min":   true,
+		"Adm1n":    true,
+		"adm!n":    true,
+		"4dmin":    true,
+		"mail":     true,
+		"ma1l":     true,
+		"mai1":     true,
+		"he1p":     true,
+		"rne":      true,
+		"5upp0rt":  true,
+		"mall":     false, // A real word, not a look-alike of "mail"
+		"hell":     false,
+		"admins":   false,
+		"ada":      false,
+		"lovelace": false,
+	}
+	for username, want := range tests {
+		if got := b.IsReserved(username); got != want {
+			t.Errorf("IsReserved(%q) = %t, want %t", username, got, want)
+		}
+	}
+}
+
+func TestUsernameBlocklistCustom(t *testing.T) {
+	b := NewUsernameBlocklist([]string{" Arcade ", ""}, append(defaultConfusables, "@", "a", "", "x"))
+	for username, want := range map[string]bool{"arcade": true, "@rc@de": true, "4rcade": true, "arcadia": false, "": false} {
+		if got := b.IsReserved(username); got != want {
+			t.Errorf("IsReserved(%q) = %t, want %t", username, got, want)
+		}
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	for username, want := range map[string]error{"ada.lovelace": nil, "Adm1n": ErrUsernameReserved, "mall": nil} {
+		if err := ValidateUsername(username); err != want {
+			t.Errorf("ValidateUsername(%q) = %v, want %v", username, err, want)
+		}
+	}
+	if err := ValidateUsername("a"); err == nil {
+		t.Error("ValidateUsername accepted a one-character name")
+	}
+}
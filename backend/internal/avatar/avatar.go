@@ -0,0 +1,143 @@
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// ✅ Square sizes (in pixels) every avatar is rendered at; DefaultSize is linked from the profile
+var Sizes = []int{256, 128, 64}
+
+const DefaultSize = 256
+
+// ✅ Input limits: decoded dimensions are checked before the pixels are decoded
+const (
+	MaxSide   = 8192
+	MaxPixels = 25_000_000
+)
+
+var (
+	ErrUnsupportedType = errors.New("avatar must be a JPEG, PNG or GIF image")
+	ErrTooLarge        = fmt.Errorf("avatar must be at most %dx%d pixels", MaxSide, MaxSide)
+	ErrInvalidImage    = errors.New("avatar could not be decoded")
+)
+
+// ✅ Allowed MIME types, detected from the content rather than trusted from the client
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// ✅ Process an uploaded image into square JPEG avatars keyed by size
+//
+// The image is decoded and re-encoded from raw pixels, so metadata (EXIF, GPS,
+// ICC, comments) never survives; the JPEG orientation tag is applied first so
+// phone photos are not sideways. Transparent pixels are flattened onto white.
+func Process(data []byte) (map[int][]byte, error) {
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > MaxSide || cfg.Height > MaxSide || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var src image.Image
+	switch format {
+	case "jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		src, err = gif.Decode(bytes.NewReader(data)) // First frame only
+	default:
+		return nil, ErrUnsupportedType
+	}
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	square := cropSquare(src)
+	if format == "jpeg" {
+		square = applyOrientation(square, jpegOrientation(data))
+	}
+
+	out := make(map[int][]byte, len(Sizes))
+	for _, size := range Sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size), &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		out[size] = buf.Bytes()
+	}
+	return out, nil
+}
+
+// ✅ Centre-crop to a square and flatten onto a white RGBA canvas
+func cropSquare(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	origin := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, origin, draw.Over)
+	return dst
+}
+
+// ✅ Box-filter resize of a square image (nearest pixel when upscaling)
+func resize(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	scale := float64(side) / float64(size)
+
+	span := func(i int) (int, int) {
+		start := int(float64(i) * scale)
+		end := int(float64(i+1)*scale + 0.999999)
+		if end <= start {
+			end = start + 1
+		}
+		if end > side {
+			end = side
+		}
+		return start, end
+	}
+
+	for y := 0; y < size; y++ {
+		y0, y1 := span(y)
+		for x := 0; x < size; x++ {
+			x0, x1 := span(x)
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					b += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+			o := dst.Pix[y*dst.Stride+x*4:]
+			o[0], o[1], o[2], o[3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,181 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{255, 0, 0, 255}
+	blue = color.RGBA{0, 0, 255, 255}
+)
+
+// Square image, red on the left half and blue on the right
+func halves(side int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, side, side))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			if x < side/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+// JPEG with an EXIF APP1 segment carrying the orientation tag
+func jpegWithOrientation(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Big-endian TIFF header, then IFD0 with one SHORT entry (0x0112) and no next IFD
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1}
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := encoded.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...) // Right after SOI
+}
+
+// Decode one rendered size and check that it is a JPEG of that size
+func decodeSize(t *testing.T, out map[int][]byte, size int) image.Image {
+	t.Helper()
+	img, err := jpeg.Decode(bytes.NewReader(out[size]))
+	if err != nil {
+		t.Fatalf("size %d: %v", size, err)
+	}
+	if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+		t.Fatalf("size %d rendered at %dx%d", size, b.Dx(), b.Dy())
+	}
+	return img
+}
+
+// Whether a decoded pixel is close to a colour (JPEG is lossy)
+func near(c color.Color, want color.RGBA) bool {
+	r, g, b, _ := c.RGBA()
+	diff := func(got uint32, want uint8) bool {
+		d := int(got>>8) - int(want)
+		return d > -40 && d < 40
+	}
+	return diff(r, want.R) && diff(g, want.G) && diff(b, want.B)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	for orientation := uint16(1); orientation <= 8; orientation++ {
+		if got := jpegOrientation(jpegWithOrientation(t, halves(16), orientation)); got != int(orientation) {
+			t.Errorf("orientation %d read as %d", orientation, got)
+		}
+	}
+	if got := jpegOrientation(jpegWithOrientation(t, halves(16), 9)); got != 1 {
+		t.Errorf("out-of-range orientation read as %d, want 1", got)
+	}
+	if got := jpegOrientation([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF}); got != 1 {
+		t.Errorf("truncated segment read as %d, want 1", got)
+	}
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	tests := []struct {
+		orientation          uint16
+		top, bottom          color.RGBA // Middle of the top and bottom edges
+		left, right          color.RGBA // Middle of the left and right edges
+		checkTop, checkSides bool
+	}{
+		{orientation: 1, left: red, right: blue, checkSides: true},
+		{orientation: 2, left: blue, right: red, checkSides: true},
+		{orientation: 3, left: blue, right: red, checkSides: true},
+		{orientation: 6, top: red, bottom: blue, checkTop: true}, // Rotated clockwise: left becomes top
+		{orientation: 8, top: blue, bottom: red, checkTop: true}, // Rotated counter-clockwise: right becomes top
+	}
+	for _, tt := range tests {
+		out, err := Process(jpegWithOrientation(t, halves(300), tt.orientation))
+		if err != nil {
+			t.Fatalf("orientation %d: %v", tt.orientation, err)
+		}
+		img := decodeSize(t, out, 64)
+		if tt.checkSides && (!near(img.At(4, 32), tt.left) || !near(img.At(59, 32), tt.right)) {
+			t.Errorf("orientation %d: left %v, right %v", tt.orientation, img.At(4, 32), img.At(59, 32))
+		}
+		if tt.checkTop && (!near(img.At(32, 4), tt.top) || !near(img.At(32, 59), tt.bottom)) {
+			t.Errorf("orientation %d: top %v, bottom %v", tt.orientation, img.At(32, 4), img.At(32, 59))
+		}
+	}
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+	out, err := Process(jpegWithOrientation(t, halves(64), 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range Sizes {
+		decodeSize(t, out, size)
+		if bytes.Contains(out[size], []byte("Exif")) {
+			t.Errorf("size %d kept the EXIF segment", size)
+		}
+	}
+}
+
+func TestProcessCropsAndFlattens(t *testing.T) {
+	// A wide transparent PNG with a red square in the middle: the crop keeps the
+	// square and the transparent sides go white
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 125; x < 175; x++ {
+			img.Set(x, y, red)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	avatar := decodeSize(t, out, DefaultSize)
+	if !near(avatar.At(DefaultSize/2, DefaultSize/2), red) {
+		t.Errorf("centre = %v, want red", avatar.At(DefaultSize/2, DefaultSize/2))
+	}
+	if !near(avatar.At(5, 5), color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("corner = %v, want transparency flattened to white", avatar.At(5, 5))
+	}
+}
+
+func TestProcessRejectsBadInput(t *testing.T) {
+	if _, err := Process([]byte("%PDF-1.4 not an image")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("PDF: %v, want ErrUnsupportedType", err)
+	}
+	if _, err := Process([]byte("\xFF\xD8\xFF\xE0 truncated jpeg")); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("truncated JPEG: %v, want ErrInvalidImage", err)
+	}
+
+	// A tiny GIF claiming to be huge is refused before its pixels are decoded
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, halves(8), nil); err != nil {
+		t.Fatal(err)
+	}
+	huge := buf.Bytes()
+	binary.LittleEndian.PutUint16(huge[6:], MaxSide+1)
+	binary.LittleEndian.PutUint16(huge[8:], 10)
+	if _, err := Process(huge); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized GIF: %v, want ErrTooLarge", err)
+	}
+}
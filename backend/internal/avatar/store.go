@@ -0,0 +1,26 @@
+package avatar
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/thejpness/ArcadiaGo/internal/storage"
+)
+
+// ✅ Storage key of one rendered avatar size
+func ObjectKey(prefix string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", prefix, size)
+}
+
+// ✅ Delete every rendered size of an uploaded avatar (best effort)
+func Delete(ctx context.Context, prefix string) {
+	if prefix == "" {
+		return
+	}
+	for _, size := range Sizes {
+		if err := storage.Active().Delete(ctx, ObjectKey(prefix, size)); err != nil {
+			log.Println("⚠️ Failed to delete avatar object:", err)
+		}
+	}
+}
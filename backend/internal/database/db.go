@@ -38,11 +38,43 @@ func Migrate() {
 		&models.User{},            // ✅ Correctly reference models from models package
 		&models.UserEmailChange{}, // ✅ Correctly reference models from models package
 		&models.UserSession{},     // ✅ Correctly reference models from models package
+		&models.Organization{},
+		&models.Membership{},
+		&models.OrgInvitation{},
+		&models.MagicLinkToken{},
+		&models.UsedActionToken{},
+		&models.AuditEvent{},
+		&models.TrustedDevice{},
+		&models.UsernameHistory{},
+		&models.ServiceClient{},
+		&models.ClientAssertion{},
+		&models.DeviceAuthorization{},
+		&models.Impersonation{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.OutboxEvent{},
+		&models.EventDelivery{},
+		&models.SentEmail{},
+		&models.ScheduledJob{},
+		&models.JobRun{},
 	)
 
 	if err != nil {
 		log.Fatalf("❌ Migration failed: %v", err)
 	}
 
+	// ✅ Usernames are unique per scope now: AutoMigrate never drops the old global constraint
+	if err := DB.Exec("ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key").Error; err != nil {
+		log.Fatalf("❌ Failed to drop the global username constraint: %v", err)
+	}
+
+	// ✅ Case-insensitive username uniqueness (backfill keys for rows created before they existed)
+	if err := DB.Exec("UPDATE users SET username_key = LOWER(username) WHERE username_key = ''").Error; err != nil {
+		log.Fatalf("❌ Failed to backfill username keys: %v", err)
+	}
+	if err := DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_scope_username_key ON users (username_scope, username_key)").Error; err != nil {
+		log.Println("❌ Could not enforce case-insensitive usernames (resolve duplicate names differing only by case):", err)
+	}
+
 	log.Println("✅ Database migration completed")
 }
@@ -0,0 +1,22 @@
+// Package env reads typed settings from environment variables.
+package env
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// ✅ Read a duration environment variable (e.g. "72h") with a fallback
+func Duration(envVar string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("⚠️ WARNING: %s is not a valid duration, using default %s", envVar, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
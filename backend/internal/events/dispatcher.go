@@ -0,0 +1,231 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ Deliveries claimed per round
+const batchSize = 50
+
+// ✅ How long one subscriber may take over one event (EVENT_HANDLER_TIMEOUT, default 30s)
+func handlerTimeout() time.Duration {
+	return env.Duration("EVENT_HANDLER_TIMEOUT", 30*time.Second)
+}
+
+// ✅ Attempts before a delivery is dead (EVENT_MAX_ATTEMPTS, default 10: about 40 minutes of retries)
+func MaxAttempts() int {
+	if n, err := strconv.Atoi(os.Getenv("EVENT_MAX_ATTEMPTS")); err == nil && n > 0 {
+		return n
+	}
+	return 10
+}
+
+// ✅ How long handled events stay in the outbox (EVENT_RETENTION, default 7 days)
+func retention() time.Duration {
+	return env.Duration("EVENT_RETENTION", 7*24*time.Hour)
+}
+
+// ✅ Wait after a failed attempt: 5s doubling per attempt, at most 15m
+func retryDelay(attempt int) time.Duration {
+	delay := 5 * time.Second
+	for i := 1; i < attempt && delay < 15*time.Minute; i++ {
+		delay *= 2
+	}
+	return min(delay, 15*time.Minute)
+}
+
+// ✅ Start the dispatcher in the background (EVENT_POLL_INTERVAL, default 1s)
+//
+// Several instances may run it: each claims its deliveries with SKIP LOCKED.
+// Subscribers run one at a time, off the request path. It stops once ctx is
+// cancelled, after the subscriber running; the returned channel is closed
+// when it has.
+func Start(ctx context.Context) <-chan struct{} {
+	interval := env.Duration("EVENT_POLL_INTERVAL", time.Second)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := Drain(ctx); err != nil {
+				log.Println("❌ Failed to dispatch events:", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	log.Printf("📣 Event dispatcher polling every %s (up to %d attempts per subscriber)", interval, MaxAttempts())
+	return done
+}
+
+// ✅ Dispatch rounds until nothing is due (tests call it to run subscribers synchronously)
+func Drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		handled, err := Dispatch(ctx, time.Now())
+		if err != nil || handled < batchSize {
+			return err
+		}
+	}
+	return nil
+}
+
+// ✅ Claim the deliveries due at now and run each subscriber once; returns how many ran
+//
+// Once ctx is cancelled no further subscriber starts (the deliveries left over
+// are retried when their claim runs out); the one running keeps its own
+// timeout rather than failing mid-way.
+func Dispatch(ctx context.Context, now time.Time) (int, error) {
+	timeout := handlerTimeout()
+
+	// ✅ Claiming pushes NextAttemptAt past the attempt, so a crashed instance's claims come back
+	//
+	// Subscribers run one after another, each within timeout, so every claim
+	// lasts until its own turn in the batch is over rather than the batch's start.
+	var due []models.EventDelivery
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.EventPending, now).
+			Order("next_attempt_at").Limit(batchSize).
+			Find(&due).Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		for i, delivery := range due {
+			lease := now.Add(time.Duration(i+1)*timeout + time.Minute)
+			err := tx.Model(&models.EventDelivery{}).Where("event_id = ? AND subscriber = ?", delivery.EventID, delivery.Subscriber).
+				Update("next_attempt_at", lease).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	ids := make([]uuid.UUID, len(due))
+	for i, delivery := range due {
+		ids[i] = delivery.EventID
+	}
+	var records []models.OutboxEvent
+	if err := database.DB.Where("id IN ?", ids).Find(&records).Error; err != nil {
+		return 0, err
+	}
+	byID := make(map[uuid.UUID]*models.OutboxEvent, len(records))
+	for i := range records {
+		byID[records[i].ID] = &records[i]
+	}
+
+	handled := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		run(context.WithoutCancel(ctx), &due[i], byID[due[i].EventID], timeout)
+		handled++
+	}
+	return handled, nil
+}
+
+// ✅ Hand one event to one subscriber and record the outcome: done, retry later, or dead
+func run(ctx context.Context, delivery *models.EventDelivery, record *models.OutboxEvent, timeout time.Duration) {
+	attempt := delivery.Attempts + 1
+	err := handle(ctx, delivery, record, attempt, timeout)
+
+	now := time.Now()
+	updates := map[string]interface{}{"attempts": attempt, "last_error": ""}
+	switch {
+	case err == nil:
+		updates["status"] = models.EventDone
+		updates["finished_at"] = now
+	case attempt >= MaxAttempts():
+		updates["status"] = models.EventDead
+		updates["finished_at"] = now
+		updates["last_error"] = err.Error()
+		failures.Add(delivery.Subscriber, 1)
+		log.Printf("❌ Subscriber %s gave up on event %s after %d attempts: %v", delivery.Subscriber, delivery.EventID, attempt, err)
+	default:
+		updates["next_attempt_at"] = now.Add(retryDelay(attempt))
+		updates["last_error"] = err.Error()
+		failures.Add(delivery.Subscriber, 1)
+		log.Printf("⚠️ Subscriber %s failed on event %s (attempt %d): %v", delivery.Subscriber, delivery.EventID, attempt, err)
+	}
+
+	err = database.DB.Model(&models.EventDelivery{}).
+		Where("event_id = ? AND subscriber = ?", delivery.EventID, delivery.Subscriber).
+		Updates(updates).Error
+	if err != nil {
+		log.Println("❌ Failed to record event delivery:", delivery.EventID, delivery.Subscriber, err)
+	}
+}
+
+// ✅ Decode the event and call the subscriber (a panic counts as a failure)
+func handle(ctx context.Context, delivery *models.EventDelivery, record *models.OutboxEvent, attempt int, timeout time.Duration) (err error) {
+	if record == nil {
+		return errors.New("event is missing from the outbox")
+	}
+	var sub *subscription
+	for _, s := range subscribers(record.Name) {
+		if s.subscriber == delivery.Subscriber {
+			sub = &s
+			break
+		}
+	}
+	if sub == nil {
+		return fmt.Errorf("%s no longer subscribes to %s", delivery.Subscriber, record.Name)
+	}
+
+	d := Delivery{
+		EventID:    record.ID,
+		Name:       record.Name,
+		OccurredAt: record.CreatedAt,
+		Attempt:    attempt,
+		Key:        record.ID.String() + "/" + delivery.Subscriber,
+	}
+	if err := json.Unmarshal([]byte(record.Meta), &d.Meta); err != nil {
+		return fmt.Errorf("decode meta: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return sub.handle(ctx, d, []byte(record.Payload))
+}
+
+// ✅ Remove finished deliveries and fully handled events older than the retention period; returns how many events went
+func Prune(now time.Time) (int, error) {
+	cutoff := now.Add(-retention())
+	if err := database.DB.Where("status <> ? AND finished_at < ?", models.EventPending, cutoff).Delete(&models.EventDelivery{}).Error; err != nil {
+		return 0, err
+	}
+
+	result := database.DB.
+		Where("created_at < ? AND NOT EXISTS (SELECT 1 FROM event_deliveries WHERE event_deliveries.event_id = outbox_events.id)", cutoff).
+		Delete(&models.OutboxEvent{})
+	return int(result.RowsAffected), result.Error
+}
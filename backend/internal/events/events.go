@@ -0,0 +1,143 @@
+// Package events is the in-process domain event bus.
+//
+// Handlers publish typed events inside the transaction that makes the change
+// (the transactional outbox), so an event exists exactly when its change was
+// committed:
+//
+//	err := database.DB.Transaction(func(tx *gorm.DB) error {
+//		if err := tx.Create(&user).Error; err != nil {
+//			return err
+//		}
+//		return events.Publish(tx, meta, events.UserRegistered{UserID: user.ID, …})
+//	})
+//
+// Publishing records one delivery per subscriber; the dispatcher (Start) hands
+// them to the subscribers asynchronously and retries failures with backoff.
+// Delivery is at least once: a subscriber may see an event again after a crash
+// or a timeout, so it makes its effect idempotent with Delivery.Key.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+)
+
+// ✅ Event is a typed domain event; its name routes it to subscribers
+type Event interface {
+	EventName() string
+}
+
+// ✅ Meta records who caused an event and from where (empty for background jobs)
+type Meta struct {
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"` // Support admin acting as the user (impersonation)
+	IPAddress string     `json:"ip_address,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
+	RequestID string     `json:"request_id,omitempty"`
+}
+
+// ✅ Delivery is one event as handed to one subscriber
+type Delivery struct {
+	EventID    uuid.UUID // Same for every subscriber and attempt
+	Name       string
+	Meta       Meta
+	OccurredAt time.Time
+	Attempt    int    // 1 on the first try
+	Key        string // Idempotency key: "<event ID>/<subscriber>"
+}
+
+// ✅ A subscriber's handler for one event name, decoding the payload into its type
+type subscription struct {
+	subscriber string
+	handle     func(ctx context.Context, d Delivery, payload []byte) error
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string][]subscription{} // Event name -> subscriptions
+)
+
+// ✅ Subscribe a named subscriber to one event type
+//
+// Subscribe before the server handles requests: events published earlier
+// are not delivered to late subscribers. A subscriber name must be stable,
+// since pending deliveries refer to it.
+func On[E Event](subscriber string, handle func(ctx context.Context, d Delivery, event E) error) {
+	var zero E
+	name := zero.EventName()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, sub := range registry[name] {
+		if sub.subscriber == subscriber {
+			panic(fmt.Sprintf("events: %s already subscribes to %s", subscriber, name))
+		}
+	}
+	registry[name] = append(registry[name], subscription{
+		subscriber: subscriber,
+		handle: func(ctx context.Context, d Delivery, payload []byte) error {
+			var event E
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return fmt.Errorf("decode %s: %w", name, err)
+			}
+			return handle(ctx, d, event)
+		},
+	})
+}
+
+// ✅ Subscribers of an event name, in subscription order
+func subscribers(name string) []subscription {
+	mu.RLock()
+	defer mu.RUnlock()
+	return slices.Clone(registry[name])
+}
+
+// ✅ Write events to the outbox inside tx, with a pending delivery per subscriber
+func Publish(tx *gorm.DB, meta Meta, events ...Event) error {
+	encodedMeta, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", event.EventName(), err)
+		}
+		record := models.OutboxEvent{
+			ID:      uuid.New(),
+			Name:    event.EventName(),
+			Payload: string(payload),
+			Meta:    string(encodedMeta),
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+
+		subs := subscribers(record.Name)
+		if len(subs) == 0 {
+			continue
+		}
+		deliveries := make([]models.EventDelivery, len(subs))
+		for i, sub := range subs {
+			deliveries[i] = models.EventDelivery{
+				EventID:       record.ID,
+				Subscriber:    sub.subscriber,
+				Status:        models.EventPending,
+				NextAttemptAt: now,
+			}
+		}
+		if err := tx.Create(&deliveries).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
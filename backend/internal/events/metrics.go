@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"expvar"
+
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ Counters of this process (also served by expvar.Handler, for hosts that mount it)
+var (
+	handled  = expvar.NewMap("arcadia_events_handled")            // Event name -> events seen by the metrics subscriber
+	failures = expvar.NewMap("arcadia_event_subscriber_failures") // Subscriber -> failed attempts
+)
+
+// ✅ Every event type, for subscribers that want them all
+var catalogue = []Event{
+	UserRegistered{}, EmailVerified{}, EmailChangeRequested{}, EmailChanged{}, PasswordChanged{},
+	SessionRevoked{}, UserDeleted{}, UserRestored{}, UserPurged{},
+}
+
+// ✅ Subscribe the "metrics" subscriber, which counts events by name
+func SubscribeMetrics() {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, event := range catalogue {
+		name := event.EventName()
+		registry[name] = append(registry[name], subscription{
+			subscriber: "metrics",
+			handle: func(context.Context, Delivery, []byte) error {
+				handled.Add(name, 1)
+				return nil
+			},
+		})
+	}
+}
+
+// ✅ Stats is a snapshot of the bus: this process's counters and the queue across all instances
+type Stats struct {
+	Handled  map[string]int64 // Event name -> count (this process, since start)
+	Failures map[string]int64 // Subscriber -> failed attempts (this process, since start)
+	Pending  map[string]int64 // Subscriber -> deliveries waiting
+	Dead     map[string]int64 // Subscriber -> deliveries given up on (until pruned)
+}
+
+// ✅ Current Stats
+func Snapshot() (Stats, error) {
+	stats := Stats{Handled: counters(handled), Failures: counters(failures), Pending: map[string]int64{}, Dead: map[string]int64{}}
+
+	var rows []struct {
+		Subscriber string
+		Status     string
+		Count      int64
+	}
+	err := database.DB.Model(&models.EventDelivery{}).
+		Select("subscriber, status, COUNT(*) AS count").
+		Where("status IN ?", []string{models.EventPending, models.EventDead}).
+		Group("subscriber, status").
+		Scan(&rows).Error
+	if err != nil {
+		return stats, err
+	}
+	for _, row := range rows {
+		if row.Status == models.EventPending {
+			stats.Pending[row.Subscriber] = row.Count
+		} else {
+			stats.Dead[row.Subscriber] = row.Count
+		}
+	}
+	return stats, nil
+}
+
+func counters(m *expvar.Map) map[string]int64 {
+	out := map[string]int64{}
+	m.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			out[kv.Key] = v.Value()
+		}
+	})
+	return out
+}
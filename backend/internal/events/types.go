@@ -0,0 +1,91 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ✅ UserRegistered: a new account, self-registered or through an invitation
+type UserRegistered struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	Username string    `json:"username"`
+	Invited  bool      `json:"invited"` // Created by accepting an organization invitation
+}
+
+func (UserRegistered) EventName() string { return "user.registered" }
+
+// ✅ EmailVerified: the user proved they control their (new) email address
+type EmailVerified struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	Username string    `json:"username"`
+}
+
+func (EmailVerified) EventName() string { return "user.email_verified" }
+
+// ✅ EmailChangeRequested: a confirmation link must go to the new address
+type EmailChangeRequested struct {
+	UserID   uuid.UUID `json:"user_id"`
+	NewEmail string    `json:"new_email"`
+	Token    string    `json:"token"` // Confirmation token for the emailed link
+}
+
+func (EmailChangeRequested) EventName() string { return "user.email_change_requested" }
+
+// ✅ EmailChanged: the new address was confirmed and is now the account's
+type EmailChanged struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	OldEmail string    `json:"old_email"`
+	NewEmail string    `json:"new_email"`
+}
+
+func (EmailChanged) EventName() string { return "user.email_changed" }
+
+// ✅ PasswordChanged: by the user, or through a reset link (which signs out every session)
+type PasswordChanged struct {
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+	Reset  bool      `json:"reset"`
+}
+
+func (PasswordChanged) EventName() string { return "user.password_changed" }
+
+// ✅ SessionRevoked: one session was signed out by someone other than itself
+type SessionRevoked struct {
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"session_id"`
+	Reason    string    `json:"reason"` // "signed_out", "token_revoked" or "reported_not_me"
+	ClientID  string    `json:"client_id,omitempty"`
+}
+
+func (SessionRevoked) EventName() string { return "session.revoked" }
+
+// ✅ UserDeleted: soft delete; every session is gone and the account is restorable until PurgeAfter
+type UserDeleted struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Email      string    `json:"email"`
+	Username   string    `json:"username"`
+	DeletedAt  time.Time `json:"deleted_at"`
+	PurgeAfter time.Time `json:"purge_after"`
+}
+
+func (UserDeleted) EventName() string { return "user.deleted" }
+
+// ✅ UserRestored: a soft-deleted account came back
+type UserRestored struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	Username string    `json:"username"`
+}
+
+func (UserRestored) EventName() string { return "user.restored" }
+
+// ✅ UserPurged: the account and everything belonging to it were erased
+type UserPurged struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (UserPurged) EventName() string { return "user.purged" }
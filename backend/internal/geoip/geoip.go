@@ -0,0 +1,136 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ✅ Location resolved for an IP address
+type Location struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// ✅ Human-readable location ("City, Region, Country"), empty when unknown
+func (l Location) String() string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{l.City, l.Region, l.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ✅ A contiguous IP range and its location
+type ipRange struct {
+	start    netip.Addr
+	end      netip.Addr
+	location Location
+}
+
+// ✅ Offline GeoIP Database (IP range CSV, e.g. the free DB-IP "city lite" export)
+//
+// Each row is: ip_start,ip_end,country[,region[,city]]. Extra columns are ignored.
+type Database struct {
+	ranges []ipRange
+}
+
+// ✅ Active database (nil when GEOIP_CSV is not configured)
+var active = loadFromEnv()
+
+// ✅ Load the database named by GEOIP_CSV, if any
+func loadFromEnv() *Database {
+	path := os.Getenv("GEOIP_CSV")
+	if path == "" {
+		return nil
+	}
+
+	db, err := Open(path)
+	if err != nil {
+		log.Printf("⚠️ WARNING: Could not load GeoIP database %s: %v", path, err)
+		return nil
+	}
+	log.Printf("✅ Loaded GeoIP database with %d ranges", len(db.ranges))
+	return db
+}
+
+// ✅ Open a GeoIP CSV file
+func Open(path string) (*Database, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// ✅ Parse GeoIP CSV rows (invalid rows are skipped)
+func Parse(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	db := &Database{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			continue
+		}
+
+		start, errStart := netip.ParseAddr(strings.TrimSpace(record[0]))
+		end, errEnd := netip.ParseAddr(strings.TrimSpace(record[1]))
+		if errStart != nil || errEnd != nil || start.Is4() != end.Is4() {
+			continue
+		}
+
+		entry := ipRange{start: start, end: end, location: Location{Country: strings.TrimSpace(record[2])}}
+		if len(record) > 3 {
+			entry.location.Region = strings.TrimSpace(record[3])
+		}
+		if len(record) > 4 {
+			entry.location.City = strings.TrimSpace(record[4])
+		}
+		db.ranges = append(db.ranges, entry)
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool { return db.ranges[i].start.Less(db.ranges[j].start) })
+	return db, nil
+}
+
+// ✅ Look up an IP address in this database
+func (db *Database) Lookup(ip string) (Location, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || db == nil {
+		return Location{}, false
+	}
+	addr = addr.Unmap()
+
+	// First range starting after addr; the candidate is the one before it
+	i := sort.Search(len(db.ranges), func(i int) bool { return addr.Less(db.ranges[i].start) })
+	if i == 0 {
+		return Location{}, false
+	}
+	candidate := db.ranges[i-1]
+	if candidate.start.Is4() != addr.Is4() || candidate.end.Less(addr) {
+		return Location{}, false
+	}
+	return candidate.location, true
+}
+
+// ✅ Look up an IP address in the active database (zero Location when unavailable)
+func Lookup(ip string) Location {
+	location, _ := active.Lookup(ip)
+	return location
+}
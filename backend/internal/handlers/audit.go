@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ Record an Audit Event for a user (failures are logged, never returned)
+//
+// Events recorded while impersonating name the admin as actor.
+func recordAudit(c *gin.Context, userID uuid.UUID, action string, metadata gin.H) {
+	var actorID *uuid.UUID
+	if id, err := uuid.Parse(c.GetString("actor_id")); err == nil {
+		actorID = &id
+	}
+	recordAuditAs(c, userID, actorID, action, metadata)
+}
+
+// ✅ Record an Audit Event performed by someone other than the user (nil actor = the user)
+func recordAuditAs(c *gin.Context, userID uuid.UUID, actorID *uuid.UUID, action string, metadata gin.H) {
+	event := models.AuditEvent{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ActorID:   actorID,
+		Action:    action,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	if len(metadata) > 0 {
+		if encoded, err := json.Marshal(metadata); err == nil {
+			event.Metadata = string(encoded)
+		}
+	}
+
+	if err := database.DB.Create(&event).Error; err != nil {
+		log.Println("⚠️ Failed to record audit event:", action, err)
+	}
+}
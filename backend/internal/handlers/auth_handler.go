@@ -1,175 +1,286 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
 	"github.com/thejpness/ArcadiaGo/internal/auth"
 	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/middleware"
 	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
 )
 
 // ✅ Register a new user
 func RegisterUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+	var req RegisterRequest
+	if !bindJSON(c, &req) {
 		return
 	}
-
-	log.Println("🔍 Received password:", user.Password) // Debug received password
+	req.Email = strings.TrimSpace(req.Email)
 
 	// ✅ Ensure Email Uniqueness
 	var existingUser models.User
-	if err := database.DB.Where("email = ?", user.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+	if err := database.DB.Where("LOWER(email) = ?", emailKey(req.Email)).First(&existingUser).Error; err == nil {
+		apierror.Abort(c, apierror.EmailTaken.New())
 		return
 	}
 
-	// ✅ Validate Password Strength
-	if err := auth.ValidatePassword(user.Password); err != nil {
-		log.Println("❌ Password validation failed:", err) // Debug validation
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// ✅ Ensure the username is free (case-insensitively, including recently released names)
+	if err := checkUsernameAvailable(database.DB, req.Username, "", uuid.Nil); err != nil {
+		respondUsernameUnavailable(c, err)
+		return
+	}
+
+	// ✅ Validate Password against the policy (the binding already checked the generic rules)
+	if err := auth.ValidatePasswordForUser(req.Password, req.Username, req.Email); err != nil {
+		log.Println("❌ Password validation failed:", err)
+		respondPasswordPolicyError(c, "password", err)
 		return
 	}
 
 	// ✅ Hash Password
-	hashedPassword, err := auth.HashPassword(user.Password)
+	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
 		log.Println("❌ Error hashing password:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		apierror.Abort(c, apierror.Internal.New())
 		return
 	}
 
-	user.ID = uuid.New()
-	user.Password = hashedPassword
+	user := models.User{
+		ID:            uuid.New(),
+		Email:         req.Email,
+		Username:      req.Username,
+		UsernameKey:   auth.FoldUsername(req.Username),
+		UsernameScope: "", // Self-registered users always have globally unique usernames
+		Password:      hashedPassword,
+	}
 
 	// ✅ Insert User into Database
-	if err := database.DB.Create(&user).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return publish(c, tx, events.UserRegistered{UserID: user.ID, Email: user.Email, Username: user.Username})
+	})
+	if err != nil {
 		log.Println("❌ Error inserting user:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
+		apierror.Abort(c, apierror.Internal.WithDetail("Could not create user"))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
+	c.JSON(http.StatusCreated, MessageResponse{Message: "User registered successfully"})
 }
 
 // ✅ Login user and issue tokens
 func LoginUser(c *gin.Context) {
-	var request struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+	var request LoginRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
 	// ✅ Fetch User by Email
 	var user models.User
-	if err := database.DB.Where("email = ?", request.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	if err := database.DB.Where("LOWER(email) = ?", emailKey(request.Email)).First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.InvalidCredentials.New())
 		return
 	}
 
 	// ✅ Check Password Hash
 	if !auth.CheckPassword(user.Password, request.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		recordAudit(c, user.ID, "auth.login_failed", nil)
+		apierror.Abort(c, apierror.InvalidCredentials.New())
 		return
 	}
 
-	// ✅ Generate JWT Access & Refresh Tokens using UUID
-	accessToken, err := auth.GenerateAccessToken(user.ID)
+	// ✅ Transparently upgrade outdated hashes (e.g. bcrypt -> Argon2id)
+	if auth.PasswordNeedsRehash(user.Password) {
+		if rehashed, err := auth.HashPassword(request.Password); err == nil {
+			if err := database.DB.Model(&user).Update("password", rehashed).Error; err != nil {
+				log.Println("⚠️ Failed to store upgraded password hash:", err)
+			} else {
+				log.Println("🔐 Upgraded password hash for user:", user.ID)
+			}
+		}
+	}
+
+	// ✅ Pick the active organization (requested one, or the first membership)
+	orgID, err := resolveActiveOrg(user.ID, request.OrgID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token generation failed"})
+		apierror.Abort(c, apierror.OrgNotMember.New())
 		return
 	}
 
-	refreshToken, err := auth.GenerateRefreshToken(user.ID)
+	// ✅ Generate JWT Access & Refresh Tokens and set cookies
+	sessionID, err := issueAuthCookies(c, auth.TokenSubject{UserID: user.ID, OrgID: orgID})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Refresh token generation failed"})
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
 		return
 	}
 
-	// ✅ Set Secure HttpOnly Cookies
-	c.SetCookie("auth_token", accessToken, 3600, "/", "", true, true)
-	c.SetCookie("refresh_token", refreshToken, int(7*24*time.Hour.Seconds()), "/", "", true, true)
+	// ✅ Warn the user about sign-ins from devices we have not seen before
+	checkNewDevice(c, &user, sessionID)
+
+	recordAudit(c, user.ID, "auth.login", gin.H{"method": "password"})
 
-	c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Login successful"})
 }
 
-// ✅ Logout user by clearing authentication & refresh token cookies
+// ✅ Logout user by revoking the session and clearing authentication & refresh token cookies
 func LogoutUser(c *gin.Context) {
-	c.SetCookie("auth_token", "", -1, "/", "", true, true)
-	c.SetCookie("refresh_token", "", -1, "/", "", true, true)
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+	if refreshToken, err := c.Cookie("refresh_token"); err == nil {
+		if claims, err := auth.ValidateToken(refreshToken, true); err == nil && claims.SessionID != "" {
+			database.DB.Where("id = ? AND user_id = ?", claims.SessionID, claims.UserID).Delete(&models.UserSession{})
+		}
+	}
+
+	clearAuthCookies(c)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Logged out successfully"})
 }
 
 // ✅ Refresh Access Token using a valid Refresh Token
 func RefreshToken(c *gin.Context) {
 	refreshToken, err := c.Cookie("refresh_token")
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No refresh token found"})
+		apierror.Abort(c, apierror.InvalidRefreshToken.WithDetail("No refresh token found"))
 		return
 	}
 
 	// ✅ Validate the Refresh Token
 	claims, err := auth.ValidateToken(refreshToken, true)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		apierror.Abort(c, apierror.InvalidRefreshToken.New())
 		return
 	}
 
 	// ✅ Convert UserID back to UUID
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	// ✅ The refresh token must still belong to a live session
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		apierror.Abort(c, apierror.SessionRevoked.New())
 		return
 	}
+	session, ok := activeSession(sessionID, userID, refreshToken)
+	if !ok {
+		apierror.Abort(c, apierror.SessionRevoked.New())
+		return
+	}
+
+	// ✅ Keep the active organization only while the membership still exists
+	orgID, _ := uuid.Parse(claims.OrgID)
+	if orgID != uuid.Nil && !isOrgMember(userID, orgID) {
+		orgID = uuid.Nil
+	}
 
 	// ✅ Generate a new Access Token
-	newAccessToken, err := auth.GenerateAccessToken(userID)
+	newAccessToken, err := auth.GenerateAccessToken(auth.TokenSubject{UserID: userID, OrgID: orgID, SessionID: sessionID, AuthTime: session.AuthTime})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate new access token"})
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
 		return
 	}
 
 	// ✅ Set new Secure HttpOnly Access Token Cookie
 	c.SetCookie("auth_token", newAccessToken, 3600, "/", "", true, true)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Token refreshed"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Token refreshed"})
 }
 
-// ✅ Fetch User Profile using UUID stored in JWT
-func GetUserProfile(c *gin.Context) {
+// ✅ Re-authenticate (sudo mode): confirm the password to unlock sensitive operations
+func Reauthenticate(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr.(string))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
-	// ✅ Ensure we fetch `username`, `email`, and `created_at`
-	var user struct {
-		Username  string `json:"username"`
-		Email     string `json:"email"`
-		CreatedAt string `json:"joined"`
+	var request ReauthenticateRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound.New())
+		return
 	}
 
-	// ✅ Query to fetch username, email, and created_at
-	err = database.DB.Raw("SELECT username, email, created_at FROM users WHERE id = ?", userID).Scan(&user).Error
+	if !auth.CheckPassword(user.Password, request.Password) {
+		recordAudit(c, userID, "auth.reauthenticate_failed", nil)
+		apierror.Abort(c, apierror.IncorrectPassword.New())
+		return
+	}
+
+	// ✅ Re-issue the session's tokens with a fresh auth_time
+	sessionID, _ := uuid.Parse(c.GetString("session_id"))
+	orgID, _ := uuid.Parse(c.GetString("org_id"))
+	authTime := time.Now()
+	if _, err := issueAuthCookies(c, auth.TokenSubject{UserID: userID, OrgID: orgID, SessionID: sessionID, AuthTime: authTime}); err != nil {
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
+		return
+	}
+
+	recordAudit(c, userID, "auth.reauthenticated", nil)
+
+	c.JSON(http.StatusOK, ReauthenticateResponse{Message: "Re-authenticated", ElevatedUntil: authTime.Add(middleware.SudoModeWindow())})
+}
+
+// ✅ Fetch User Profile using UUID stored in JWT
+func GetUserProfile(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound.New())
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserProfile(&user))
+}
+
+// ✅ Emails are matched case-insensitively: compare "LOWER(email) = ?" with this key
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ✅ Respond with every failed password rule so clients can show them all
+func respondPasswordPolicyError(c *gin.Context, field string, err error) {
+	problem := apierror.PasswordPolicyViolation.New()
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		for _, violation := range policyErr.Violations {
+			problem.WithFields(apierror.FieldError{Field: field, Code: violation.Rule, Message: violation.Message})
+		}
+	} else {
+		problem.WithFields(apierror.FieldError{Field: field, Code: "invalid", Message: err.Error()})
+	}
+	apierror.Abort(c, problem)
 }
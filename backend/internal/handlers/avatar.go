@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/avatar"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"github.com/thejpness/ArcadiaGo/internal/storage"
+)
+
+// ✅ Largest accepted avatar upload in bytes (AVATAR_MAX_BYTES, default 5 MiB)
+func avatarMaxBytes() int64 {
+	if value, err := strconv.ParseInt(os.Getenv("AVATAR_MAX_BYTES"), 10, 64); err == nil && value > 0 {
+		return value
+	}
+	return 5 << 20
+}
+
+// ✅ Upload Avatar (multipart field "avatar")
+//
+// Every upload gets a fresh storage prefix, so avatar URLs never change
+// content and can be cached indefinitely by browsers and CDNs.
+func UploadAvatar(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	// ✅ Cap the whole request body, leaving room for multipart framing
+	maxBytes := avatarMaxBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+64<<10)
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierror.Abort(c, apierror.PayloadTooLarge.WithDetail(fmt.Sprintf("Avatar must be at most %d KiB", maxBytes>>10)))
+			return
+		}
+		apierror.Abort(c, apierror.InvalidField("avatar", "required", "Missing avatar file"))
+		return
+	}
+	if file.Size > maxBytes {
+		apierror.Abort(c, apierror.PayloadTooLarge.WithDetail(fmt.Sprintf("Avatar must be at most %d KiB", maxBytes>>10)))
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidBody.WithDetail("Could not read avatar file"))
+		return
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxBytes+1))
+	if err != nil || int64(len(data)) > maxBytes {
+		apierror.Abort(c, apierror.PayloadTooLarge.WithDetail(fmt.Sprintf("Avatar must be at most %d KiB", maxBytes>>10)))
+		return
+	}
+
+	// ✅ Sniff, decode, strip metadata and render the fixed sizes
+	rendered, err := avatar.Process(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, avatar.ErrUnsupportedType):
+			apierror.Abort(c, apierror.UnsupportedMediaType.WithDetail(err.Error()))
+		case errors.Is(err, avatar.ErrTooLarge), errors.Is(err, avatar.ErrInvalidImage):
+			apierror.Abort(c, apierror.AvatarInvalid.WithDetail(err.Error()))
+		default:
+			log.Println("❌ Failed to process avatar:", err)
+			apierror.Abort(c, apierror.Internal.WithDetail("Failed to process avatar"))
+		}
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound.New())
+		return
+	}
+
+	version := make([]byte, 8)
+	if _, err := rand.Read(version); err != nil {
+		log.Println("❌ Failed to generate avatar version:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+	prefix := fmt.Sprintf("avatars/%s/%s", userID, hex.EncodeToString(version))
+
+	ctx := c.Request.Context()
+	store := storage.Active()
+	for _, size := range avatar.Sizes {
+		body := rendered[size]
+		if err := store.Put(ctx, avatar.ObjectKey(prefix, size), bytes.NewReader(body), int64(len(body)), "image/jpeg"); err != nil {
+			log.Println("❌ Failed to store avatar:", err)
+			avatar.Delete(ctx, prefix)
+			apierror.Abort(c, apierror.UpstreamFailed.WithDetail("Failed to store avatar"))
+			return
+		}
+	}
+
+	avatarURL := store.URL(avatar.ObjectKey(prefix, avatar.DefaultSize))
+	if err := database.DB.Model(&user).Updates(map[string]interface{}{"avatar_url": avatarURL, "avatar_key": prefix}).Error; err != nil {
+		log.Println("❌ Failed to save avatar:", err)
+		avatar.Delete(ctx, prefix)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to save avatar"))
+		return
+	}
+	avatar.Delete(ctx, user.AvatarKey) // Previous upload, if any
+
+	recordAudit(c, userID, "user.avatar_updated", nil)
+
+	urls := map[string]string{}
+	for _, size := range avatar.Sizes {
+		urls[strconv.Itoa(size)] = store.URL(avatar.ObjectKey(prefix, size))
+	}
+	c.JSON(http.StatusOK, AvatarResponse{AvatarURL: avatarURL, Sizes: urls})
+}
+
+// ✅ Remove Avatar
+func DeleteAvatar(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound.New())
+		return
+	}
+
+	if err := database.DB.Model(&user).Updates(map[string]interface{}{"avatar_url": "", "avatar_key": ""}).Error; err != nil {
+		log.Println("❌ Failed to remove avatar:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to remove avatar"))
+		return
+	}
+	avatar.Delete(c.Request.Context(), user.AvatarKey)
+
+	recordAudit(c, userID, "user.avatar_removed", nil)
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Avatar removed"})
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"os"
+	"strings"
+)
+
+// ✅ Path prefix the API is mounted under ("" at the root, e.g. "/auth" when embedded)
+var basePath string
+
+// ✅ Mount the API under a path prefix (redirects, default links and the OpenAPI servers entry include it)
+func SetBasePath(prefix string) {
+	basePath = prefix
+}
+
+// ✅ Public base URL used in links sent by email (APP_BASE_URL must include any mount prefix)
+func appBaseURL() string {
+	if url := os.Getenv("APP_BASE_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost:8080" + basePath
+}
+
+// ✅ Public base URL of the web app, for links that need a form (e.g. password reset)
+func frontendBaseURL() string {
+	if url := os.Getenv("FRONTEND_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost:5173"
+}
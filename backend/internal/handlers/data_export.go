@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ Export User Data (GDPR Art. 15/20) as a zip of JSON files
+func ExportUserData(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound.New())
+		return
+	}
+
+	// ✅ Gather everything held about the user (secrets such as hashes and tokens are omitted)
+	var sessions []models.UserSession
+	var emailChanges []models.UserEmailChange
+	var memberships []models.Membership
+	var auditEvents []models.AuditEvent
+	var magicLinks []models.MagicLinkToken
+	var devices []models.TrustedDevice
+	var usernames []models.UsernameHistory
+	var impersonations []models.Impersonation
+
+	queries := []struct {
+		name string
+		err  error
+	}{
+		{"sessions", database.DB.Where("user_id = ?", userID).Order("created_at").Find(&sessions).Error},
+		{"email changes", database.DB.Where("user_id = ?", userID).Order("created_at").Find(&emailChanges).Error},
+		{"memberships", database.DB.Preload("Organization").Where("user_id = ?", userID).Order("created_at").Find(&memberships).Error},
+		{"audit events", database.DB.Where("user_id = ?", userID).Order("created_at").Find(&auditEvents).Error},
+		{"magic links", database.DB.Where("user_id = ?", userID).Order("created_at").Find(&magicLinks).Error},
+		{"devices", database.DB.Where("user_id = ?", userID).Order("first_seen_at").Find(&devices).Error},
+		{"username history", database.DB.Where("user_id = ?", userID).Order("changed_at").Find(&usernames).Error},
+		{"impersonations", database.DB.Where("user_id = ?", userID).Order("created_at").Find(&impersonations).Error},
+	}
+	for _, q := range queries {
+		if q.err != nil {
+			log.Println("❌ Failed to export", q.name, "for user:", userID, q.err)
+			apierror.Abort(c, apierror.Internal.WithDetail("Failed to export data"))
+			return
+		}
+	}
+
+	files := map[string]interface{}{
+		"profile.json": gin.H{
+			"id":                user.ID,
+			"email":             user.Email,
+			"username":          user.Username,
+			"display_name":      user.DisplayName,
+			"avatar_url":        user.AvatarURL,
+			"locale":            user.Locale,
+			"timezone":          user.Timezone,
+			"bio":               user.Bio,
+			"email_verified_at": user.EmailVerifiedAt,
+			"created_at":        user.CreatedAt,
+			"updated_at":        user.UpdatedAt,
+		},
+		"sessions.json": sessionExports(sessions),
+		"email_changes.json": func() []gin.H {
+			out := make([]gin.H, 0, len(emailChanges))
+			for _, e := range emailChanges {
+				out = append(out, gin.H{"id": e.ID, "new_email": e.NewEmail, "created_at": e.CreatedAt})
+			}
+			return out
+		}(),
+		"organizations.json": func() []gin.H {
+			out := make([]gin.H, 0, len(memberships))
+			for _, m := range memberships {
+				out = append(out, gin.H{"organization_id": m.OrganizationID, "name": m.Organization.Name, "role": m.Role, "joined": m.CreatedAt})
+			}
+			return out
+		}(),
+		"audit_events.json": auditEvents,
+		"devices.json":      devices,
+		"username_history.json": func() []gin.H {
+			out := make([]gin.H, 0, len(usernames))
+			for _, u := range usernames {
+				out = append(out, gin.H{"username": u.Username, "changed_at": u.ChangedAt})
+			}
+			return out
+		}(),
+		"support_access.json": func() []gin.H {
+			out := make([]gin.H, 0, len(impersonations))
+			for _, i := range impersonations {
+				out = append(out, gin.H{"id": i.ID, "reason": i.Reason, "started_at": i.CreatedAt, "expires_at": i.ExpiresAt, "ended_at": i.EndedAt})
+			}
+			return out
+		}(),
+		"sign_in_links.json": func() []gin.H {
+			out := make([]gin.H, 0, len(magicLinks))
+			for _, m := range magicLinks {
+				out = append(out, gin.H{"id": m.ID, "ip_address": m.IPAddress, "created_at": m.CreatedAt, "expires_at": m.ExpiresAt, "used_at": m.UsedAt})
+			}
+			return out
+		}(),
+	}
+
+	exportedAt := time.Now().UTC()
+	files["manifest.json"] = gin.H{
+		"format":      "arcadiago-user-export",
+		"version":     1,
+		"user_id":     user.ID,
+		"exported_at": exportedAt,
+	}
+
+	filename := fmt.Sprintf("arcadiago-export-%s.zip", exportedAt.Format("20060102T150405Z"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			log.Println("❌ Failed to write export archive:", err)
+			return
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(content); err != nil {
+			log.Println("❌ Failed to encode export file:", name, err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Println("❌ Failed to finalize export archive:", err)
+		return
+	}
+
+	recordAudit(c, userID, "user.data_exported", nil)
+	log.Println("✅ Data export generated for user:", userID)
+}
+
+// ✅ Session rows without the token hash
+func sessionExports(sessions []models.UserSession) []gin.H {
+	out := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		out = append(out, gin.H{"id": s.ID, "ip_address": s.IPAddress, "user_agent": s.UserAgent, "device_name": s.DeviceName, "created_at": s.CreatedAt})
+	}
+	return out
+}
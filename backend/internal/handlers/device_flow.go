@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/didip/tollbooth/v7"
+	"github.com/didip/tollbooth/v7/limiter"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+)
+
+// ✅ Grant type a device polls /token with (RFC 8628 §3.4)
+const grantDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
+
+// ✅ User codes: 8 consonants (no vowels to spell words with, no look-alike digits), RFC 8628 §6.1
+const (
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+)
+
+// ✅ User code lookups: 10 per user, then 1 every 6 seconds (guessing someone else's code is pointless, but cheap to stop)
+var userCodeLimiter = tollbooth.NewLimiter(1.0/6, &limiter.ExpirableOptions{
+	DefaultExpirationTTL: time.Hour,
+}).SetBurst(10)
+
+// ✅ How long a device sign-in waits for the user (DEVICE_CODE_TTL, default 10m)
+func deviceCodeTTL() time.Duration {
+	return env.Duration("DEVICE_CODE_TTL", 10*time.Minute)
+}
+
+// ✅ Minimum time between polls (DEVICE_POLL_INTERVAL, default 5s)
+func devicePollInterval() time.Duration {
+	return env.Duration("DEVICE_POLL_INTERVAL", 5*time.Second)
+}
+
+// ✅ Start a Device Sign-In (RFC 8628 device authorization endpoint)
+//
+// The device shows the user code and verification URI, then polls /token
+// with the device code until the user has answered on another screen.
+func RequestDeviceCode(c *gin.Context) {
+	var req DeviceCodeRequest
+	if !bindForm(c, &req) {
+		return
+	}
+	client := c.MustGet("service_client").(*models.ServiceClient)
+
+	deviceName := strings.TrimSpace(req.DeviceName)
+	if deviceName == "" {
+		deviceName = client.Name
+	}
+
+	deviceCode, deviceCodeHash, err := auth.GenerateURLToken()
+	if err != nil {
+		log.Println("❌ Failed to generate device code:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+	userCode, err := generateUserCode()
+	if err != nil {
+		log.Println("❌ Failed to generate user code:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	// ✅ Expired sign-ins are pruned as new ones start (frees their user codes)
+	now := time.Now()
+	database.DB.Where("expires_at < ?", now).Delete(&models.DeviceAuthorization{})
+
+	ttl, interval := deviceCodeTTL(), devicePollInterval()
+	authorization := models.DeviceAuthorization{
+		ID:             uuid.New(),
+		ClientID:       client.ID,
+		DeviceCodeHash: deviceCodeHash,
+		UserCode:       userCode,
+		DeviceName:     deviceName,
+		IPAddress:      c.ClientIP(),
+		PollInterval:   int(interval.Seconds()),
+		ExpiresAt:      now.Add(ttl),
+	}
+	if err := database.DB.Create(&authorization).Error; err != nil {
+		log.Println("❌ Failed to store device authorization:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	verificationURI := appBaseURL() + "/device"
+	log.Printf("✅ Device sign-in started by client %s (%q)", client.ID, deviceName)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, DeviceCodeResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                formatUserCode(userCode),
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(formatUserCode(userCode)),
+		ExpiresIn:               int64(ttl.Seconds()),
+		Interval:                int64(authorization.PollInterval),
+	})
+}
+
+// ✅ Get a Pending Device Sign-In, so the user can check it before answering
+func GetDeviceAuthorization(c *gin.Context) {
+	var query UserCodeQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	if tollbooth.LimitByKeys(userCodeLimiter, []string{c.GetString("user_id")}) != nil {
+		apierror.Abort(c, apierror.RateLimited.New())
+		return
+	}
+
+	authorization, ok := pendingDeviceAuthorization(query.UserCode)
+	if !ok {
+		apierror.Abort(c, apierror.UserCodeInvalid.New())
+		return
+	}
+
+	var client models.ServiceClient
+	database.DB.Select("name").First(&client, "id = ?", authorization.ClientID)
+
+	c.JSON(http.StatusOK, DeviceAuthorizationInfo{
+		UserCode:   formatUserCode(authorization.UserCode),
+		ClientName: client.Name,
+		DeviceName: authorization.DeviceName,
+		IPAddress:  authorization.IPAddress,
+		CreatedAt:  authorization.CreatedAt,
+		ExpiresAt:  authorization.ExpiresAt,
+	})
+}
+
+// ✅ Approve or Decline a Device Sign-In
+//
+// Approving signs the device in as the current user (and organization); it
+// gets its own session, labelled with the device name. It does not count as
+// a fresh sign-in for sudo mode.
+func AnswerDeviceAuthorization(c *gin.Context) {
+	var req DeviceApprovalRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if tollbooth.LimitByKeys(userCodeLimiter, []string{c.GetString("user_id")}) != nil {
+		apierror.Abort(c, apierror.RateLimited.New())
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	authorization, ok := pendingDeviceAuthorization(req.UserCode)
+	if !ok {
+		apierror.Abort(c, apierror.UserCodeInvalid.New())
+		return
+	}
+
+	now := time.Now()
+	answer := map[string]interface{}{"denied_at": now}
+	if req.Approve {
+		authTime := time.Unix(c.GetInt64("auth_time"), 0)
+		answer = map[string]interface{}{"approved_at": now, "user_id": userID, "auth_time": authTime}
+		if orgID, err := uuid.Parse(c.GetString("org_id")); err == nil {
+			answer["org_id"] = orgID
+		}
+	}
+
+	// ✅ Answer once, even if two tabs race
+	result := database.DB.Model(&models.DeviceAuthorization{}).
+		Where("id = ? AND approved_at IS NULL AND denied_at IS NULL", authorization.ID).
+		Updates(answer)
+	if result.Error != nil {
+		log.Println("❌ Failed to answer device authorization:", result.Error)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierror.Abort(c, apierror.UserCodeInvalid.New())
+		return
+	}
+
+	metadata := gin.H{"client_id": authorization.ClientID, "device_name": authorization.DeviceName, "device_ip": authorization.IPAddress}
+	if !req.Approve {
+		recordAudit(c, userID, "device.denied", metadata)
+		c.JSON(http.StatusOK, MessageResponse{Message: "Device sign-in declined"})
+		return
+	}
+
+	recordAudit(c, userID, "device.approved", metadata)
+	log.Println("✅ Device sign-in approved by user:", userID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Device signed in"})
+}
+
+// ✅ device_code grant (RFC 8628 §3.4): the device polls until the user has answered
+func deviceCodeGrant(c *gin.Context, req TokenGrantRequest) {
+	client := c.MustGet("service_client").(*models.ServiceClient)
+	if req.DeviceCode == "" {
+		apierror.Abort(c, apierror.InvalidOAuthRequest.WithDetail("device_code is required"))
+		return
+	}
+
+	var authorization models.DeviceAuthorization
+	err := database.DB.Where("device_code_hash = ? AND client_id = ?", auth.HashURLToken(req.DeviceCode), client.ID).
+		First(&authorization).Error
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidGrant.New())
+		return
+	}
+
+	now := time.Now()
+	if now.After(authorization.ExpiresAt) {
+		apierror.Abort(c, apierror.ExpiredToken.New())
+		return
+	}
+
+	// ✅ Polling faster than the interval earns slow_down and a 5 second longer interval
+	interval := time.Duration(authorization.PollInterval) * time.Second
+	if authorization.LastPolledAt != nil && now.Sub(*authorization.LastPolledAt) < interval {
+		database.DB.Model(&authorization).Updates(map[string]interface{}{"poll_interval": gorm.Expr("poll_interval + 5"), "last_polled_at": now})
+		apierror.Abort(c, apierror.SlowDown.New().With("interval", authorization.PollInterval+5))
+		return
+	}
+	database.DB.Model(&authorization).Update("last_polled_at", now)
+
+	switch {
+	case authorization.DeniedAt != nil:
+		apierror.Abort(c, apierror.AccessDenied.New())
+		return
+	case authorization.ApprovedAt == nil || authorization.UserID == nil:
+		apierror.Abort(c, apierror.AuthorizationPending.New())
+		return
+	}
+
+	// ✅ Approved: the tokens are handed out once
+	result := database.DB.Where("id = ?", authorization.ID).Delete(&models.DeviceAuthorization{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		apierror.Abort(c, apierror.InvalidGrant.New())
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", *authorization.UserID).Error; err != nil {
+		apierror.Abort(c, apierror.InvalidGrant.New())
+		return
+	}
+
+	subject := auth.TokenSubject{UserID: user.ID}
+	if authorization.OrgID != nil && isOrgMember(user.ID, *authorization.OrgID) {
+		subject.OrgID = *authorization.OrgID
+	}
+	if authorization.AuthTime != nil {
+		subject.AuthTime = *authorization.AuthTime
+	}
+	tokens, err := issueTokens(c, subject, sessionOrigin{ClientID: client.ID, DeviceName: authorization.DeviceName})
+	if err != nil {
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
+		return
+	}
+
+	recordAudit(c, user.ID, "auth.login", gin.H{"method": "device_code", "client_id": client.ID, "device_name": authorization.DeviceName})
+
+	log.Println("✅ Device signed in for user:", user.ID)
+	respondToken(c, TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(auth.AccessTokenTTL.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// ✅ Unanswered, unexpired device sign-in with this user code
+func pendingDeviceAuthorization(userCode string) (*models.DeviceAuthorization, bool) {
+	var authorization models.DeviceAuthorization
+	err := database.DB.
+		Where("user_code = ? AND approved_at IS NULL AND denied_at IS NULL AND expires_at > ?", normalizeUserCode(userCode), time.Now()).
+		First(&authorization).Error
+	if err != nil {
+		return nil, false
+	}
+	return &authorization, true
+}
+
+// ✅ Random user code from userCodeAlphabet
+func generateUserCode() (string, error) {
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// ✅ User code as typed: case, spaces and dashes don't matter
+func normalizeUserCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+}
+
+// ✅ User code as displayed: "BDWP-HQTX"
+func formatUserCode(code string) string {
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
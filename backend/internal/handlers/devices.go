@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/geoip"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ Purpose of the "this wasn't me" link in new-device emails
+const notMePurpose = "not_me"
+
+// ✅ Record the device behind a new session and email the user if it is new
+//
+// A device is identified by its user agent and the network it signs in from.
+// A known device seen from a new country (when GeoIP is configured) is
+// treated as suspicious as well.
+func checkNewDevice(c *gin.Context, user *models.User, sessionID uuid.UUID) {
+	userAgent := c.Request.UserAgent()
+	ip := c.ClientIP()
+	location := geoip.Lookup(ip)
+	now := time.Now()
+
+	var device models.TrustedDevice
+	err := database.DB.Where("user_id = ? AND fingerprint = ?", user.ID, deviceFingerprint(userAgent, ip)).First(&device).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		device = models.TrustedDevice{
+			ID:          uuid.New(),
+			UserID:      user.ID,
+			Fingerprint: deviceFingerprint(userAgent, ip),
+			Name:        deviceName(userAgent),
+			UserAgent:   userAgent,
+			LastIP:      ip,
+			Location:    location.String(),
+			FirstSeenAt: now,
+			LastSeenAt:  now,
+		}
+		if err := database.DB.Create(&device).Error; err != nil {
+			log.Println("⚠️ Failed to record device:", err)
+			return
+		}
+		sendNewDeviceEmail(user, sessionID, device, "A new device signed in to your account")
+	case err != nil:
+		log.Println("⚠️ Failed to look up device:", err)
+	default:
+		movedCountry := location.Country != "" && !strings.HasSuffix(device.Location, location.Country)
+		database.DB.Model(&device).Updates(map[string]interface{}{"last_ip": ip, "last_seen_at": now, "location": location.String()})
+		if movedCountry {
+			device.LastIP, device.Location = ip, location.String()
+			sendNewDeviceEmail(user, sessionID, device, "Sign-in to your account from a new location")
+		}
+	}
+}
+
+// ✅ Email a new-device notice with a "this wasn't me" link
+func sendNewDeviceEmail(user *models.User, sessionID uuid.UUID, device models.TrustedDevice, subject string) {
+	token, err := auth.GenerateActionToken(notMePurpose, user.ID, 0, sessionID.String(), time.Now().Add(auth.RefreshTokenTTL))
+	if err != nil {
+		log.Println("❌ Failed to generate not-me token:", err)
+		return
+	}
+
+	location := device.Location
+	if location == "" {
+		location = "unknown location"
+	}
+	link := fmt.Sprintf("%s/not-me?token=%s", frontendBaseURL(), url.QueryEscape(token))
+	body := fmt.Sprintf("Your account (%s) was just signed in to from:\n\n  Device: %s\n  IP address: %s (%s)\n  Time: %s\n\n"+
+		"If this was you, you can ignore this email.\n\nIf this wasn't you, sign that device out and reset your password: %s",
+		user.Username, device.Name, device.LastIP, location, time.Now().Format(time.RFC1123), link)
+
+	go func(to string) {
+		if err := SendEmail(to, subject, body); err != nil {
+			log.Println("❌ Failed to send new device email:", err)
+		}
+	}(user.Email)
+}
+
+// ✅ Fingerprint a device from its user agent and network (the /24 or /64 its IP is in)
+//
+// A copied user agent alone does not make a device look familiar.
+func deviceFingerprint(userAgent, ip string) string {
+	network := ip
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		addr = addr.Unmap()
+		bits := 64
+		if addr.Is4() {
+			bits = 24
+		}
+		if prefix, err := addr.WithZone("").Prefix(bits); err == nil {
+			network = prefix.String()
+		}
+	}
+	return auth.HashURLToken(strings.TrimSpace(userAgent) + "\n" + network)
+}
+
+// ✅ Summarise a user agent as "Browser on OS"
+func deviceName(userAgent string) string {
+	browser := "Unknown browser"
+	for _, candidate := range []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"},
+		{"Safari/", "Safari"}, {"curl/", "curl"}, {"Go-http-client", "Go client"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	os := "unknown OS"
+	for _, candidate := range []struct{ token, name string }{
+		{"Windows", "Windows"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Android", "Android"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			os = candidate.name
+			break
+		}
+	}
+
+	return browser + " on " + os
+}
+
+// ✅ "This wasn't me": revoke the session, forget the device and start a password reset (public)
+//
+// The emailed link opens a confirmation page that posts the token here. Each
+// link works once.
+func ReportNotMe(c *gin.Context) {
+	var req ReportNotMeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := auth.ValidateActionToken(req.Token, notMePurpose)
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidLink.New())
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", claims.UserID).Error; err != nil {
+		apierror.Abort(c, apierror.InvalidLink.New())
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := useActionToken(tx, user.ID, claims); err != nil {
+			return err
+		}
+
+		var session models.UserSession
+		err := tx.Where("id = ? AND user_id = ?", claims.Target, user.ID).First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // Already signed out
+		} else if err != nil {
+			return err
+		}
+		if err := tx.Delete(&session).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND fingerprint = ?", user.ID, deviceFingerprint(session.UserAgent, session.IPAddress)).Delete(&models.TrustedDevice{}).Error; err != nil {
+			return err
+		}
+		return publish(c, tx, events.SessionRevoked{UserID: user.ID, SessionID: session.ID, Reason: "reported_not_me"})
+	})
+	if errors.Is(err, errActionTokenUsed) {
+		apierror.Abort(c, apierror.InvalidLink.New())
+		return
+	}
+	if err != nil {
+		log.Println("❌ Failed to revoke reported session:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	if err := sendPasswordResetEmail(&user); err != nil {
+		log.Println("❌ Failed to start password reset:", err)
+	}
+
+	recordAudit(c, user.ID, "session.reported_not_me", gin.H{"session_id": claims.Target})
+
+	log.Println("⚠️ User reported suspicious sign-in:", user.ID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "That device has been signed out. Check your email to reset your password."})
+}
+
+var errActionTokenUsed = errors.New("link was already used")
+
+// ✅ Mark a single-use action token as used inside tx (errActionTokenUsed the second time)
+func useActionToken(tx *gorm.DB, userID uuid.UUID, claims *auth.ActionClaims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return errActionTokenUsed // Issued before links carried an ID, so it cannot be tracked
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UsedActionToken{
+		ID:        claims.ID,
+		UserID:    userID,
+		Purpose:   claims.Purpose,
+		ExpiresAt: claims.ExpiresAt.Time,
+		UsedAt:    time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errActionTokenUsed
+	}
+	return nil
+}
+
+// ✅ List Trusted Devices
+func ListTrustedDevices(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var devices []models.TrustedDevice
+	if err := database.DB.Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&devices).Error; err != nil {
+		log.Println("❌ Failed to retrieve devices:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to retrieve devices"))
+		return
+	}
+
+	c.JSON(http.StatusOK, DeviceListResponse{Devices: devices})
+}
+
+// ✅ Rename a Trusted Device
+func RenameTrustedDevice(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var req RenameDeviceRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		apierror.Abort(c, apierror.InvalidField("name", "required", "is required"))
+		return
+	}
+
+	result := database.DB.Model(&models.TrustedDevice{}).Where("id = ? AND user_id = ?", c.Param("device_id"), userID).Update("name", name)
+	if result.Error != nil || result.RowsAffected == 0 {
+		apierror.Abort(c, apierror.DeviceNotFound.New())
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Device renamed"})
+}
+
+// ✅ Forget a Trusted Device (the next sign-in from it triggers a notification again)
+func RemoveTrustedDevice(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	deviceID, err := uuid.Parse(c.Param("device_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidParameter.WithDetail("Invalid device ID"))
+		return
+	}
+
+	result := database.DB.Where("id = ? AND user_id = ?", deviceID, userID).Delete(&models.TrustedDevice{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		apierror.Abort(c, apierror.DeviceNotFound.New())
+		return
+	}
+
+	recordAudit(c, userID, "device.removed", gin.H{"device_id": deviceID})
+
+	log.Println("✅ Trusted device removed for user:", userID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Device removed"})
+}
@@ -1,41 +1,39 @@
 package handlers
 
 import (
-	"fmt"
 	"log"
 	"net/http"
-	"net/smtp"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
 	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/lifecycle"
+	"github.com/thejpness/ArcadiaGo/internal/mailer"
 	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
 )
 
-// RequestEmailVerification handles email change requests by sending a confirmation email
+// RequestEmailVerification handles email change requests; the new address gets a confirmation email
 func RequestEmailVerification(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		log.Println("❌ user_id not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr.(string))
 	if err != nil {
 		log.Println("❌ Invalid user ID:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
-	var req struct {
-		NewEmail string `json:"new_email"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("❌ Invalid request format:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	var req EmailChangeRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -43,9 +41,9 @@ func RequestEmailVerification(c *gin.Context) {
 
 	// Ensure the new email is not already registered
 	var existingUser models.User
-	if err := database.DB.Where("email = ?", req.NewEmail).First(&existingUser).Error; err == nil {
+	if err := database.DB.Where("LOWER(email) = ?", emailKey(req.NewEmail)).First(&existingUser).Error; err == nil {
 		log.Println("❌ Email already registered:", req.NewEmail)
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+		apierror.Abort(c, apierror.EmailTaken.New())
 		return
 	}
 
@@ -61,105 +59,88 @@ func RequestEmailVerification(c *gin.Context) {
 		CreatedAt: time.Now(),
 	}
 
-	if err := database.DB.Create(&emailChange).Error; err != nil {
-		log.Println("❌ Failed to create email verification request:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create email verification request"})
-		return
-	}
-
-	// Send confirmation email via MailHog
-	err = SendEmail(req.NewEmail, "Confirm Email Change",
-		fmt.Sprintf("Click here to confirm your email change: http://localhost:8080/confirm-email?token=%s", token))
+	// The mailer subscriber sends the confirmation email
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// An expired request for the address no longer reserves it
+		if err := tx.Where("LOWER(new_email) = ? AND created_at < ?", emailKey(req.NewEmail), time.Now().Add(-lifecycle.EmailChangeTTL())).Delete(&models.UserEmailChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&emailChange).Error; err != nil {
+			return err
+		}
+		return publish(c, tx, events.EmailChangeRequested{UserID: userID, NewEmail: req.NewEmail, Token: token})
+	})
 	if err != nil {
-		log.Println("❌ Failed to send email:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send confirmation email"})
+		log.Println("❌ Failed to create email verification request:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to create email verification request"))
 		return
 	}
 
-	log.Println("✅ Email change request stored and verification email sent")
-	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+	log.Println("✅ Email change request stored")
+	c.JSON(http.StatusOK, MessageResponse{Message: "Verification email sent"})
 }
 
 // ConfirmEmailVerification verifies the token and updates the user's email
 func ConfirmEmailVerification(c *gin.Context) {
-	token := c.Query("token")
+	var query TokenQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	token := query.Token
 
-	// Check if the token exists in the user_email_changes table
+	// Check if the token exists in the user_email_changes table and has not expired
 	var request models.UserEmailChange
-	if err := database.DB.Where("token = ?", token).First(&request).Error; err != nil {
+	if err := database.DB.Where("token = ? AND created_at >= ?", token, time.Now().Add(-lifecycle.EmailChangeTTL())).First(&request).Error; err != nil {
 		log.Println("❌ Invalid or expired token:", token)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid or expired token"})
+		apierror.Abort(c, apierror.InvalidLink.New())
 		return
 	}
 
 	log.Println("🔍 Found email change request for user:", request.UserID, "New Email:", request.NewEmail)
 
-	// Start a transaction to ensure atomicity
-	tx := database.DB.Begin()
-
-	// Fetch the user by ID
+	// Update the email and consume the request atomically (nests as a savepoint inside an outer transaction)
 	var user models.User
-	if err := tx.Where("id = ?", request.UserID).First(&user).Error; err != nil {
-		log.Println("❌ User not found in users table:", request.UserID, err)
-		tx.Rollback()
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-	log.Println("👀 Current Email before update:", user.Email)
-
-	// Update the user's email
-	if err := tx.Model(&models.User{}).Where("id = ?", request.UserID).Update("email", request.NewEmail).Error; err != nil {
-		log.Println("❌ Failed to update email for user:", request.UserID, err)
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email"})
-		return
-	}
-
-	// Fetch the user after the update to confirm the change
-	var updatedUser models.User
-	if err := tx.Where("id = ?", request.UserID).First(&updatedUser).Error; err == nil {
-		log.Println("✅ Updated Email:", updatedUser.Email)
-	} else {
-		log.Println("❌ Failed to fetch updated user email:", err)
-	}
-
-	// Delete the email change request only if the update was successful
-	if err := tx.Where("token = ?", token).Delete(&models.UserEmailChange{}).Error; err != nil {
-		log.Println("❌ Failed to delete email verification request:", err)
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finalize email update"})
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Fetch the user by ID
+		if err := tx.Where("id = ?", request.UserID).First(&user).Error; err != nil {
+			log.Println("❌ User not found in users table:", request.UserID, err)
+			return apierror.UserNotFound.New()
+		}
+		log.Println("👀 Current Email before update:", user.Email)
+
+		// Update the user's email
+		if err := tx.Model(&models.User{}).Where("id = ?", request.UserID).Updates(map[string]interface{}{"email": request.NewEmail, "email_verified_at": time.Now()}).Error; err != nil {
+			log.Println("❌ Failed to update email for user:", request.UserID, err)
+			return apierror.Internal.WithDetail("Failed to update email")
+		}
+
+		// Delete the email change request only if the update was successful
+		if err := tx.Where("token = ?", token).Delete(&models.UserEmailChange{}).Error; err != nil {
+			log.Println("❌ Failed to delete email verification request:", err)
+			return apierror.Internal.WithDetail("Failed to finalize email update")
+		}
+
+		// ✅ Confirming the link also verifies the new address
+		evts := []events.Event{events.EmailChanged{UserID: user.ID, Username: user.Username, OldEmail: user.Email, NewEmail: request.NewEmail}}
+		if user.EmailVerifiedAt == nil {
+			evts = append(evts, events.EmailVerified{UserID: user.ID, Email: request.NewEmail, Username: user.Username})
+		}
+		if err := publish(c, tx, evts...); err != nil {
+			log.Println("❌ Failed to publish email change:", err)
+			return apierror.Internal.WithDetail("Failed to finalize email update")
+		}
+		return nil
+	})
+	if err != nil {
+		apierror.Abort(c, err)
 		return
 	}
 
-	// Commit the transaction
-	tx.Commit()
-
 	log.Println("✅ Email updated successfully for user:", request.UserID)
-	c.JSON(http.StatusOK, gin.H{"message": "Email updated successfully"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Email updated successfully"})
 }
 
-// SendEmail sends an email using MailHog (SMTP)
+// SendEmail sends an email through the configured mailer (MailHog SMTP by default)
 func SendEmail(to, subject, body string) error {
-	smtpHost := "localhost"
-	smtpPort := "1025" // MailHog SMTP port
-	from := "no-reply@arcadiago.dev"
-
-	// Construct email headers
-	msg := []byte(
-		"From: " + from + "\r\n" +
-			"To: " + to + "\r\n" +
-			"Subject: " + subject + "\r\n" +
-			"\r\n" +
-			body + "\r\n",
-	)
-
-	// Send email using MailHog
-	err := smtp.SendMail(smtpHost+":"+smtpPort, nil, from, []string{to}, msg)
-	if err != nil {
-		log.Println("❌ Failed to send email:", err)
-		return err
-	}
-
-	log.Println("📧 Email sent successfully to:", to)
-	return nil
+	return mailer.Send(to, subject, body)
 }
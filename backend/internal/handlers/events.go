@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ Who caused the events of this request and from where (the admin, when impersonating)
+func eventMeta(c *gin.Context) events.Meta {
+	meta := events.Meta{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString(apierror.RequestIDKey),
+	}
+	if id, err := uuid.Parse(c.GetString("actor_id")); err == nil {
+		meta.ActorID = &id
+	}
+	return meta
+}
+
+// ✅ Publish events inside tx, attributed to the current request
+func publish(c *gin.Context, tx *gorm.DB, evts ...events.Event) error {
+	return events.Publish(tx, eventMeta(c), evts...)
+}
+
+// ✅ Subscribe the "audit" and "mailer" subscribers to the event bus
+func SubscribeEvents() {
+	// ✅ Audit trail
+	events.On("audit", func(_ context.Context, d events.Delivery, e events.UserRegistered) error {
+		var metadata gin.H
+		if e.Invited {
+			metadata = gin.H{"invited": true}
+		}
+		return auditDelivery(d, e.UserID, "user.registered", metadata)
+	})
+	events.On("audit", func(_ context.Context, d events.Delivery, e events.EmailChangeRequested) error {
+		return auditDelivery(d, e.UserID, "user.email_change_requested", gin.H{"new_email": e.NewEmail})
+	})
+	events.On("audit", func(_ context.Context, d events.Delivery, e events.EmailChanged) error {
+		return auditDelivery(d, e.UserID, "user.email_changed", gin.H{"old_email": e.OldEmail, "new_email": e.NewEmail})
+	})
+	events.On("audit", func(_ context.Context, d events.Delivery, e events.PasswordChanged) error {
+		if e.Reset {
+			return auditDelivery(d, e.UserID, "auth.password_reset", nil)
+		}
+		return auditDelivery(d, e.UserID, "user.password_changed", nil)
+	})
+	events.On("audit", func(_ context.Context, d events.Delivery, e events.SessionRevoked) error {
+		metadata := gin.H{"session_id": e.SessionID, "reason": e.Reason}
+		if e.ClientID != "" {
+			metadata["client_id"] = e.ClientID
+		}
+		return auditDelivery(d, e.UserID, "session.revoked", metadata)
+	})
+	events.On("audit", func(_ context.Context, d events.Delivery, e events.UserDeleted) error {
+		return auditDelivery(d, e.UserID, "user.deleted", nil)
+	})
+	events.On("audit", func(_ context.Context, d events.Delivery, e events.UserRestored) error {
+		return auditDelivery(d, e.UserID, "user.restored", nil)
+	})
+
+	// ✅ Emails (each sent at most once per delivery, see sendOnce)
+	events.On("mailer", func(_ context.Context, d events.Delivery, e events.EmailChangeRequested) error {
+		return sendOnce(d, e.NewEmail, "Confirm Email Change",
+			fmt.Sprintf("Click here to confirm your email change: %s/confirm-email?token=%s", appBaseURL(), e.Token))
+	})
+	events.On("mailer", func(_ context.Context, d events.Delivery, e events.PasswordChanged) error {
+		if !e.Reset {
+			return nil
+		}
+		return sendOnce(d, e.Email, "Your password was changed", "Your password was just reset and all devices have been signed out.\nIf this wasn't you, contact support immediately.")
+	})
+	events.On("mailer", func(_ context.Context, d events.Delivery, e events.UserDeleted) error {
+		// ✅ A signed restore link to the confirmation page, valid until the purge deadline
+		restoreToken, err := auth.GenerateActionToken(restoreAccountPurpose, e.UserID, e.DeletedAt.UnixMicro(), "", e.PurgeAfter)
+		if err != nil {
+			return err
+		}
+		link := fmt.Sprintf("%s/restore-account?token=%s", frontendBaseURL(), url.QueryEscape(restoreToken))
+		return sendOnce(d, e.Email, "Your account has been deleted",
+			fmt.Sprintf("Your account (%s) has been deleted and you have been signed out everywhere.\n\n"+
+				"Changed your mind? Restore it before %s: %s\n\nAfter that date it will be permanently erased.",
+				e.Username, e.PurgeAfter.Format(time.RFC1123), link))
+	})
+}
+
+// ✅ Event Bus Stats (admin)
+func GetEventStats(c *gin.Context) {
+	stats, err := events.Snapshot()
+	if err != nil {
+		log.Println("❌ Failed to read event stats:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+	c.JSON(http.StatusOK, EventStatsResponse{Handled: stats.Handled, Failures: stats.Failures, Pending: stats.Pending, Dead: stats.Dead})
+}
+
+// ✅ Namespace of audit event IDs derived from event deliveries
+var auditNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("urn:arcadia:audit"))
+
+// ✅ Record the Audit Event for a delivery, once however often it is delivered
+func auditDelivery(d events.Delivery, userID uuid.UUID, action string, metadata gin.H) error {
+	event := models.AuditEvent{
+		ID:        uuid.NewSHA1(auditNamespace, []byte(d.Key)),
+		UserID:    userID,
+		ActorID:   d.Meta.ActorID,
+		Action:    action,
+		IPAddress: d.Meta.IPAddress,
+		UserAgent: d.Meta.UserAgent,
+		CreatedAt: d.OccurredAt,
+	}
+	if len(metadata) > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		event.Metadata = string(encoded)
+	}
+	return database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&event).Error
+}
+
+// ✅ Send the email of a delivery, once however often it is delivered
+//
+// The delivery key is recorded before sending and forgotten again if the send
+// fails, so a failed attempt is retried. An instance dying between the two
+// loses that email instead of sending it twice.
+func sendOnce(d events.Delivery, to, subject, body string) error {
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.SentEmail{DeliveryKey: d.Key, EventID: d.EventID, SentAt: time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil // An earlier attempt sent it
+	}
+
+	if err := SendEmail(to, subject, body); err != nil {
+		if forgetErr := database.DB.Where("delivery_key = ?", d.Key).Delete(&models.SentEmail{}).Error; forgetErr != nil {
+			log.Println("❌ Failed to forget unsent email:", d.Key, forgetErr)
+		}
+		return err
+	}
+	return nil
+}
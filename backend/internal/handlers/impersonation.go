@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ Lifetime of impersonation tokens (IMPERSONATION_TTL, default 15m)
+func impersonationTTL() time.Duration {
+	return env.Duration("IMPERSONATION_TTL", 15*time.Minute)
+}
+
+// ✅ Start Impersonating a User (support staff, sudo mode)
+//
+// The token acts as the user, with the admin in its "act" claim. The user is
+// emailed, every request made with the token is audited with the admin as
+// actor, and the middleware.DenyImpersonation blocklist applies.
+func StartImpersonation(c *gin.Context) {
+	actorID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+	actorSessionID, err := uuid.Parse(c.GetString("session_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var req StartImpersonationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", req.UserID).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound.New())
+		return
+	}
+	if user.IsAdmin || user.ID == actorID {
+		apierror.Abort(c, apierror.ImpersonationNotAllowed.New())
+		return
+	}
+
+	ttl := impersonationTTL()
+	impersonation := models.Impersonation{
+		ID:             uuid.New(),
+		ActorID:        actorID,
+		ActorSessionID: actorSessionID,
+		UserID:         user.ID,
+		Reason:         req.Reason,
+		ExpiresAt:      time.Now().Add(ttl),
+	}
+	if err := database.DB.Create(&impersonation).Error; err != nil {
+		log.Println("❌ Failed to store impersonation:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	orgID, err := resolveActiveOrg(user.ID, uuid.Nil)
+	if err != nil {
+		log.Println("⚠️ Failed to resolve active organization:", err)
+	}
+	token, err := auth.GenerateImpersonationToken(auth.TokenSubject{UserID: user.ID, OrgID: orgID, SessionID: impersonation.ID}, actorID, ttl)
+	if err != nil {
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
+		return
+	}
+
+	// ✅ Both accounts keep a record: the user's names the admin as actor
+	metadata := gin.H{"impersonation_id": impersonation.ID, "reason": req.Reason}
+	recordAuditAs(c, user.ID, &actorID, "impersonation.started", metadata)
+	recordAudit(c, actorID, "admin.impersonation_started", gin.H{"impersonation_id": impersonation.ID, "user_id": user.ID, "reason": req.Reason})
+
+	go func(to string) {
+		if err := SendEmail(to, "Support accessed your account",
+			fmt.Sprintf("A member of our support team started viewing your account as you at %s, for up to %d minutes.\n\nReason: %s\n\nThey can see your account and your organizations as you do, but cannot change or download anything. If you did not ask us for help, please contact us.",
+				impersonation.CreatedAt.UTC().Format(time.RFC1123), int(ttl.Minutes()), req.Reason)); err != nil {
+			log.Println("❌ Failed to send impersonation notice:", err)
+		}
+	}(user.Email)
+
+	log.Println("✅ Admin", actorID, "started impersonating user:", user.ID)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, ImpersonationResponse{
+		ImpersonationID: impersonation.ID,
+		UserID:          user.ID,
+		AccessToken:     token,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(ttl.Seconds()),
+		ExpiresAt:       impersonation.ExpiresAt,
+	})
+}
+
+// ✅ Stop Impersonating (called with the impersonation token, which stops working at once)
+func StopImpersonation(c *gin.Context) {
+	actorID, err := uuid.Parse(c.GetString("actor_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.NotImpersonating.New())
+		return
+	}
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	impersonationID := c.GetString("impersonation_id")
+	if err := endImpersonation(impersonationID); err != nil {
+		log.Println("❌ Failed to end impersonation:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	recordAudit(c, userID, "impersonation.ended", gin.H{"impersonation_id": impersonationID})
+	recordAuditAs(c, actorID, nil, "admin.impersonation_ended", gin.H{"impersonation_id": impersonationID, "user_id": userID})
+
+	log.Println("✅ Admin", actorID, "stopped impersonating user:", userID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Impersonation ended"})
+}
+
+// ✅ End an impersonation now (a no-op when it already ended)
+func endImpersonation(impersonationID string) error {
+	return database.DB.Model(&models.Impersonation{}).
+		Where("id = ? AND ended_at IS NULL", impersonationID).
+		Update("ended_at", time.Now()).Error
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+)
+
+// ✅ How long an invitation link stays valid (ORG_INVITE_TTL, default 72h)
+func orgInviteTTL() time.Duration {
+	return env.Duration("ORG_INVITE_TTL", 72*time.Hour)
+}
+
+// ✅ Create Organization Invitation (org admins)
+func CreateOrgInvitation(c *gin.Context) {
+	caller := c.MustGet("org_membership").(models.Membership)
+
+	var req CreateInvitationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Role == "" {
+		req.Role = models.OrgRoleMember
+	}
+	if !canAssignOrgRole(caller.Role, req.Role) {
+		apierror.Abort(c, apierror.OrgRoleNotAssignable.New())
+		return
+	}
+
+	// ✅ Skip people who already belong to the organization
+	var existingUser models.User
+	if err := database.DB.Where("LOWER(email) = ?", emailKey(req.Email)).First(&existingUser).Error; err == nil && isOrgMember(existingUser.ID, caller.OrganizationID) {
+		apierror.Abort(c, apierror.OrgAlreadyMember.New())
+		return
+	}
+
+	// ✅ One pending invitation per email (use resend instead)
+	var pending int64
+	database.DB.Model(&models.OrgInvitation{}).
+		Where("organization_id = ? AND LOWER(email) = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", caller.OrganizationID, emailKey(req.Email), time.Now()).
+		Count(&pending)
+	if pending > 0 {
+		apierror.Abort(c, apierror.InvitationAlreadyExists.New())
+		return
+	}
+
+	token, tokenHash, err := auth.GenerateURLToken()
+	if err != nil {
+		log.Println("❌ Failed to generate invitation token:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	invite := models.OrgInvitation{
+		ID:             uuid.New(),
+		OrganizationID: caller.OrganizationID,
+		Email:          req.Email,
+		Role:           req.Role,
+		TokenHash:      tokenHash,
+		InvitedByID:    caller.UserID,
+		ExpiresAt:      time.Now().Add(orgInviteTTL()),
+	}
+
+	// ✅ Only keep the invitation if its email went out
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&invite).Error; err != nil {
+			log.Println("❌ Failed to create invitation:", err)
+			return err
+		}
+		if err := sendOrgInvitationEmail(&invite, token); err != nil {
+			log.Println("❌ Failed to send invitation email:", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to create invitation"))
+		return
+	}
+
+	log.Println("✅ Invitation created for", invite.Email, "to organization:", invite.OrganizationID)
+	c.JSON(http.StatusCreated, InvitationResponse{Invitation: invite})
+}
+
+// ✅ List Pending Invitations (org admins)
+func ListOrgInvitations(c *gin.Context) {
+	caller := c.MustGet("org_membership").(models.Membership)
+
+	var invites []models.OrgInvitation
+	err := database.DB.
+		Where("organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", caller.OrganizationID, time.Now()).
+		Order("created_at DESC").
+		Find(&invites).Error
+	if err != nil {
+		log.Println("❌ Failed to retrieve invitations:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to retrieve invitations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, InvitationListResponse{Invitations: invites})
+}
+
+// ✅ Resend Invitation (rotates the token and restarts the expiry)
+func ResendOrgInvitation(c *gin.Context) {
+	caller := c.MustGet("org_membership").(models.Membership)
+
+	invite, ok := findOrgInvitation(c, caller.OrganizationID)
+	if !ok {
+		return
+	}
+	if invite.AcceptedAt != nil || invite.RevokedAt != nil {
+		apierror.Abort(c, apierror.InvitationNotPending.New())
+		return
+	}
+
+	token, tokenHash, err := auth.GenerateURLToken()
+	if err != nil {
+		log.Println("❌ Failed to generate invitation token:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	// ✅ Keep the old link working if the new one cannot be sent
+	invite.TokenHash = tokenHash
+	invite.ExpiresAt = time.Now().Add(orgInviteTTL())
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(invite).Updates(map[string]interface{}{"token_hash": invite.TokenHash, "expires_at": invite.ExpiresAt}).Error; err != nil {
+			log.Println("❌ Failed to update invitation:", err)
+			return err
+		}
+		if err := sendOrgInvitationEmail(invite, token); err != nil {
+			log.Println("❌ Failed to send invitation email:", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to resend invitation"))
+		return
+	}
+
+	log.Println("✅ Invitation resent to", invite.Email)
+	c.JSON(http.StatusOK, InvitationResponse{Message: "Invitation resent", Invitation: *invite})
+}
+
+// ✅ Revoke Invitation
+func RevokeOrgInvitation(c *gin.Context) {
+	caller := c.MustGet("org_membership").(models.Membership)
+
+	invite, ok := findOrgInvitation(c, caller.OrganizationID)
+	if !ok {
+		return
+	}
+	if invite.AcceptedAt != nil {
+		apierror.Abort(c, apierror.InvitationNotPending.WithDetail("Invitation was already accepted"))
+		return
+	}
+
+	if err := database.DB.Model(invite).Update("revoked_at", time.Now()).Error; err != nil {
+		log.Println("❌ Failed to revoke invitation:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to revoke invitation"))
+		return
+	}
+
+	log.Println("✅ Invitation revoked:", invite.ID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Invitation revoked"})
+}
+
+// ✅ Preview Invitation from the emailed token (public)
+func GetInvitation(c *gin.Context) {
+	var query TokenQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	invite, err := pendingInvitationByToken(query.Token)
+	if err != nil {
+		apierror.Abort(c, apierror.InvitationInvalid.New())
+		return
+	}
+
+	var accountCount int64
+	database.DB.Model(&models.User{}).Where("LOWER(email) = ?", emailKey(invite.Email)).Count(&accountCount)
+
+	c.JSON(http.StatusOK, InvitationPreview{
+		Organization:  invite.Organization.Name,
+		Email:         invite.Email,
+		Role:          invite.Role,
+		ExpiresAt:     invite.ExpiresAt,
+		AccountExists: accountCount > 0,
+	})
+}
+
+// ✅ Accept Invitation (public)
+//
+// Existing accounts confirm with their password. New accounts are registered
+// with the invited email already verified, since the token proves ownership.
+func AcceptInvitation(c *gin.Context) {
+	var req AcceptInvitationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	invite, err := pendingInvitationByToken(req.Token)
+	if err != nil {
+		apierror.Abort(c, apierror.InvitationInvalid.New())
+		return
+	}
+
+	// ✅ Deleted accounts still hold their email until they are purged
+	var user models.User
+	lookupErr := database.DB.Unscoped().Where("LOWER(email) = ?", emailKey(invite.Email)).First(&user).Error
+	switch {
+	case lookupErr == nil && user.DeletedAt.Valid:
+		apierror.Abort(c, apierror.InvitationAccountDeleted.New())
+		return
+	case lookupErr == nil:
+		// ✅ Link existing account
+		if !auth.CheckPassword(user.Password, req.Password) {
+			apierror.Abort(c, apierror.InvalidCredentials.New())
+			return
+		}
+	case errors.Is(lookupErr, gorm.ErrRecordNotFound):
+		// ✅ Register new account through the invitation
+		if err := auth.ValidateUsername(req.Username); err != nil {
+			apierror.Abort(c, usernameProblem("username", err))
+			return
+		}
+		if err := auth.ValidatePasswordForUser(req.Password, req.Username, invite.Email); err != nil {
+			respondPasswordPolicyError(c, "password", err)
+			return
+		}
+
+		scope := invite.Organization.UsernameScope()
+		if err := checkUsernameAvailable(database.DB, req.Username, scope, uuid.Nil); err != nil {
+			respondUsernameUnavailable(c, err)
+			return
+		}
+
+		hashedPassword, err := auth.HashPassword(req.Password)
+		if err != nil {
+			apierror.Abort(c, apierror.Internal.New())
+			return
+		}
+
+		now := time.Now()
+		user = models.User{
+			ID:              uuid.New(),
+			Email:           invite.Email,
+			Username:        req.Username,
+			UsernameKey:     auth.FoldUsername(req.Username),
+			UsernameScope:   scope,
+			Password:        hashedPassword,
+			EmailVerifiedAt: &now,
+		}
+	default:
+		log.Println("❌ Failed to look up invited user:", lookupErr)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	// ✅ Create user (if new), membership and mark invitation accepted atomically
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if lookupErr != nil {
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			if err := publish(c, tx, events.UserRegistered{UserID: user.ID, Email: user.Email, Username: user.Username, Invited: true}); err != nil {
+				return err
+			}
+		}
+
+		// Consume the invitation only if it is still pending (guards against double accept)
+		result := tx.Model(&models.OrgInvitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invite.ID).
+			Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invitation already used")
+		}
+
+		if isOrgMember(user.ID, invite.OrganizationID) {
+			return nil
+		}
+		return tx.Create(&models.Membership{
+			ID:             uuid.New(),
+			OrganizationID: invite.OrganizationID,
+			UserID:         user.ID,
+			Role:           invite.Role,
+		}).Error
+	})
+	if err != nil {
+		log.Println("❌ Failed to accept invitation:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to accept invitation"))
+		return
+	}
+
+	// ✅ Sign the user straight into the organization they joined
+	if _, err := issueAuthCookies(c, auth.TokenSubject{UserID: user.ID, OrgID: invite.OrganizationID}); err != nil {
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
+		return
+	}
+
+	recordAudit(c, user.ID, "org.invitation_accepted", gin.H{"org_id": invite.OrganizationID, "role": invite.Role})
+
+	log.Println("✅ Invitation accepted by user:", user.ID, "organization:", invite.OrganizationID)
+	c.JSON(http.StatusOK, InvitationAcceptedResponse{Message: "Invitation accepted", OrgID: invite.OrganizationID})
+}
+
+// ✅ Look up a pending invitation from a raw emailed token
+func pendingInvitationByToken(token string) (*models.OrgInvitation, error) {
+	if token == "" {
+		return nil, errors.New("missing token")
+	}
+
+	var invite models.OrgInvitation
+	if err := database.DB.Preload("Organization").Where("token_hash = ?", auth.HashURLToken(token)).First(&invite).Error; err != nil {
+		return nil, err
+	}
+	if !invite.IsPending(time.Now()) {
+		return nil, errors.New("invitation is not pending")
+	}
+	return &invite, nil
+}
+
+// ✅ Load an invitation from the :invite_id route param within an organization
+func findOrgInvitation(c *gin.Context, orgID uuid.UUID) (*models.OrgInvitation, bool) {
+	inviteID, err := uuid.Parse(c.Param("invite_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidParameter.WithDetail("Invalid invitation ID"))
+		return nil, false
+	}
+
+	var invite models.OrgInvitation
+	if err := database.DB.Where("id = ? AND organization_id = ?", inviteID, orgID).First(&invite).Error; err != nil {
+		apierror.Abort(c, apierror.InvitationNotFound.New())
+		return nil, false
+	}
+	return &invite, true
+}
+
+// ✅ Email an invitation link
+func sendOrgInvitationEmail(invite *models.OrgInvitation, token string) error {
+	var org models.Organization
+	if err := database.DB.First(&org, "id = ?", invite.OrganizationID).Error; err != nil {
+		log.Println("❌ Organization not found for invitation:", invite.ID)
+		return err
+	}
+
+	link := fmt.Sprintf("%s/invitations?token=%s", appBaseURL(), url.QueryEscape(token))
+	return SendEmail(invite.Email, "You're invited to join "+org.Name,
+		fmt.Sprintf("You have been invited to join %s as %s.\n\nAccept the invitation: %s\n\nThis link expires on %s.",
+			org.Name, invite.Role, link, invite.ExpiresAt.Format(time.RFC1123)))
+}
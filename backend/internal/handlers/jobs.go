@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/jobs"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ List Scheduled Jobs with their next and last run
+func ListJobs(c *gin.Context) {
+	states, err := jobs.List(time.Now())
+	if err != nil {
+		log.Println("❌ Failed to list jobs:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	var runIDs []uuid.UUID
+	for _, state := range states {
+		if state.LastRunID != nil {
+			runIDs = append(runIDs, *state.LastRunID)
+		}
+	}
+	var runs []models.JobRun
+	if len(runIDs) > 0 {
+		if err := database.DB.Where("id IN ?", runIDs).Find(&runs).Error; err != nil {
+			log.Println("❌ Failed to load last job runs:", err)
+			apierror.Abort(c, apierror.Internal.New())
+			return
+		}
+	}
+
+	list := make([]JobInfo, 0, len(states))
+	for i := range states {
+		info := jobInfo(&states[i])
+		for j := range runs {
+			if states[i].LastRunID != nil && runs[j].ID == *states[i].LastRunID {
+				last := jobRunInfo(&runs[j])
+				info.LastRun = &last
+			}
+		}
+		list = append(list, info)
+	}
+	c.JSON(http.StatusOK, JobListResponse{Jobs: list})
+}
+
+// ✅ Run History of a Job (newest first)
+func ListJobRuns(c *gin.Context) {
+	name, ok := jobFromPath(c)
+	if !ok {
+		return
+	}
+
+	var query JobRunQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = 50
+	}
+
+	db := database.DB.Where("job = ?", name)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	var runs []models.JobRun
+	if err := db.Order("started_at DESC").Limit(query.Limit).Find(&runs).Error; err != nil {
+		log.Println("❌ Failed to list job runs:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	list := make([]JobRunInfo, 0, len(runs))
+	for i := range runs {
+		list = append(list, jobRunInfo(&runs[i]))
+	}
+	c.JSON(http.StatusOK, JobRunListResponse{Runs: list})
+}
+
+// ✅ Run a Job Now (at the next poll of whichever instance takes its lock)
+func RunJob(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+	name, ok := jobFromPath(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if err := jobs.Trigger(name, now); err != nil {
+		log.Println("❌ Failed to request job run:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+	var state models.ScheduledJob
+	if err := database.DB.First(&state, "name = ?", name).Error; err != nil {
+		log.Println("❌ Failed to load job:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	recordAudit(c, adminID, "admin.job_triggered", gin.H{"job": name})
+
+	log.Println("✅ Job run requested:", name)
+	c.JSON(http.StatusAccepted, jobInfo(&state))
+}
+
+// ✅ The registered job named in the path (aborts with 404 otherwise)
+func jobFromPath(c *gin.Context) (string, bool) {
+	name := c.Param("job")
+	if !slices.Contains(jobs.Names(), name) {
+		apierror.Abort(c, apierror.JobNotFound.New())
+		return "", false
+	}
+	return name, true
+}
+
+func jobInfo(state *models.ScheduledJob) JobInfo {
+	return JobInfo{
+		Name:        state.Name,
+		Schedule:    state.Schedule,
+		NextRunAt:   state.NextRunAt,
+		RequestedAt: state.RequestedAt,
+	}
+}
+
+func jobRunInfo(run *models.JobRun) JobRunInfo {
+	return JobRunInfo{
+		ID:         run.ID,
+		Job:        run.Job,
+		Status:     run.Status,
+		Trigger:    run.Trigger,
+		Instance:   run.Instance,
+		Affected:   run.Affected,
+		Error:      run.Error,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+	}
+}
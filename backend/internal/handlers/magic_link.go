@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/didip/tollbooth/v7"
+	"github.com/didip/tollbooth/v7/limiter"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+)
+
+const magicLinkNonceCookie = "magic_link_nonce"
+
+// ✅ Magic link requests: 3 per email and per IP, then 1 every 5 minutes
+var magicLinkLimiter = tollbooth.NewLimiter(1.0/300, &limiter.ExpirableOptions{
+	DefaultExpirationTTL: time.Hour,
+}).SetBurst(3)
+
+// ✅ How long a magic link stays valid (MAGIC_LINK_TTL, default 15m)
+func magicLinkTTL() time.Duration {
+	return env.Duration("MAGIC_LINK_TTL", 15*time.Minute)
+}
+
+// ✅ Request a Magic Sign-In Link
+//
+// The response is identical whether or not the account exists, and the email
+// is sent in the background so timing does not reveal it either.
+func RequestMagicLink(c *gin.Context) {
+	var req MagicLinkRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	email := emailKey(req.Email)
+
+	// ✅ Rate limit per email and per IP
+	if tollbooth.LimitByKeys(magicLinkLimiter, []string{"email", email}) != nil ||
+		tollbooth.LimitByKeys(magicLinkLimiter, []string{"ip", c.ClientIP()}) != nil {
+		log.Println("⚠️ Magic link rate limit hit for IP:", c.ClientIP())
+		apierror.Abort(c, apierror.RateLimited.New())
+		return
+	}
+
+	// ✅ Bind the link to this browser with a nonce cookie
+	nonce, nonceHash, err := auth.GenerateURLToken()
+	if err != nil {
+		log.Println("❌ Failed to generate magic link nonce:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+	ttl := magicLinkTTL()
+	c.SetSameSite(http.SameSiteLaxMode) // Must survive the top-level navigation from the email client
+	c.SetCookie(magicLinkNonceCookie, nonce, int(ttl.Seconds()), "/", "", true, true)
+
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = ?", email).First(&user).Error; err == nil {
+		token, tokenHash, err := auth.GenerateURLToken()
+		if err != nil {
+			log.Println("❌ Failed to generate magic link token:", err)
+			apierror.Abort(c, apierror.Internal.New())
+			return
+		}
+
+		magicLink := models.MagicLinkToken{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			TokenHash: tokenHash,
+			NonceHash: nonceHash,
+			IPAddress: c.ClientIP(),
+			ExpiresAt: time.Now().Add(ttl),
+		}
+		if err := database.DB.Create(&magicLink).Error; err != nil {
+			log.Println("❌ Failed to store magic link:", err)
+			apierror.Abort(c, apierror.Internal.New())
+			return
+		}
+
+		link := fmt.Sprintf("%s/login/magic-link/verify?token=%s", appBaseURL(), url.QueryEscape(token))
+		go func(to string) {
+			if err := SendEmail(to, "Your sign-in link",
+				fmt.Sprintf("Click here to sign in: %s\n\nThis link works once, only in the browser you requested it from, and expires in %d minutes.\nIf you didn't request it, you can ignore this email.", link, int(ttl.Minutes()))); err != nil {
+				log.Println("❌ Failed to send magic link email:", err)
+			}
+		}(user.Email)
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "If an account exists for that email, a sign-in link has been sent"})
+}
+
+// ✅ Verify a Magic Link and sign the user in (same cookies as LoginUser)
+func VerifyMagicLink(c *gin.Context) {
+	var query TokenQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	token := query.Token
+	nonce, err := c.Cookie(magicLinkNonceCookie)
+	if err != nil {
+		apierror.Abort(c, apierror.MagicLinkInvalid.New())
+		return
+	}
+
+	var magicLink models.MagicLinkToken
+	if err := database.DB.Where("token_hash = ?", auth.HashURLToken(token)).First(&magicLink).Error; err != nil {
+		apierror.Abort(c, apierror.MagicLinkInvalid.New())
+		return
+	}
+
+	if magicLink.UsedAt != nil || time.Now().After(magicLink.ExpiresAt) {
+		apierror.Abort(c, apierror.MagicLinkInvalid.New())
+		return
+	}
+
+	// ✅ The link only works in the browser that requested it
+	if subtle.ConstantTimeCompare([]byte(auth.HashURLToken(nonce)), []byte(magicLink.NonceHash)) != 1 {
+		log.Println("⚠️ Magic link opened without matching nonce for user:", magicLink.UserID)
+		apierror.Abort(c, apierror.MagicLinkWrongBrowser.WithDetail("Open the sign-in link in the browser you requested it from"))
+		return
+	}
+
+	// ✅ Consume the token (single use, safe against concurrent clicks)
+	now := time.Now()
+	result := database.DB.Model(&models.MagicLinkToken{}).Where("id = ? AND used_at IS NULL", magicLink.ID).Update("used_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		apierror.Abort(c, apierror.MagicLinkInvalid.New())
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", magicLink.UserID).Error; err != nil {
+		apierror.Abort(c, apierror.MagicLinkInvalid.New())
+		return
+	}
+
+	// ✅ Receiving the link proves the user controls the email address
+	if user.EmailVerifiedAt == nil {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&user).Update("email_verified_at", now).Error; err != nil {
+				return err
+			}
+			return publish(c, tx, events.EmailVerified{UserID: user.ID, Email: user.Email, Username: user.Username})
+		})
+		if err != nil {
+			log.Println("⚠️ Failed to mark email verified:", err)
+		}
+	}
+
+	orgID, err := resolveActiveOrg(user.ID, uuid.Nil)
+	if err != nil {
+		log.Println("⚠️ Failed to resolve active organization:", err)
+	}
+
+	sessionID, err := issueAuthCookies(c, auth.TokenSubject{UserID: user.ID, OrgID: orgID})
+	if err != nil {
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
+		return
+	}
+	c.SetCookie(magicLinkNonceCookie, "", -1, "/", "", true, true)
+
+	checkNewDevice(c, &user, sessionID)
+
+	recordAudit(c, user.ID, "auth.login", gin.H{"method": "magic_link"})
+
+	log.Println("✅ Magic link sign-in for user:", user.ID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Login successful"})
+}
@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/middleware"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+)
+
+// ✅ Token type hints (RFC 7009 §2.1)
+const (
+	hintAccessToken  = "access_token"
+	hintRefreshToken = "refresh_token"
+)
+
+// ✅ Grant types the token endpoint supports (plus grantDeviceCode)
+const (
+	grantClientCredentials = "client_credentials"
+	grantRefreshToken      = "refresh_token"
+)
+
+// ✅ Lifetime of client_credentials tokens (SERVICE_TOKEN_TTL, default 15m)
+//
+// They cannot be revoked one by one: disabling the client makes them
+// introspect as inactive, and JWKS-verifying services see that at expiry.
+func serviceTokenTTL() time.Duration {
+	return env.Duration("SERVICE_TOKEN_TTL", 15*time.Minute)
+}
+
+// ✅ Issue a Token (RFC 6749 token endpoint)
+//
+// Public clients may poll device sign-ins and refresh their sessions, only
+// confidential ones get service tokens.
+func IssueToken(c *gin.Context) {
+	var req TokenGrantRequest
+	if !bindForm(c, &req) {
+		return
+	}
+
+	switch req.GrantType {
+	case grantClientCredentials:
+		clientCredentialsGrant(c, req)
+	case grantDeviceCode:
+		deviceCodeGrant(c, req)
+	case grantRefreshToken:
+		refreshTokenGrant(c, req)
+	default:
+		apierror.Abort(c, apierror.UnsupportedGrantType.WithDetail("Unsupported grant_type "+strconv.Quote(req.GrantType)))
+	}
+}
+
+// ✅ client_credentials grant (RFC 6749 §4.4): a service token for the authenticated client
+func clientCredentialsGrant(c *gin.Context, req TokenGrantRequest) {
+	client := c.MustGet("service_client").(*models.ServiceClient)
+	if client.AuthMethod == models.ClientAuthNone {
+		apierror.Abort(c, apierror.UnauthorizedClient.WithDetail("Public clients cannot get service tokens"))
+		return
+	}
+
+	scope, ok := grantedScope(client.AllowedScopes, req.Scope)
+	if !ok {
+		apierror.Abort(c, apierror.InvalidScope.WithDetail("Requested scope is not allowed for this client"))
+		return
+	}
+
+	ttl := serviceTokenTTL()
+	token, err := auth.GenerateClientToken(auth.ClientSubject{
+		ClientID:       client.ID,
+		Scope:          scope,
+		CertThumbprint: c.GetString("client_cert_thumbprint"), // mTLS clients get certificate-bound tokens
+	}, ttl)
+	if err != nil {
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
+		return
+	}
+
+	log.Printf("✅ Issued service token to client %s (scope: %q)", client.ID, scope)
+	respondToken(c, TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		Scope:       scope,
+	})
+}
+
+// ✅ refresh_token grant (RFC 6749 §6): a new access token for a device-flow session
+//
+// Only sessions issued to the calling client qualify; browser sessions keep
+// refreshing through their cookies at /refresh. The refresh token itself is
+// not rotated, as with /refresh.
+func refreshTokenGrant(c *gin.Context, req TokenGrantRequest) {
+	client := c.MustGet("service_client").(*models.ServiceClient)
+	if req.RefreshToken == "" {
+		apierror.Abort(c, apierror.InvalidOAuthRequest.WithDetail("refresh_token is required"))
+		return
+	}
+
+	claims, err := auth.ValidateToken(req.RefreshToken, true)
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidGrant.New())
+		return
+	}
+	userID, errUser := uuid.Parse(claims.UserID)
+	sessionID, errSession := uuid.Parse(claims.SessionID)
+	if errUser != nil || errSession != nil {
+		apierror.Abort(c, apierror.InvalidGrant.New())
+		return
+	}
+	session, ok := activeSession(sessionID, userID, req.RefreshToken)
+	if !ok || session.ClientID != client.ID {
+		apierror.Abort(c, apierror.InvalidGrant.New())
+		return
+	}
+
+	// ✅ Keep the active organization only while the membership still exists
+	orgID, _ := uuid.Parse(claims.OrgID)
+	if orgID != uuid.Nil && !isOrgMember(userID, orgID) {
+		orgID = uuid.Nil
+	}
+
+	accessToken, err := auth.GenerateAccessToken(auth.TokenSubject{UserID: userID, OrgID: orgID, SessionID: sessionID, AuthTime: session.AuthTime})
+	if err != nil {
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
+		return
+	}
+
+	respondToken(c, TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(auth.AccessTokenTTL.Seconds()),
+	})
+}
+
+// ✅ Send a token response, which must never be cached (RFC 6749 §5.1)
+func respondToken(c *gin.Context, resp TokenResponse) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, resp)
+}
+
+// ✅ Scopes to grant: the requested ones if all are allowed, every allowed one when none are requested
+func grantedScope(allowed, requested string) (string, bool) {
+	allowedSet := strings.Fields(allowed)
+	if strings.TrimSpace(requested) == "" {
+		return strings.Join(allowedSet, " "), true
+	}
+
+	var granted []string
+	for _, scope := range strings.Fields(requested) {
+		if !slices.Contains(allowedSet, scope) {
+			return "", false
+		}
+		if !slices.Contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	return strings.Join(granted, " "), true
+}
+
+// ✅ How long resource servers may cache an introspection answer (INTROSPECTION_CACHE_TTL, default 30s)
+//
+// This is also the longest a revoked session can stay usable at a service
+// that introspects, so keep it short.
+func introspectionCacheTTL() time.Duration {
+	return env.Duration("INTROSPECTION_CACHE_TTL", 30*time.Second)
+}
+
+// ✅ Introspect a Token (RFC 7662)
+//
+// Called by service clients. A token is active while its signature and
+// expiry check out AND its session still exists: logout, remote sign-out and
+// account deletion make it inactive at once, unlike a local JWT check.
+// Refresh tokens are only active while they are the session's current one,
+// and service tokens while their client is enabled.
+func IntrospectToken(c *gin.Context) {
+	var req TokenRequest
+	if !bindForm(c, &req) {
+		return
+	}
+
+	resp := introspect(req.Token, req.TokenTypeHint)
+
+	// ✅ Cacheable briefly, and never past the token's own expiry
+	maxAge := introspectionCacheTTL()
+	if resp.Active {
+		if remaining := time.Until(time.Unix(resp.ExpiresAt, 0)); remaining < maxAge {
+			maxAge = remaining
+		}
+	}
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds())))
+	c.Header("Vary", "Authorization")
+
+	log.Printf("✅ Token introspected by client %s (active: %t)", c.GetString("client_id"), resp.Active)
+	c.JSON(http.StatusOK, resp)
+}
+
+// ✅ Revoke a Token (RFC 7009)
+//
+// Revoking either token of a session signs the whole session out, so the
+// access token stops introspecting as active too. Unknown, expired and
+// already revoked tokens get the same 200, as the RFC requires; so do
+// service tokens, which have no session (see serviceTokenTTL). Revoking an
+// impersonation token ends the impersonation.
+func RevokeToken(c *gin.Context) {
+	var req TokenRequest
+	if !bindForm(c, &req) {
+		return
+	}
+
+	if claims, _, ok := validateAnyToken(req.Token, req.TokenTypeHint); ok && claims.IsImpersonation() {
+		if err := endImpersonation(claims.SessionID); err != nil {
+			log.Println("❌ Failed to end impersonation:", err)
+		}
+	} else if ok {
+		userID, errUser := uuid.Parse(claims.UserID)
+		sessionID, errSession := uuid.Parse(claims.SessionID)
+		if errUser == nil && errSession == nil {
+			var revoked bool
+			err := database.DB.Transaction(func(tx *gorm.DB) error {
+				result := tx.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&models.UserSession{})
+				if result.Error != nil || result.RowsAffected == 0 {
+					return result.Error
+				}
+				revoked = true
+				return publish(c, tx, events.SessionRevoked{UserID: userID, SessionID: sessionID, Reason: "token_revoked", ClientID: c.GetString("client_id")})
+			})
+			if err != nil {
+				log.Println("❌ Failed to revoke session:", err)
+			} else if revoked {
+				log.Println("✅ Session revoked via revocation endpoint for user:", userID)
+			}
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, MessageResponse{Message: "Token revoked"})
+}
+
+// ✅ Introspection result for a token (inactive unless it is valid and its session is live)
+func introspect(token, hint string) IntrospectionResponse {
+	claims, tokenType, ok := validateAnyToken(token, hint)
+	if !ok {
+		return IntrospectionResponse{Active: false}
+	}
+
+	live := false
+	switch {
+	case claims.IsService():
+		live = clientEnabled(claims.ClientID)
+	case claims.IsImpersonation():
+		live = middleware.ImpersonationActive(claims.SessionID, claims.UserID, claims.Actor.Subject)
+	case tokenType == hintAccessToken:
+		live = sessionLive(claims.SessionID, claims.UserID)
+	case tokenType == hintRefreshToken:
+		userID, errUser := uuid.Parse(claims.UserID)
+		sessionID, errSession := uuid.Parse(claims.SessionID)
+		if errUser == nil && errSession == nil {
+			_, live = activeSession(sessionID, userID, token) // Rotated-out refresh tokens are inactive
+		}
+	}
+	if !live {
+		return IntrospectionResponse{Active: false}
+	}
+
+	resp := IntrospectionResponse{
+		Active:    true,
+		TokenType: tokenType,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Subject:   claims.Subject,
+		UserID:    claims.UserID,
+		OrgID:     claims.OrgID,
+		SessionID: claims.SessionID,
+		AuthTime:  claims.AuthTime,
+		Cnf:       claims.Confirmation,
+		Act:       claims.Actor,
+	}
+	if resp.Subject == "" {
+		resp.Subject = claims.UserID // Tokens issued before the sub claim existed
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Unix()
+	}
+	return resp
+}
+
+// ✅ Validate a token of either type, trying the hinted type first
+func validateAnyToken(token, hint string) (*auth.Claims, string, bool) {
+	order := []string{hintAccessToken, hintRefreshToken}
+	if hint == hintRefreshToken {
+		order = []string{hintRefreshToken, hintAccessToken}
+	}
+	for _, tokenType := range order {
+		if claims, err := auth.ValidateToken(token, tokenType == hintRefreshToken); err == nil {
+			return claims, tokenType, true
+		}
+	}
+	return nil, "", false
+}
+
+// ✅ Check that a service client exists and is not disabled
+func clientEnabled(clientID string) bool {
+	var count int64
+	database.DB.Model(&models.ServiceClient{}).Where("id = ? AND disabled_at IS NULL", clientID).Count(&count)
+	return count > 0
+}
+
+// ✅ Check that a session exists, belongs to the user and has not expired
+func sessionLive(sessionID, userID string) bool {
+	if sessionID == "" {
+		return false
+	}
+
+	var count int64
+	database.DB.Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND expires_at > ?", sessionID, userID, time.Now()).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ Pick the organization a session should be scoped to
+//
+// A requested org must be one the user belongs to; otherwise the oldest
+// membership is used, or uuid.Nil when the user has none.
+func resolveActiveOrg(userID, requested uuid.UUID) (uuid.UUID, error) {
+	if requested != uuid.Nil {
+		if !isOrgMember(userID, requested) {
+			return uuid.Nil, errors.New("not a member of this organization")
+		}
+		return requested, nil
+	}
+
+	var membership models.Membership
+	err := database.DB.Where("user_id = ?", userID).Order("created_at ASC").First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return membership.OrganizationID, nil
+}
+
+// ✅ Check whether a user belongs to an organization
+func isOrgMember(userID, orgID uuid.UUID) bool {
+	var count int64
+	database.DB.Model(&models.Membership{}).Where("user_id = ? AND organization_id = ?", userID, orgID).Count(&count)
+	return count > 0
+}
+
+// ✅ Create Organization (creator becomes owner)
+func CreateOrganization(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var req CreateOrganizationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	if req.Name == "" {
+		apierror.Abort(c, apierror.InvalidField("name", "required", "is required"))
+		return
+	}
+
+	// ✅ Ensure Slug Uniqueness
+	var existing models.Organization
+	if err := database.DB.Unscoped().Where("slug = ?", req.Slug).First(&existing).Error; err == nil {
+		apierror.Abort(c, apierror.OrgSlugTaken.New())
+		return
+	}
+
+	org := models.Organization{
+		ID:              uuid.New(),
+		Name:            req.Name,
+		Slug:            req.Slug,
+		ScopedUsernames: req.ScopedUsernames,
+	}
+
+	// ✅ Create organization and owner membership atomically
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Membership{
+			ID:             uuid.New(),
+			OrganizationID: org.ID,
+			UserID:         userID,
+			Role:           models.OrgRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		log.Println("❌ Failed to create organization:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to create organization"))
+		return
+	}
+
+	log.Println("✅ Organization created:", org.Slug, "by user:", userID)
+	c.JSON(http.StatusCreated, OrganizationResponse{Organization: org})
+}
+
+// ✅ List the caller's organizations and roles
+func ListMyOrganizations(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var memberships []models.Membership
+	if err := database.DB.Preload("Organization").Where("user_id = ?", userID).Order("created_at ASC").Find(&memberships).Error; err != nil {
+		log.Println("❌ Failed to retrieve organizations:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to retrieve organizations"))
+		return
+	}
+
+	activeOrg := c.GetString("org_id")
+	orgs := make([]OrgSummary, 0, len(memberships))
+	for _, m := range memberships {
+		orgs = append(orgs, OrgSummary{
+			ID:     m.Organization.ID,
+			Name:   m.Organization.Name,
+			Slug:   m.Organization.Slug,
+			Role:   m.Role,
+			Active: m.OrganizationID.String() == activeOrg,
+		})
+	}
+
+	c.JSON(http.StatusOK, OrganizationListResponse{Organizations: orgs})
+}
+
+// ✅ Switch Active Organization (re-issues tokens with the new org claim)
+func SwitchOrganization(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var req SwitchOrganizationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if !isOrgMember(userID, req.OrgID) {
+		apierror.Abort(c, apierror.OrgNotMember.New())
+		return
+	}
+
+	// ✅ Keep the current session, only the org claim changes
+	sessionID, _ := uuid.Parse(c.GetString("session_id"))
+	if _, err := issueAuthCookies(c, auth.TokenSubject{UserID: userID, OrgID: req.OrgID, SessionID: sessionID}); err != nil {
+		log.Println("❌ Failed to issue tokens:", err)
+		apierror.Abort(c, apierror.TokenGenerationFailed.New())
+		return
+	}
+
+	recordAudit(c, userID, "org.switched", gin.H{"org_id": req.OrgID})
+
+	log.Println("✅ User", userID, "switched to organization:", req.OrgID)
+	c.JSON(http.StatusOK, OrgSwitchedResponse{Message: "Organization switched", OrgID: req.OrgID})
+}
+
+// ✅ List Organization Members (org admins)
+func ListOrgMembers(c *gin.Context) {
+	orgID := c.MustGet("org_membership").(models.Membership).OrganizationID
+
+	members := []OrgMember{}
+	err := database.DB.Model(&models.Membership{}).
+		Select("memberships.user_id, users.username, users.email, memberships.role, memberships.created_at AS joined").
+		Joins("JOIN users ON users.id = memberships.user_id AND users.deleted_at IS NULL").
+		Where("memberships.organization_id = ?", orgID).
+		Order("memberships.created_at ASC").
+		Scan(&members).Error
+	if err != nil {
+		log.Println("❌ Failed to retrieve members:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to retrieve members"))
+		return
+	}
+
+	c.JSON(http.StatusOK, MemberListResponse{Members: members})
+}
+
+// ✅ Add an existing user to the organization by email (org admins)
+func AddOrgMember(c *gin.Context) {
+	caller := c.MustGet("org_membership").(models.Membership)
+
+	var req AddOrgMemberRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.OrgRoleMember
+	}
+	if !canAssignOrgRole(caller.Role, req.Role) {
+		apierror.Abort(c, apierror.OrgRoleNotAssignable.New())
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = ?", emailKey(req.Email)).First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound.WithDetail("No account with that email"))
+		return
+	}
+
+	if isOrgMember(user.ID, caller.OrganizationID) {
+		apierror.Abort(c, apierror.OrgAlreadyMember.New())
+		return
+	}
+
+	membership := models.Membership{
+		ID:             uuid.New(),
+		OrganizationID: caller.OrganizationID,
+		UserID:         user.ID,
+		Role:           req.Role,
+	}
+	if err := database.DB.Create(&membership).Error; err != nil {
+		log.Println("❌ Failed to add member:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to add member"))
+		return
+	}
+
+	// ✅ Let the user know (failure to notify does not undo the membership)
+	var org models.Organization
+	if err := database.DB.First(&org, "id = ?", caller.OrganizationID).Error; err == nil {
+		if err := SendEmail(user.Email, "You've been added to "+org.Name,
+			fmt.Sprintf("You have been added to %s as %s. Sign in to switch to it.", org.Name, req.Role)); err != nil {
+			log.Println("⚠️ Failed to send membership notification:", err)
+		}
+	}
+
+	log.Println("✅ User", user.ID, "added to organization:", caller.OrganizationID, "as", req.Role)
+	c.JSON(http.StatusCreated, MemberAddedResponse{Message: "Member added", Membership: membership})
+}
+
+// ✅ Change a Member's Role (org admins; only owners manage owners)
+func UpdateOrgMemberRole(c *gin.Context) {
+	caller := c.MustGet("org_membership").(models.Membership)
+
+	targetID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidParameter.WithDetail("Invalid user ID"))
+		return
+	}
+
+	var req UpdateOrgMemberRoleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var target models.Membership
+	if err := database.DB.Where("organization_id = ? AND user_id = ?", caller.OrganizationID, targetID).First(&target).Error; err != nil {
+		apierror.Abort(c, apierror.OrgMemberNotFound.New())
+		return
+	}
+
+	if !canAssignOrgRole(caller.Role, req.Role) || !canAssignOrgRole(caller.Role, target.Role) {
+		apierror.Abort(c, apierror.OrgRoleNotAssignable.New())
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if target.Role == models.OrgRoleOwner && req.Role != models.OrgRoleOwner {
+			if err := lockOtherOrgOwner(tx, caller.OrganizationID, target.UserID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&target).Update("role", req.Role).Error
+	})
+	if errors.Is(err, errLastOrgOwner) {
+		apierror.Abort(c, apierror.OrgLastOwner.New())
+		return
+	}
+	if err != nil {
+		log.Println("❌ Failed to update member role:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to update member role"))
+		return
+	}
+
+	log.Println("✅ Member", targetID, "role changed to", req.Role, "in organization:", caller.OrganizationID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Member role updated"})
+}
+
+// ✅ Remove a Member (org admins; only owners remove owners)
+func RemoveOrgMember(c *gin.Context) {
+	caller := c.MustGet("org_membership").(models.Membership)
+
+	targetID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidParameter.WithDetail("Invalid user ID"))
+		return
+	}
+
+	var target models.Membership
+	if err := database.DB.Where("organization_id = ? AND user_id = ?", caller.OrganizationID, targetID).First(&target).Error; err != nil {
+		apierror.Abort(c, apierror.OrgMemberNotFound.New())
+		return
+	}
+
+	if !canAssignOrgRole(caller.Role, target.Role) {
+		apierror.Abort(c, apierror.OrgMemberNotRemovable.New())
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if target.Role == models.OrgRoleOwner {
+			if err := lockOtherOrgOwner(tx, caller.OrganizationID, target.UserID); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&target).Error
+	})
+	if errors.Is(err, errLastOrgOwner) {
+		apierror.Abort(c, apierror.OrgLastOwner.New())
+		return
+	}
+	if err != nil {
+		log.Println("❌ Failed to remove member:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to remove member"))
+		return
+	}
+
+	log.Println("✅ Member", targetID, "removed from organization:", caller.OrganizationID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Member removed"})
+}
+
+// ✅ Admins manage admins and members; only owners grant or revoke ownership
+func canAssignOrgRole(callerRole, role string) bool {
+	rank := models.OrgRoleRank(role)
+	if rank == 0 {
+		return false
+	}
+	if role == models.OrgRoleOwner {
+		return callerRole == models.OrgRoleOwner
+	}
+	return models.OrgRoleRank(callerRole) >= models.OrgRoleRank(models.OrgRoleAdmin)
+}
+
+var errLastOrgOwner = errors.New("organization must keep at least one owner")
+
+// ✅ Make sure an organization has an owner besides userID (errLastOrgOwner if not)
+//
+// The owner rows are locked (SELECT … FOR UPDATE) until tx ends, so two owners
+// demoting or removing each other at the same time cannot both succeed.
+func lockOtherOrgOwner(tx *gorm.DB, orgID, userID uuid.UUID) error {
+	var owners []uuid.UUID
+	err := tx.Model(&models.Membership{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND role = ?", orgID, models.OrgRoleOwner).Pluck("user_id", &owners).Error
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		if owner != userID {
+			return nil
+		}
+	}
+	return errLastOrgOwner
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/didip/tollbooth/v7"
+	"github.com/didip/tollbooth/v7/limiter"
+	"github.com/gin-gonic/gin"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+)
+
+// ✅ Purpose of password reset action tokens
+const passwordResetPurpose = "password_reset"
+
+// ✅ Password reset requests: 3 per email and per IP, then 1 every 5 minutes
+var passwordResetLimiter = tollbooth.NewLimiter(1.0/300, &limiter.ExpirableOptions{
+	DefaultExpirationTTL: time.Hour,
+}).SetBurst(3)
+
+// ✅ How long a password reset link stays valid (PASSWORD_RESET_TTL, default 1h)
+func passwordResetTTL() time.Duration {
+	return env.Duration("PASSWORD_RESET_TTL", time.Hour)
+}
+
+// ✅ Email a password reset link
+//
+// The token is bound to the current password hash, so it stops working as soon
+// as the password changes (including through a previous use of the same link).
+func sendPasswordResetEmail(user *models.User) error {
+	ttl := passwordResetTTL()
+	token, err := auth.GenerateActionToken(passwordResetPurpose, user.ID, auth.SecretFingerprint(user.Password), "", time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", frontendBaseURL(), url.QueryEscape(token))
+	go func(to string) {
+		if err := SendEmail(to, "Reset your password",
+			fmt.Sprintf("Click here to choose a new password: %s\n\nThis link expires in %d minutes.\nIf you didn't request it, you can ignore this email.", link, int(ttl.Minutes()))); err != nil {
+			log.Println("❌ Failed to send password reset email:", err)
+		}
+	}(user.Email)
+	return nil
+}
+
+// ✅ Request a Password Reset (same response whether or not the account exists)
+func RequestPasswordReset(c *gin.Context) {
+	var req PasswordResetRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	email := emailKey(req.Email)
+
+	// ✅ Rate limit per email and per IP
+	if tollbooth.LimitByKeys(passwordResetLimiter, []string{"email", email}) != nil ||
+		tollbooth.LimitByKeys(passwordResetLimiter, []string{"ip", c.ClientIP()}) != nil {
+		log.Println("⚠️ Password reset rate limit hit for IP:", c.ClientIP())
+		apierror.Abort(c, apierror.RateLimited.New())
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = ?", email).First(&user).Error; err == nil {
+		if err := sendPasswordResetEmail(&user); err != nil {
+			log.Println("❌ Failed to generate password reset token:", err)
+		} else {
+			recordAudit(c, user.ID, "auth.password_reset_requested", nil)
+		}
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "If an account exists for that email, a password reset link has been sent"})
+}
+
+// ✅ Complete a Password Reset: set the new password and sign out everywhere
+func ConfirmPasswordReset(c *gin.Context) {
+	var req PasswordResetConfirmRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := auth.ValidateActionToken(req.Token, passwordResetPurpose)
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidLink.New())
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", claims.UserID).Error; err != nil {
+		apierror.Abort(c, apierror.InvalidLink.New())
+		return
+	}
+
+	// ✅ The link is only valid for the password it was issued against
+	if claims.Ref != auth.SecretFingerprint(user.Password) {
+		apierror.Abort(c, apierror.InvalidLink.New())
+		return
+	}
+
+	if err := auth.ValidatePasswordForUser(req.NewPassword, user.Username, user.Email); err != nil {
+		respondPasswordPolicyError(c, "new_password", err)
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		log.Println("❌ Error hashing password:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	// ✅ The mailer subscriber tells the user their password was changed
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		return publish(c, tx, events.PasswordChanged{UserID: user.ID, Email: user.Email, Reset: true})
+	})
+	if err != nil {
+		log.Println("❌ Failed to update password:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to update password"))
+		return
+	}
+
+	if err := revokeAllSessions(user.ID); err != nil {
+		log.Println("⚠️ Failed to revoke sessions after password reset:", err)
+	}
+
+	log.Println("✅ Password reset for user:", user.ID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Password has been reset. Please sign in again."})
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/avatar"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"golang.org/x/text/language"
+)
+
+// ✅ Profile as returned by GET /user and PATCH /user
+type UserProfile struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	DisplayName   string    `json:"display_name"`
+	AvatarURL     string    `json:"avatar_url"`
+	Locale        string    `json:"locale"`
+	Timezone      string    `json:"timezone"`
+	Bio           string    `json:"bio"`
+	CreatedAt     time.Time `json:"joined"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ✅ Build the public profile of a user
+func newUserProfile(user *models.User) UserProfile {
+	return UserProfile{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		DisplayName:   user.DisplayName,
+		AvatarURL:     user.AvatarURL,
+		Locale:        user.Locale,
+		Timezone:      user.Timezone,
+		Bio:           user.Bio,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// ✅ Editable profile fields: JSON name -> column and validator
+//
+// Email, username and password are not editable here because they have their
+// own flows (verification, uniqueness rules, re-authentication).
+var profileFields = map[string]struct {
+	column   string
+	validate func(string) (string, error)
+}{
+	"display_name": {"display_name", validateDisplayName},
+	"avatar_url":   {"avatar_url", validateAvatarURL},
+	"locale":       {"locale", validateLocale},
+	"timezone":     {"timezone", validateTimezone},
+	"bio":          {"bio", validateBio},
+}
+
+// ✅ Update Profile (PATCH /user, JSON merge patch semantics)
+//
+// Absent fields are left unchanged, null clears a field and a string sets it.
+// Every field is validated before anything is written, and all errors are
+// returned together keyed by field name.
+func UpdateUserProfile(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		log.Println("❌ user_id not found in context")
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		log.Println("❌ Invalid user ID:", err)
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		apierror.Abort(c, apierror.InvalidBody.WithDetail("Request body must be a JSON object"))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	var fieldErrors []apierror.FieldError
+	for name, raw := range patch {
+		field, ok := profileFields[name]
+		if !ok {
+			fieldErrors = append(fieldErrors, apierror.FieldError{Field: name, Code: "unknown", Message: "unknown or read-only field"})
+			continue
+		}
+
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			updates[field.column] = ""
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			fieldErrors = append(fieldErrors, apierror.FieldError{Field: name, Code: "type", Message: "must be a string or null"})
+			continue
+		}
+
+		normalized, err := field.validate(value)
+		if err != nil {
+			fieldErrors = append(fieldErrors, apierror.FieldError{Field: name, Code: "invalid", Message: err.Error()})
+			continue
+		}
+		updates[field.column] = normalized
+	}
+
+	if len(fieldErrors) > 0 {
+		apierror.Abort(c, apierror.ValidationFailed.WithDetail("Invalid profile update").WithFields(fieldErrors...))
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound.New())
+		return
+	}
+
+	if len(updates) > 0 {
+		// ✅ Setting or clearing the URL replaces any uploaded avatar
+		_, replacesAvatar := updates["avatar_url"]
+		if replacesAvatar {
+			updates["avatar_key"] = ""
+		}
+
+		if err := database.DB.Model(&user).Updates(updates).Error; err != nil {
+			log.Println("❌ Failed to update profile:", err)
+			apierror.Abort(c, apierror.Internal.WithDetail("Failed to update profile"))
+			return
+		}
+
+		if replacesAvatar {
+			avatar.Delete(c.Request.Context(), user.AvatarKey)
+		}
+
+		changed := make([]string, 0, len(updates))
+		for name := range patch {
+			changed = append(changed, name)
+		}
+		recordAudit(c, userID, "user.profile_updated", gin.H{"fields": changed})
+
+		if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+			apierror.Abort(c, apierror.UserNotFound.New())
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, newUserProfile(&user))
+}
+
+// ✅ Display name: up to 64 printable characters
+func validateDisplayName(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > 64 {
+		return "", fmt.Errorf("must be at most 64 characters")
+	}
+	if strings.IndexFunc(value, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+		return "", fmt.Errorf("must not contain control characters")
+	}
+	return value, nil
+}
+
+// ✅ Avatar URL: empty, or an absolute http(s) URL
+func validateAvatarURL(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if len(value) > 2048 {
+		return "", fmt.Errorf("must be at most 2048 characters")
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return "", fmt.Errorf("must be an absolute http or https URL")
+	}
+	return parsed.String(), nil
+}
+
+// ✅ Locale: empty, or a well-formed BCP 47 tag (stored in canonical form)
+func validateLocale(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	tag, err := language.Parse(value)
+	if err != nil || len(value) > 35 {
+		return "", fmt.Errorf("must be a BCP 47 language tag such as \"en-GB\"")
+	}
+	return tag.String(), nil
+}
+
+// ✅ Timezone: empty, or an IANA time zone name
+func validateTimezone(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if value == "Local" || len(value) > 64 {
+		return "", fmt.Errorf("must be an IANA time zone such as \"Europe/London\"")
+	}
+	if _, err := time.LoadLocation(value); err != nil {
+		return "", fmt.Errorf("must be an IANA time zone such as \"Europe/London\"")
+	}
+	return value, nil
+}
+
+// ✅ Bio: up to 500 characters (newlines allowed)
+func validateBio(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > 500 {
+		return "", fmt.Errorf("must be at most 500 characters")
+	}
+	if strings.IndexFunc(value, func(r rune) bool { return !unicode.IsPrint(r) && r != '\n' }) >= 0 {
+		return "", fmt.Errorf("must not contain control characters")
+	}
+	return value, nil
+}
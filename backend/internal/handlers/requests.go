@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/validation"
+)
+
+// ✅ Request DTOs
+//
+// Every JSON body is bound into one of these (never into a model), unknown
+// fields are rejected and the `binding` tags are checked by the validator,
+// including the custom email_addr / username / password / org_role / org_slug
+// rules from the validation package.
+
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email_addr,max=254"`
+	Username string `json:"username" binding:"required,username"`
+	Password string `json:"password" binding:"required,password"`
+}
+
+type LoginRequest struct {
+	Email    string    `json:"email" binding:"required"`
+	Password string    `json:"password" binding:"required"`
+	OrgID    uuid.UUID `json:"org_id"` // Optional organization to sign into
+}
+
+type ReauthenticateRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email_addr"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email_addr"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,password"`
+}
+
+type RestoreAccountRequest struct {
+	Token string `json:"token" binding:"required"` // From the emailed restore link
+}
+
+type ReportNotMeRequest struct {
+	Token string `json:"token" binding:"required"` // From the "this wasn't me" link (works once)
+}
+
+type UpdatePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,password"`
+}
+
+type UpdateUsernameRequest struct {
+	NewUsername string `json:"new_username" binding:"required,username"`
+}
+
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email_addr,max=254"`
+}
+
+type LogoutSessionRequest struct {
+	SessionID uuid.UUID `json:"session_id" binding:"required"`
+}
+
+type DeviceApprovalRequest struct {
+	UserCode string `json:"user_code" binding:"required"`
+	Approve  bool   `json:"approve"` // false declines the sign-in
+}
+
+type RenameDeviceRequest struct {
+	Name string `json:"name" binding:"required,max=64,single_line"`
+}
+
+type CreateOrganizationRequest struct {
+	Name            string `json:"name" binding:"required,max=100,single_line"`
+	Slug            string `json:"slug" binding:"required,org_slug"`
+	ScopedUsernames bool   `json:"scoped_usernames"`
+}
+
+type SwitchOrganizationRequest struct {
+	OrgID uuid.UUID `json:"org_id" binding:"required"`
+}
+
+type AddOrgMemberRequest struct {
+	Email string `json:"email" binding:"required,email_addr"`
+	Role  string `json:"role" binding:"omitempty,org_role"` // Defaults to member
+}
+
+type UpdateOrgMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,org_role"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email_addr,max=254"`
+	Role  string `json:"role" binding:"omitempty,org_role"` // Defaults to member
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Username string `json:"username"` // Only for new accounts (validated then)
+	Password string `json:"password" binding:"required"`
+}
+
+type StartImpersonationRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+	Reason string    `json:"reason" binding:"required,max=500"` // Ticket or explanation; emailed to the user
+}
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=2000"` // https, or http to localhost
+	Description string   `json:"description" binding:"max=200"`
+	Events      []string `json:"events"` // Event types to receive; empty = all
+}
+
+// ✅ Body of PATCH /admin/webhooks/:webhook_id (absent fields are unchanged)
+type UpdateWebhookRequest struct {
+	URL         *string   `json:"url,omitempty" binding:"omitempty,url,max=2000"`
+	Description *string   `json:"description,omitempty" binding:"omitempty,max=200"`
+	Events      *[]string `json:"events,omitempty"` // Replaces the filter; empty = all
+	Enabled     *bool     `json:"enabled,omitempty"`
+}
+
+// ✅ Query of GET /admin/webhooks/:webhook_id/deliveries (newest first)
+type WebhookDeliveryQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending delivered dead"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"` // Default 50
+}
+
+// ✅ Query of GET /admin/jobs/:job/runs (newest first)
+type JobRunQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=running succeeded failed"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"` // Default 50
+}
+
+// ✅ Body of PATCH /user (JSON merge patch: absent = unchanged, null = cleared)
+//
+// Documentation only: UpdateUserProfile reads the raw object so it can tell
+// null from absent and report unknown fields together with invalid ones.
+type ProfilePatchRequest struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	AvatarURL   *string `json:"avatar_url,omitempty"`
+	Locale      *string `json:"locale,omitempty"`   // BCP 47 language tag
+	Timezone    *string `json:"timezone,omitempty"` // IANA zone name
+	Bio         *string `json:"bio,omitempty"`
+}
+
+// ✅ Form body of POST /introspect (RFC 7662) and POST /revoke (RFC 7009)
+type TokenRequest struct {
+	Token         string `json:"token" form:"token" binding:"required"`
+	TokenTypeHint string `json:"token_type_hint,omitempty" form:"token_type_hint"` // "access_token" or "refresh_token"; unknown hints are ignored
+}
+
+// ✅ Form body of POST /token (client credentials go in the Authorization header or as extra fields)
+type TokenGrantRequest struct {
+	GrantType    string `json:"grant_type" form:"grant_type" binding:"required"` // "client_credentials", "urn:ietf:params:oauth:grant-type:device_code" or "refresh_token"
+	ClientID     string `json:"client_id,omitempty" form:"client_id"`            // How public clients identify themselves
+	Scope        string `json:"scope,omitempty" form:"scope"`                    // client_credentials: space-separated, defaults to every scope the client is allowed
+	DeviceCode   string `json:"device_code,omitempty" form:"device_code"`        // device_code grant
+	RefreshToken string `json:"refresh_token,omitempty" form:"refresh_token"`    // refresh_token grant
+}
+
+// ✅ Form body of POST /device/code (RFC 8628 §3.1)
+type DeviceCodeRequest struct {
+	ClientID   string `json:"client_id,omitempty" form:"client_id"`                                             // How public clients identify themselves
+	DeviceName string `json:"device_name,omitempty" form:"device_name" binding:"omitempty,max=100,single_line"` // Labels the session; defaults to the client's name
+}
+
+// ✅ Query string of GET /device
+type UserCodeQuery struct {
+	UserCode string `form:"user_code" binding:"required"` // As displayed by the device; case and dashes are ignored
+}
+
+// ✅ Query string of the emailed-link endpoints
+type TokenQuery struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// ✅ Bind and validate a JSON body (unknown fields are rejected), aborting with a problem on failure
+func bindJSON(c *gin.Context, req interface{}) bool {
+	if err := validation.DecodeJSON(c.Request.Body, req); err != nil {
+		apierror.Abort(c, validation.Problem(err))
+		return false
+	}
+	return true
+}
+
+// ✅ Bind and validate a form body (OAuth endpoints), aborting with invalid_request on failure
+func bindForm(c *gin.Context, req interface{}) bool {
+	err := c.Request.ParseForm()
+	if err == nil {
+		err = validation.DecodeForm(c.Request.PostForm, req)
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidOAuthRequest.New().WithFields(validation.Problem(err).Errors...))
+		return false
+	}
+	return true
+}
+
+// ✅ Bind and validate the query string, aborting with a problem on failure
+func bindQuery(c *gin.Context, req interface{}) bool {
+	if err := validation.DecodeForm(c.Request.URL.Query(), req); err != nil {
+		apierror.Abort(c, validation.Problem(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ Response DTOs
+//
+// Every JSON success response is one of these (or UserProfile / PublicProfile),
+// never an ad-hoc gin.H, so the OpenAPI document can describe it and the
+// contract test can check that handlers and spec agree.
+
+// ✅ Plain acknowledgement
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+type ReauthenticateResponse struct {
+	Message       string    `json:"message"`
+	ElevatedUntil time.Time `json:"elevated_until"` // End of sudo mode
+}
+
+type AvatarResponse struct {
+	AvatarURL string            `json:"avatar_url"`
+	Sizes     map[string]string `json:"sizes"` // Pixel size -> URL
+}
+
+type DeviceListResponse struct {
+	Devices []models.TrustedDevice `json:"devices"`
+}
+
+// ✅ Active session as listed to its owner
+type SessionInfo struct {
+	ID         uuid.UUID `json:"id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`               // The session making the request
+	DeviceName string    `json:"device_name,omitempty"` // Set for device-flow sign-ins (CLI, TV)
+}
+
+type SessionListResponse struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+type AccountDeletedResponse struct {
+	Message       string    `json:"message"`
+	RestoreBefore time.Time `json:"restore_before"`
+}
+
+// ✅ Deleted account a restore link is for, shown before it is restored
+type RestoreAccountInfo struct {
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	DeletedAt     time.Time `json:"deleted_at"`
+	RestoreBefore time.Time `json:"restore_before"`
+}
+
+type UsernameRedirectResponse struct {
+	Username string `json:"username"` // Current username
+	Location string `json:"location"`
+}
+
+type OrganizationResponse struct {
+	Organization models.Organization `json:"organization"`
+}
+
+// ✅ Organization as listed to one of its members
+type OrgSummary struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Slug   string    `json:"slug"`
+	Role   string    `json:"role"`
+	Active bool      `json:"active"` // Organization of the current token
+}
+
+type OrganizationListResponse struct {
+	Organizations []OrgSummary `json:"organizations"`
+}
+
+type OrgSwitchedResponse struct {
+	Message string    `json:"message"`
+	OrgID   uuid.UUID `json:"org_id"`
+}
+
+type OrgMember struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	Joined   time.Time `json:"joined"`
+}
+
+type MemberListResponse struct {
+	Members []OrgMember `json:"members"`
+}
+
+type MemberAddedResponse struct {
+	Message    string            `json:"message"`
+	Membership models.Membership `json:"membership"`
+}
+
+type InvitationResponse struct {
+	Message    string               `json:"message,omitempty"`
+	Invitation models.OrgInvitation `json:"invitation"`
+}
+
+type InvitationListResponse struct {
+	Invitations []models.OrgInvitation `json:"invitations"`
+}
+
+// ✅ What the invitee sees before accepting
+type InvitationPreview struct {
+	Organization  string    `json:"organization"` // Organization name
+	Email         string    `json:"email"`
+	Role          string    `json:"role"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	AccountExists bool      `json:"account_exists"` // Accept with a password instead of registering
+}
+
+type InvitationAcceptedResponse struct {
+	Message string    `json:"message"`
+	OrgID   uuid.UUID `json:"org_id"`
+}
+
+// ✅ Token introspection result (RFC 7662); inactive tokens only carry "active"
+type IntrospectionResponse struct {
+	Active    bool               `json:"active"`
+	TokenType string             `json:"token_type,omitempty"` // "access_token" or "refresh_token"
+	Scope     string             `json:"scope,omitempty"`
+	ClientID  string             `json:"client_id,omitempty"`
+	Subject   string             `json:"sub,omitempty"`
+	UserID    string             `json:"user_id,omitempty"`
+	OrgID     string             `json:"org_id,omitempty"`
+	SessionID string             `json:"sid,omitempty"`
+	ExpiresAt int64              `json:"exp,omitempty"`
+	IssuedAt  int64              `json:"iat,omitempty"`
+	AuthTime  int64              `json:"auth_time,omitempty"`
+	Cnf       *auth.Confirmation `json:"cnf,omitempty"` // Certificate a service token is bound to (RFC 8705)
+	Act       *auth.Actor        `json:"act,omitempty"` // Admin behind an impersonation token (RFC 8693)
+}
+
+// ✅ Token endpoint result (RFC 6749 §5.1)
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`              // Always "Bearer"
+	ExpiresIn    int64  `json:"expires_in"`              // Seconds
+	RefreshToken string `json:"refresh_token,omitempty"` // device_code grant; use it with the refresh_token grant
+	Scope        string `json:"scope,omitempty"`
+}
+
+// ✅ Device authorization result (RFC 8628 §3.2)
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"` // Secret: poll /token with it
+	UserCode                string `json:"user_code"`   // Shown to the user, e.g. "BDWP-HQTX"
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"` // With the user code filled in (for QR codes)
+	ExpiresIn               int64  `json:"expires_in"`                // Seconds
+	Interval                int64  `json:"interval"`                  // Seconds to wait between polls
+}
+
+// ✅ Pending device sign-in, shown to the user before they approve it
+type DeviceAuthorizationInfo struct {
+	UserCode   string    `json:"user_code"`
+	ClientName string    `json:"client_name"` // Registered name of the app asking
+	DeviceName string    `json:"device_name"`
+	IPAddress  string    `json:"ip_address"` // Where the device asked from
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// ✅ Impersonation token: send it as "Authorization: Bearer", it cannot be refreshed
+type ImpersonationResponse struct {
+	ImpersonationID uuid.UUID `json:"impersonation_id"`
+	UserID          uuid.UUID `json:"user_id"` // The impersonated user
+	AccessToken     string    `json:"access_token"`
+	TokenType       string    `json:"token_type"` // Always "Bearer"
+	ExpiresIn       int64     `json:"expires_in"` // Seconds
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// ✅ Registered webhook endpoint
+type WebhookInfo struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"` // Empty = all
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ✅ New webhook endpoint and its signing secret (shown only here)
+type WebhookCreatedResponse struct {
+	Webhook WebhookInfo `json:"webhook"`
+	Secret  string      `json:"secret"` // HMAC-SHA256 key for the Arcadia-Signature header
+}
+
+type WebhookListResponse struct {
+	Webhooks []WebhookInfo `json:"webhooks"`
+}
+
+// ✅ One event queued for an endpoint, with the outcome of its last attempt
+type WebhookDeliveryInfo struct {
+	ID             uuid.UUID  `json:"id"`
+	EventID        uuid.UUID  `json:"event_id"` // The Arcadia-Webhook-Id header
+	Event          string     `json:"event"`
+	Status         string     `json:"status"` // "pending", "delivered" or "dead"
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // Pending only
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"` // HTTP status of the last attempt
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Payload        string     `json:"payload"` // The JSON body, exactly as signed
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryInfo `json:"deliveries"`
+}
+
+// ✅ A scheduled maintenance job
+type JobInfo struct {
+	Name        string      `json:"name"`
+	Schedule    string      `json:"schedule"` // Cron expression, in UTC
+	NextRunAt   time.Time   `json:"next_run_at"`
+	RequestedAt *time.Time  `json:"requested_at,omitempty"` // A manual run is waiting for the next poll
+	LastRun     *JobRunInfo `json:"last_run,omitempty"`
+}
+
+type JobListResponse struct {
+	Jobs []JobInfo `json:"jobs"`
+}
+
+// ✅ One run of a job
+type JobRunInfo struct {
+	ID         uuid.UUID  `json:"id"`
+	Job        string     `json:"job"`
+	Status     string     `json:"status"`  // "running", "succeeded" or "failed"
+	Trigger    string     `json:"trigger"` // "schedule" or "manual"
+	Instance   string     `json:"instance"`
+	Affected   int        `json:"affected"` // Rows, accounts or messages it dealt with
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type JobRunListResponse struct {
+	Runs []JobRunInfo `json:"runs"`
+}
+
+// ✅ Domain event bus health: this instance's counters and the shared delivery queue
+type EventStatsResponse struct {
+	Handled  map[string]int64 `json:"handled"`  // Event name -> events seen by this instance since it started
+	Failures map[string]int64 `json:"failures"` // Subscriber -> failed attempts on this instance since it started
+	Pending  map[string]int64 `json:"pending"`  // Subscriber -> deliveries waiting
+	Dead     map[string]int64 `json:"dead"`     // Subscriber -> deliveries given up on (until pruned)
+}
+
+// ✅ Public keys that verify access tokens (RFC 7517 JWK Set)
+type JWKSResponse struct {
+	Keys []auth.JWK `json:"keys"`
+}
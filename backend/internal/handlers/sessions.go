@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ Generate Access & Refresh Tokens and store them in Secure HttpOnly Cookies
+//
+// A subject without a SessionID starts a new UserSession; otherwise the
+// existing session is re-bound to the newly issued refresh token. The session
+// ID is returned either way.
+func issueAuthCookies(c *gin.Context, subject auth.TokenSubject) (uuid.UUID, error) {
+	tokens, err := issueTokens(c, subject, sessionOrigin{})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	c.SetCookie("auth_token", tokens.AccessToken, int(auth.AccessTokenTTL.Seconds()), "/", "", true, true)
+	c.SetCookie("refresh_token", tokens.RefreshToken, int(auth.RefreshTokenTTL.Seconds()), "/", "", true, true)
+	return tokens.SessionID, nil
+}
+
+// ✅ Where a new session comes from when it is not a browser sign-in
+type sessionOrigin struct {
+	ClientID   string // Device-flow client
+	DeviceName string
+}
+
+// ✅ Tokens of a (new or re-bound) session
+type issuedTokens struct {
+	SessionID    uuid.UUID
+	AccessToken  string
+	RefreshToken string
+}
+
+// ✅ Generate Access & Refresh Tokens and record the session (see issueAuthCookies)
+func issueTokens(c *gin.Context, subject auth.TokenSubject, origin sessionOrigin) (issuedTokens, error) {
+	newSession := subject.SessionID == uuid.Nil
+	if newSession {
+		subject.SessionID = uuid.New()
+		if subject.AuthTime.IsZero() {
+			subject.AuthTime = time.Now() // A new session means the user just authenticated
+		}
+	} else if subject.AuthTime.IsZero() {
+		// Re-issued tokens keep the session's last authentication time
+		var session models.UserSession
+		if err := database.DB.Select("auth_time").First(&session, "id = ? AND user_id = ?", subject.SessionID, subject.UserID).Error; err != nil {
+			return issuedTokens{}, err
+		}
+		subject.AuthTime = session.AuthTime
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(subject)
+	if err != nil {
+		return issuedTokens{}, err
+	}
+
+	expiresAt := time.Now().Add(auth.RefreshTokenTTL)
+	if newSession {
+		err = database.DB.Create(&models.UserSession{
+			ID:         subject.SessionID,
+			UserID:     subject.UserID,
+			TokenHash:  auth.HashURLToken(refreshToken),
+			IPAddress:  c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			ClientID:   origin.ClientID,
+			DeviceName: origin.DeviceName,
+			ExpiresAt:  expiresAt,
+			AuthTime:   subject.AuthTime,
+		}).Error
+	} else {
+		err = database.DB.Model(&models.UserSession{}).
+			Where("id = ? AND user_id = ?", subject.SessionID, subject.UserID).
+			Updates(map[string]interface{}{"token_hash": auth.HashURLToken(refreshToken), "expires_at": expiresAt, "auth_time": subject.AuthTime}).Error
+	}
+	if err != nil {
+		return issuedTokens{}, err
+	}
+
+	accessToken, err := auth.GenerateAccessToken(subject)
+	if err != nil {
+		return issuedTokens{}, err
+	}
+
+	return issuedTokens{SessionID: subject.SessionID, AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// ✅ Load the live session a refresh token belongs to
+func activeSession(sessionID, userID uuid.UUID, refreshToken string) (*models.UserSession, bool) {
+	var session models.UserSession
+	err := database.DB.
+		Where("id = ? AND user_id = ? AND token_hash = ? AND expires_at > ?", sessionID, userID, auth.HashURLToken(refreshToken), time.Now()).
+		First(&session).Error
+	if err != nil {
+		return nil, false
+	}
+	return &session, true
+}
+
+// ✅ Revoke every session a user has (e.g. on account deletion)
+func revokeAllSessions(userID uuid.UUID) error {
+	return database.DB.Where("user_id = ?", userID).Delete(&models.UserSession{}).Error
+}
+
+// ✅ Clear the authentication cookies on this browser
+func clearAuthCookies(c *gin.Context) {
+	c.SetCookie("auth_token", "", -1, "/", "", true, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", true, true)
+}
@@ -1,15 +1,20 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
 	"github.com/thejpness/ArcadiaGo/internal/auth"
 	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/lifecycle"
 	"github.com/thejpness/ArcadiaGo/internal/models"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // ✅ Validate and Update Password
@@ -17,39 +22,40 @@ func UpdatePassword(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		log.Println("❌ user_id not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr.(string))
 	if err != nil {
 		log.Println("❌ Invalid user ID:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
-	var req struct {
-		OldPassword string `json:"old_password"`
-		NewPassword string `json:"new_password"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("❌ Invalid request format:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	var req UpdatePasswordRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
 		log.Println("❌ User not found:", userID)
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		apierror.Abort(c, apierror.UserNotFound.New())
 		return
 	}
 
 	// Verify Old Password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)); err != nil {
+	if !auth.CheckPassword(user.Password, req.OldPassword) {
 		log.Println("❌ Incorrect old password for user:", userID)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect old password"})
+		apierror.Abort(c, apierror.IncorrectPassword.WithDetail("Incorrect old password"))
+		return
+	}
+
+	// ✅ Validate new password against the policy
+	if err := auth.ValidatePasswordForUser(req.NewPassword, user.Username, user.Email); err != nil {
+		log.Println("❌ Password validation failed for user:", userID)
+		respondPasswordPolicyError(c, "new_password", err)
 		return
 	}
 
@@ -57,15 +63,25 @@ func UpdatePassword(c *gin.Context) {
 	hashedPassword, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
 		log.Println("❌ Error hashing password:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Internal.New())
 		return
 	}
 
 	// Update password
-	database.DB.Model(&user).Update("password", hashedPassword)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		return publish(c, tx, events.PasswordChanged{UserID: userID, Email: user.Email})
+	})
+	if err != nil {
+		log.Println("❌ Failed to update password:", err)
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to update password"))
+		return
+	}
 
 	log.Println("✅ Password updated successfully for user:", userID)
-	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Password updated successfully"})
 }
 
 // ✅ Update Username
@@ -73,47 +89,90 @@ func UpdateUsername(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		log.Println("❌ user_id not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr.(string))
 	if err != nil {
 		log.Println("❌ Invalid user ID:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
-	var req struct {
-		NewUsername string `json:"new_username"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("❌ Invalid request format:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	var req UpdateUsernameRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	log.Println("🔍 Requested new username:", req.NewUsername)
 
-	// ✅ Ensure Username is Unique
-	var existingUser models.User
-	if err := database.DB.Where("username = ?", req.NewUsername).First(&existingUser).Error; err == nil {
-		log.Println("❌ Username already exists:", req.NewUsername)
-		c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		log.Println("❌ User not found:", userID)
+		apierror.Abort(c, apierror.UserNotFound.New())
+		return
+	}
+
+	if req.NewUsername == user.Username {
+		c.JSON(http.StatusOK, MessageResponse{Message: "Username updated successfully"})
 		return
 	}
 
-	// ✅ Update username
-	err = database.DB.Model(&models.User{}).Where("id = ?", userID).Update("username", req.NewUsername).Error
+	// ✅ Changing only the letter case keeps the same name: no cooldown, no history
+	caseOnly := auth.FoldUsername(req.NewUsername) == user.UsernameKey
+
+	// ✅ Enforce the cooldown between real changes
+	now := time.Now()
+	if !caseOnly {
+		var last models.UsernameHistory
+		if err := database.DB.Where("user_id = ?", userID).Order("changed_at DESC").First(&last).Error; err == nil {
+			if next := last.ChangedAt.Add(usernameChangeCooldown()); now.Before(next) {
+				apierror.Abort(c, apierror.UsernameCooldown.New().With("retry_after", next))
+				return
+			}
+		}
+	}
+
+	// ✅ Ensure Username is Unique (case-insensitively, within the user's username scope) and record the old one
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkUsernameAvailable(tx, req.NewUsername, user.UsernameScope, userID); err != nil {
+			return err
+		}
+
+		if !caseOnly {
+			history := models.UsernameHistory{
+				ID:            uuid.New(),
+				UserID:        userID,
+				Username:      user.Username,
+				UsernameKey:   user.UsernameKey,
+				UsernameScope: user.UsernameScope,
+				ChangedAt:     now,
+				HeldUntil:     now.Add(usernameHoldPeriod()),
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"username": req.NewUsername, "username_key": auth.FoldUsername(req.NewUsername)}).Error
+	})
+	if errors.Is(err, errUsernameTaken) || errors.Is(err, errUsernameHeld) {
+		log.Println("❌ Username unavailable:", req.NewUsername)
+		respondUsernameUnavailable(c, err)
+		return
+	}
 	if err != nil {
 		log.Println("❌ Failed to update username:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update username"})
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to update username"))
 		return
 	}
 
+	recordAudit(c, userID, "user.username_changed", gin.H{"old_username": user.Username, "new_username": req.NewUsername})
+
 	log.Println("✅ Username updated successfully to:", req.NewUsername)
-	c.JSON(http.StatusOK, gin.H{"message": "Username updated successfully"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Username updated successfully"})
 }
 
 // ✅ Request Email Change
@@ -126,56 +185,138 @@ func ConfirmEmailChange(c *gin.Context) {
 	ConfirmEmailVerification(c) // ✅ Uses function from `email_verification.go`
 }
 
-// ✅ Soft Delete Account
+// ✅ Soft Delete Account (behind sudo mode; revokes all sessions and emails a restore link)
 func SoftDeleteUser(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		log.Println("❌ user_id not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr.(string))
 	if err != nil {
 		log.Println("❌ Invalid user ID:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
-	if err := database.DB.Delete(&models.User{}, userID).Error; err != nil {
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		log.Println("❌ User not found:", userID)
+		apierror.Abort(c, apierror.UserNotFound.New())
+		return
+	}
+
+	// ✅ Soft delete and revoke every session atomically; the mailer subscriber sends the restore link
+	deletedAt := time.Now().Truncate(time.Microsecond) // Match Postgres timestamp precision
+	deadline := lifecycle.PurgeDeadline(deletedAt)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserSession{}).Error; err != nil {
+			return err
+		}
+		return publish(c, tx, events.UserDeleted{UserID: userID, Email: user.Email, Username: user.Username, DeletedAt: deletedAt, PurgeAfter: deadline})
+	})
+	if err != nil {
 		log.Println("❌ Failed to delete account:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to delete account"))
 		return
 	}
+	clearAuthCookies(c)
 
 	log.Println("✅ Account soft deleted for user:", userID)
-	c.JSON(http.StatusOK, gin.H{"message": "Account deleted (soft delete)"})
+	c.JSON(http.StatusOK, AccountDeletedResponse{Message: "Account deleted (soft delete)", RestoreBefore: deadline})
 }
 
-// ✅ Restore Account
+// ✅ Purpose of signed account-restore links
+const restoreAccountPurpose = "restore_account"
+
+// ✅ Check a restore link and show what restoring would do (no login required)
+//
+// The emailed link opens a confirmation page; following it changes nothing,
+// so link scanners cannot restore an account.
+func GetRestoreAccount(c *gin.Context) {
+	var query TokenQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	user, ok := restorableUser(c, query.Token)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, RestoreAccountInfo{
+		Username:      user.Username,
+		Email:         user.Email,
+		DeletedAt:     user.DeletedAt.Time,
+		RestoreBefore: lifecycle.PurgeDeadline(user.DeletedAt.Time),
+	})
+}
+
+// ✅ Restore Account with the token from the emailed link (no login required)
 func RestoreUser(c *gin.Context) {
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
-		log.Println("❌ user_id not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	var req RestoreAccountRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
-		log.Println("❌ Invalid user ID:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+	user, ok := restorableUser(c, req.Token)
+	if !ok {
 		return
 	}
 
-	if err := database.DB.Unscoped().Model(&models.User{}).Where("id = ?", userID).Update("deleted_at", nil).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Only restore the deletion the link was issued for, even if two requests race
+		result := tx.Unscoped().Model(&models.User{}).Where("id = ? AND deleted_at = ?", user.ID, user.DeletedAt.Time).
+			Updates(map[string]interface{}{"deleted_at": nil, "purge_notice_sent_at": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidRestoreLink
+		}
+		return publish(c, tx, events.UserRestored{UserID: user.ID, Email: user.Email, Username: user.Username})
+	})
+	if errors.Is(err, errInvalidRestoreLink) {
+		apierror.Abort(c, apierror.InvalidLink.WithDetail("Invalid or expired restore link"))
+		return
+	}
+	if err != nil {
 		log.Println("❌ Failed to restore account:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore account"})
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to restore account"))
 		return
 	}
 
-	log.Println("✅ Account restored successfully for user:", userID)
-	c.JSON(http.StatusOK, gin.H{"message": "Account restored successfully"})
+	log.Println("✅ Account restored successfully for user:", user.ID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Account restored successfully, you can now log in"})
+}
+
+var errInvalidRestoreLink = errors.New("invalid or expired restore link")
+
+// ✅ Deleted user a restore token is for, aborting unless it can still be restored
+func restorableUser(c *gin.Context, token string) (*models.User, bool) {
+	claims, err := auth.ValidateActionToken(token, restoreAccountPurpose)
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidLink.WithDetail("Invalid or expired restore link"))
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.DB.Unscoped().First(&user, "id = ?", claims.UserID).Error; err != nil {
+		apierror.Abort(c, apierror.InvalidLink.WithDetail("Invalid or expired restore link"))
+		return nil, false
+	}
+
+	// ✅ Only the link from the most recent deletion works, and only before the purge
+	if !user.DeletedAt.Valid || user.DeletedAt.Time.UnixMicro() != claims.Ref || time.Now().After(lifecycle.PurgeDeadline(user.DeletedAt.Time)) {
+		apierror.Abort(c, apierror.InvalidLink.WithDetail("Invalid or expired restore link"))
+		return nil, false
+	}
+	return &user, true
 }
 
 // ✅ Get Active Sessions
@@ -183,26 +324,40 @@ func GetActiveSessions(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		log.Println("❌ user_id not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr.(string))
 	if err != nil {
 		log.Println("❌ Invalid user ID:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
 	var sessions []models.UserSession
 	if err := database.DB.Where("user_id = ?", userID).Find(&sessions).Error; err != nil {
 		log.Println("❌ Failed to retrieve sessions:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to retrieve sessions"))
 		return
 	}
 
+	current := c.GetString("session_id")
+	list := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, SessionInfo{
+			ID:         s.ID,
+			IPAddress:  s.IPAddress,
+			UserAgent:  s.UserAgent,
+			CreatedAt:  s.CreatedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID.String() == current,
+			DeviceName: s.DeviceName,
+		})
+	}
+
 	log.Println("✅ Retrieved active sessions for user:", userID)
-	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+	c.JSON(http.StatusOK, SessionListResponse{Sessions: list})
 }
 
 // ✅ Logout from a Specific Session
@@ -210,33 +365,35 @@ func LogoutSession(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		log.Println("❌ user_id not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr.(string))
 	if err != nil {
 		log.Println("❌ Invalid user ID:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		apierror.Abort(c, apierror.Unauthenticated.New())
 		return
 	}
 
-	var req struct {
-		SessionID uuid.UUID `json:"session_id"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("❌ Invalid request format:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	var req LogoutSessionRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := database.DB.Where("user_id = ? AND id = ?", userID, req.SessionID).Delete(&models.UserSession{}).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND id = ?", userID, req.SessionID).Delete(&models.UserSession{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return publish(c, tx, events.SessionRevoked{UserID: userID, SessionID: req.SessionID, Reason: "signed_out"})
+	})
+	if err != nil {
 		log.Println("❌ Failed to log out session:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out session"})
+		apierror.Abort(c, apierror.Internal.WithDetail("Failed to log out session"))
 		return
 	}
 
 	log.Println("✅ Session logged out successfully for user:", userID)
-	c.JSON(http.StatusOK, gin.H{"message": "Session logged out successfully"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Session logged out successfully"})
 }
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	errUsernameTaken = errors.New("username already taken")
+	errUsernameHeld  = errors.New("username was recently used by another account")
+)
+
+// ✅ Validation problem for a username rejected by auth.ValidateUsername
+func usernameProblem(field string, err error) *apierror.Problem {
+	code := "format"
+	if errors.Is(err, auth.ErrUsernameReserved) {
+		code = "reserved"
+	}
+	return apierror.InvalidField(field, code, err.Error())
+}
+
+// ✅ Respond to a failed availability check
+func respondUsernameUnavailable(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errUsernameTaken):
+		apierror.Abort(c, apierror.UsernameTaken.New())
+	case errors.Is(err, errUsernameHeld):
+		apierror.Abort(c, apierror.UsernameHeld.New())
+	default:
+		log.Println("❌ Failed to check username availability:", err)
+		apierror.Abort(c, apierror.Internal.New())
+	}
+}
+
+// ✅ How long a released username stays reserved for its previous owner (USERNAME_HOLD_PERIOD, default 90 days)
+func usernameHoldPeriod() time.Duration {
+	return env.Duration("USERNAME_HOLD_PERIOD", 90*24*time.Hour)
+}
+
+// ✅ Minimum time between username changes (USERNAME_CHANGE_COOLDOWN, default 30 days)
+func usernameChangeCooldown() time.Duration {
+	return env.Duration("USERNAME_CHANGE_COOLDOWN", 30*24*time.Hour)
+}
+
+// ✅ Check a username is free in a scope (case-insensitive, including held names)
+//
+// userID is the account that wants the name (uuid.Nil for new accounts); it may
+// reclaim its own previous names while they are held.
+func checkUsernameAvailable(tx *gorm.DB, username, scope string, userID uuid.UUID) error {
+	key := auth.FoldUsername(username)
+
+	var taken int64
+	if err := tx.Unscoped().Model(&models.User{}).
+		Where("username_key = ? AND username_scope = ? AND id <> ?", key, scope, userID).
+		Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return errUsernameTaken
+	}
+
+	var held int64
+	if err := tx.Model(&models.UsernameHistory{}).
+		Where("username_key = ? AND username_scope = ? AND user_id <> ? AND held_until > ?", key, scope, userID, time.Now()).
+		Count(&held).Error; err != nil {
+		return err
+	}
+	if held > 0 {
+		return errUsernameHeld
+	}
+	return nil
+}
+
+// ✅ Public profile returned by username lookups
+type PublicProfile struct {
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url"`
+	Bio         string    `json:"bio"`
+	CreatedAt   time.Time `json:"joined"`
+}
+
+// ✅ Look up a profile by (global) username, redirecting from recently changed names
+func GetProfileByUsername(c *gin.Context) {
+	key := auth.FoldUsername(c.Param("username"))
+
+	var user models.User
+	err := database.DB.Where("username_key = ? AND username_scope = ''", key).First(&user).Error
+	if err == nil {
+		c.JSON(http.StatusOK, PublicProfile{
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			AvatarURL:   user.AvatarURL,
+			Bio:         user.Bio,
+			CreatedAt:   user.CreatedAt,
+		})
+		return
+	}
+
+	// ✅ Old name still on hold: point to the current one (302, as the name is released once the hold ends)
+	var history models.UsernameHistory
+	if err := database.DB.Where("username_key = ? AND username_scope = '' AND held_until > ?", key, time.Now()).
+		Order("changed_at DESC").First(&history).Error; err == nil {
+		if err := database.DB.First(&user, "id = ?", history.UserID).Error; err == nil && user.UsernameScope == "" {
+			location := basePath + "/users/" + url.PathEscape(user.Username)
+			c.Header("Location", location)
+			c.JSON(http.StatusFound, UsernameRedirectResponse{Username: user.Username, Location: location})
+			return
+		}
+	}
+
+	apierror.Abort(c, apierror.UserNotFound.New())
+}
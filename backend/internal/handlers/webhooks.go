@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"github.com/thejpness/ArcadiaGo/internal/webhooks"
+	"gorm.io/gorm"
+)
+
+// ✅ Register a Webhook Endpoint (support staff; the secret is shown once)
+func CreateWebhook(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+
+	var req CreateWebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if problem := checkWebhook(&req.URL, &req.Events); problem != nil {
+		apierror.Abort(c, problem)
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		log.Println("❌ Failed to generate webhook secret:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+	endpoint := models.WebhookEndpoint{
+		ID:          uuid.New(),
+		URL:         req.URL,
+		Description: strings.TrimSpace(req.Description),
+		Events:      strings.Join(req.Events, " "),
+		Secret:      secret,
+	}
+	if err := database.DB.Create(&endpoint).Error; err != nil {
+		log.Println("❌ Failed to create webhook:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	recordAudit(c, adminID, "admin.webhook_created", gin.H{"webhook_id": endpoint.ID, "url": endpoint.URL})
+
+	log.Println("✅ Webhook registered:", endpoint.ID, endpoint.URL)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, WebhookCreatedResponse{Webhook: webhookInfo(&endpoint), Secret: secret})
+}
+
+// ✅ List Webhook Endpoints
+func ListWebhooks(c *gin.Context) {
+	var endpoints []models.WebhookEndpoint
+	if err := database.DB.Order("created_at").Find(&endpoints).Error; err != nil {
+		log.Println("❌ Failed to list webhooks:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	list := make([]WebhookInfo, 0, len(endpoints))
+	for i := range endpoints {
+		list = append(list, webhookInfo(&endpoints[i]))
+	}
+	c.JSON(http.StatusOK, WebhookListResponse{Webhooks: list})
+}
+
+// ✅ Update a Webhook Endpoint (URL, description, event filter, enabled)
+//
+// Deliveries of a disabled endpoint stay queued and go out once it is
+// enabled again.
+func UpdateWebhook(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+	endpoint, ok := webhookFromPath(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if problem := checkWebhook(req.URL, req.Events); problem != nil {
+		apierror.Abort(c, problem)
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.URL != nil {
+		updates["url"] = *req.URL
+	}
+	if req.Description != nil {
+		updates["description"] = strings.TrimSpace(*req.Description)
+	}
+	if req.Events != nil {
+		updates["events"] = strings.Join(*req.Events, " ")
+	}
+	if req.Enabled != nil && *req.Enabled != (endpoint.DisabledAt == nil) {
+		if *req.Enabled {
+			updates["disabled_at"] = nil
+		} else {
+			updates["disabled_at"] = time.Now()
+		}
+	}
+	if len(updates) > 0 {
+		if err := database.DB.Model(endpoint).Updates(updates).Error; err != nil {
+			log.Println("❌ Failed to update webhook:", err)
+			apierror.Abort(c, apierror.Internal.New())
+			return
+		}
+		if err := database.DB.First(endpoint, "id = ?", endpoint.ID).Error; err != nil {
+			apierror.Abort(c, apierror.Internal.New())
+			return
+		}
+		recordAudit(c, adminID, "admin.webhook_updated", gin.H{"webhook_id": endpoint.ID, "fields": slices.Sorted(maps.Keys(updates))})
+		if endpoint.DisabledAt == nil {
+			webhooks.Wake() // Re-enabled endpoints may have deliveries waiting
+		}
+	}
+
+	c.JSON(http.StatusOK, webhookInfo(endpoint))
+}
+
+// ✅ Delete a Webhook Endpoint and its delivery log
+func DeleteWebhook(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+	endpoint, ok := webhookFromPath(c)
+	if !ok {
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("endpoint_id = ?", endpoint.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(endpoint).Error
+	})
+	if err != nil {
+		log.Println("❌ Failed to delete webhook:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	recordAudit(c, adminID, "admin.webhook_deleted", gin.H{"webhook_id": endpoint.ID, "url": endpoint.URL})
+
+	log.Println("✅ Webhook deleted:", endpoint.ID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Webhook deleted"})
+}
+
+// ✅ List an Endpoint's Deliveries (the delivery log, newest first)
+func ListWebhookDeliveries(c *gin.Context) {
+	endpoint, ok := webhookFromPath(c)
+	if !ok {
+		return
+	}
+
+	var query WebhookDeliveryQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = 50
+	}
+
+	db := database.DB.Where("endpoint_id = ?", endpoint.ID)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	var deliveries []models.WebhookDelivery
+	if err := db.Order("created_at DESC").Limit(query.Limit).Find(&deliveries).Error; err != nil {
+		log.Println("❌ Failed to list webhook deliveries:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	list := make([]WebhookDeliveryInfo, 0, len(deliveries))
+	for i := range deliveries {
+		list = append(list, webhookDeliveryInfo(&deliveries[i]))
+	}
+	c.JSON(http.StatusOK, WebhookDeliveryListResponse{Deliveries: list})
+}
+
+// ✅ Redeliver (delivered or dead deliveries start over with a fresh set of attempts)
+func RedeliverWebhook(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated.New())
+		return
+	}
+	endpoint, ok := webhookFromPath(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidParameter.WithDetail("Invalid delivery ID"))
+		return
+	}
+
+	delivery, err := webhooks.Redeliver(endpoint.ID, deliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.WebhookDeliveryNotFound.New())
+		return
+	}
+	if err != nil {
+		log.Println("❌ Failed to redeliver webhook:", err)
+		apierror.Abort(c, apierror.Internal.New())
+		return
+	}
+
+	recordAudit(c, adminID, "admin.webhook_redelivered", gin.H{"webhook_id": endpoint.ID, "delivery_id": delivery.ID})
+
+	log.Println("✅ Webhook delivery queued again:", delivery.ID)
+	c.JSON(http.StatusOK, webhookDeliveryInfo(delivery))
+}
+
+// ✅ Endpoint named by :webhook_id (aborts with a problem when there is none)
+func webhookFromPath(c *gin.Context) (*models.WebhookEndpoint, bool) {
+	id, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidParameter.WithDetail("Invalid webhook ID"))
+		return nil, false
+	}
+	var endpoint models.WebhookEndpoint
+	if err := database.DB.First(&endpoint, "id = ?", id).Error; err != nil {
+		apierror.Abort(c, apierror.WebhookNotFound.New())
+		return nil, false
+	}
+	return &endpoint, true
+}
+
+// ✅ Check a webhook URL and event filter (nil = not being set)
+//
+// Payloads carry email addresses, so plain HTTP is only accepted for local
+// receivers during development.
+func checkWebhook(rawURL *string, events *[]string) *apierror.Problem {
+	if rawURL != nil {
+		u, err := url.Parse(*rawURL)
+		if err != nil || u.Host == "" {
+			return apierror.InvalidField("url", "url", "must be an absolute URL")
+		}
+		local := u.Hostname() == "localhost" || net.ParseIP(u.Hostname()).IsLoopback()
+		if u.Scheme != "https" && !(u.Scheme == "http" && local) {
+			return apierror.InvalidField("url", "https", "must use https (http only for localhost)")
+		}
+	}
+	if events != nil {
+		for _, event := range *events {
+			if !slices.Contains(webhooks.Events, event) {
+				return apierror.InvalidField("events", "oneof", "unknown event "+event+" (want one of: "+strings.Join(webhooks.Events, ", ")+")")
+			}
+		}
+		*events = slices.Compact(slices.Sorted(slices.Values(*events)))
+	}
+	return nil
+}
+
+func webhookInfo(e *models.WebhookEndpoint) WebhookInfo {
+	events := strings.Fields(e.Events)
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookInfo{
+		ID:          e.ID,
+		URL:         e.URL,
+		Description: e.Description,
+		Events:      events,
+		Enabled:     e.DisabledAt == nil,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+func webhookDeliveryInfo(d *models.WebhookDelivery) WebhookDeliveryInfo {
+	info := WebhookDeliveryInfo{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastAttemptAt:  d.LastAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		Payload:        d.Payload,
+	}
+	if d.Status == models.WebhookPending {
+		info.NextAttemptAt = &d.NextAttemptAt
+	}
+	return info
+}
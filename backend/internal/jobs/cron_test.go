@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		expr, from, want string
+	}{
+		// Steps
+		{"*/15 * * * *", "2026-01-01 10:07", "2026-01-01 10:15"},
+		{"*/15 * * * *", "2026-01-01 10:15", "2026-01-01 10:30"}, // Strictly after
+		{"*/15 * * * *", "2026-01-01 23:50", "2026-01-02 00:00"},
+		{"5/20 * * * *", "2026-01-01 10:06", "2026-01-01 10:25"},
+		{"0-30/10 9 * * *", "2026-01-01 09:25", "2026-01-01 09:30"},
+		{"0-30/10 9 * * *", "2026-01-01 09:31", "2026-01-02 09:00"},
+		{"0 */6 * * *", "2026-01-01 13:00", "2026-01-01 18:00"},
+
+		// Values, ranges and lists
+		{"30 3 * * *", "2026-01-01 03:30", "2026-01-02 03:30"},
+		{"0,30 * * * *", "2026-01-01 10:01", "2026-01-01 10:30"},
+		{"0 9-17 * * 1-5", "2026-01-02 17:30", "2026-01-05 09:00"}, // Friday evening to Monday morning
+		{"0 0 1 * *", "2026-01-15 00:00", "2026-02-01 00:00"},
+		{"0 0 1 1,7 *", "2026-01-01 00:00", "2026-07-01 00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"}, // Next leap day
+
+		// Day of month OR day of week when both are restricted
+		{"0 0 13 * 5", "2026-01-01 00:00", "2026-01-02 00:00"},   // Friday the 2nd
+		{"0 0 13 * 5", "2026-01-09 00:00", "2026-01-13 00:00"},   // Tuesday the 13th
+		{"0 0 */10 * 1", "2026-01-01 00:00", "2026-05-11 00:00"}, // As in cron "*/10" counts as unrestricted, so both must match
+		{"0 12 * * 1", "2026-01-01 00:00", "2026-01-05 12:00"},
+
+		// Sunday is 0 and 7
+		{"0 0 * * 0", "2026-01-01 00:00", "2026-01-04 00:00"},
+		{"0 0 * * 7", "2026-01-01 00:00", "2026-01-04 00:00"},
+		{"0 0 * * 5-7", "2026-01-03 01:00", "2026-01-04 00:00"},
+		{"0 0 * * 6-7", "2026-01-04 01:00", "2026-01-10 00:00"},
+
+		// Shorthands
+		{"@hourly", "2026-01-01 10:07", "2026-01-01 11:00"},
+		{"@daily", "2026-01-01 10:07", "2026-01-02 00:00"},
+		{"@weekly", "2026-01-01 10:07", "2026-01-04 00:00"},
+		{"@monthly", "2026-01-01 10:07", "2026-02-01 00:00"},
+		{"@yearly", "2026-01-01 10:07", "2027-01-01 00:00"},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%q after %s = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04 Mon"), tt.want)
+		}
+	}
+}
+
+func TestScheduleNextInUTC(t *testing.T) {
+	s, err := ParseSchedule("0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin := time.FixedZone("CET", 60*60)
+	from := time.Date(2026, 1, 1, 3, 30, 0, 0, berlin) // 02:30 UTC
+	if got := s.Next(from); !got.Equal(at("2026-01-01 03:00")) {
+		t.Errorf("Next = %s, want 03:00 UTC the same day", got)
+	}
+	if s.String() != "0 3 * * *" {
+		t.Errorf("String() = %q", s.String())
+	}
+}
+
+func TestParseScheduleRejects(t *testing.T) {
+	tests := map[string]string{
+		"too few fields":    "* * * *",
+		"too many fields":   "* * * * * *",
+		"unknown shorthand": "@fortnightly",
+		"minute too big":    "60 * * * *",
+		"hour too big":      "0 24 * * *",
+		"day zero":          "0 0 0 * *",
+		"month too big":     "0 0 1 13 *",
+		"weekday too big":   "0 0 * * 8",
+		"reversed range":    "0 0 * * 5-1",
+		"zero step":         "*/0 * * * *",
+		"negative step":     "*/-5 * * * *",
+		"bad step":          "*/x * * * *",
+		"not a number":      "a * * * *",
+		"bad range end":     "1-x * * * *",
+		"empty list item":   "1,,2 * * * *",
+		"never (Feb 30)":    "0 0 30 2 *",
+		"never (Apr 31)":    "0 0 31 4 *",
+	}
+	for name, expr := range tests {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("%s: ParseSchedule(%q) succeeded", name, expr)
+		}
+	}
+
+	// The 31st exists in some months, so this one does match
+	if _, err := ParseSchedule("0 0 31 * *"); err != nil {
+		t.Errorf("ParseSchedule(\"0 0 31 * *\"): %v", err)
+	}
+}
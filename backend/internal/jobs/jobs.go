@@ -0,0 +1,298 @@
+// Package jobs runs scheduled maintenance inside the service.
+//
+// Jobs are registered with a cron schedule (see Schedule) before Start. Every
+// replica runs the scheduler, and they share each job's state in
+// scheduled_jobs. A replica only runs a due job while it holds a Postgres
+// advisory lock named after the job, and re-checks that the job is still due
+// once it has the lock: the replica that wins leads that run, the others skip
+// it. Every run is recorded in job_runs.
+//
+// A job's schedule can be changed with JOB_<NAME>_SCHEDULE (the name in upper
+// case, dashes as underscores, e.g. JOB_PRUNE_SESSIONS_SCHEDULE="0 */6 * * *"),
+// or set to "off" to disable the job on that replica.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ Job is a named piece of maintenance work
+type Job struct {
+	Name     string                           // Stable: the lock and the run history refer to it
+	Schedule string                           // Default cron expression
+	Run      func(now time.Time) (int, error) // Returns how many items it dealt with
+}
+
+type entry struct {
+	Job
+	schedule *Schedule
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]*entry{}
+)
+
+// ✅ Returned for a job name nothing registered
+var ErrUnknownJob = errors.New("jobs: unknown job")
+
+// ✅ Register a job (panics on a duplicate name or an invalid default schedule)
+func Register(job Job) {
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		panic(fmt.Sprintf("jobs: %s: %v", job.Name, err))
+	}
+
+	envVar := "JOB_" + strings.ToUpper(strings.ReplaceAll(job.Name, "-", "_")) + "_SCHEDULE"
+	switch override := os.Getenv(envVar); override {
+	case "":
+	case "off":
+		log.Printf("⚠️ Job %s is disabled by %s", job.Name, envVar)
+		return
+	default:
+		if parsed, err := ParseSchedule(override); err == nil {
+			schedule = parsed
+		} else {
+			log.Printf("⚠️ WARNING: %s is not a valid schedule (%v), using default %s", envVar, err, job.Schedule)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[job.Name]; ok {
+		panic(fmt.Sprintf("jobs: %s is already registered", job.Name))
+	}
+	registry[job.Name] = &entry{Job: job, schedule: schedule}
+}
+
+// ✅ Registered job, or nil
+func lookup(name string) *entry {
+	mu.RLock()
+	defer mu.RUnlock()
+	return registry[name]
+}
+
+// ✅ Names of the registered jobs, sorted
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ✅ Start the scheduler in the background (JOB_POLL_INTERVAL, default 30s)
+//
+// It stops once ctx is cancelled, after any run in progress; the returned
+// channel is closed when it has.
+func Start(ctx context.Context) <-chan struct{} {
+	interval := env.Duration("JOB_POLL_INTERVAL", 30*time.Second)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := RunDue(time.Now()); err != nil {
+				log.Println("❌ Failed to run scheduled jobs:", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	log.Printf("⏰ Job runner polling every %s for %d jobs", interval, len(Names()))
+	return done
+}
+
+// ✅ Run every job that is due or requested at now; returns the runs this instance made
+func RunDue(now time.Time) ([]models.JobRun, error) {
+	if err := syncJobs(now); err != nil {
+		return nil, err
+	}
+
+	var due []string
+	err := database.DB.Model(&models.ScheduledJob{}).
+		Where("name IN ? AND (next_run_at <= ? OR requested_at IS NOT NULL)", Names(), now).
+		Order("name").Pluck("name", &due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []models.JobRun
+	for _, name := range due {
+		run, err := runLeader(lookup(name), now)
+		if err != nil {
+			log.Println("❌ Failed to run job", name+":", err)
+			continue
+		}
+		if run != nil {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, nil
+}
+
+// ✅ Make sure every registered job has its row (a changed schedule resets the next run)
+func syncJobs(now time.Time) error {
+	for _, name := range Names() {
+		e := lookup(name)
+		state := models.ScheduledJob{Name: name, Schedule: e.schedule.String(), NextRunAt: e.schedule.Next(now)}
+		err := database.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"schedule", "next_run_at", "updated_at"}),
+			Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "scheduled_jobs.schedule <> excluded.schedule"}}},
+		}).Create(&state).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ✅ Run a job if this instance takes its lock and the job is still due (nil run = another instance has it)
+//
+// The transaction holding the advisory lock stays open for the whole run, so
+// the lock goes away with the instance if it dies mid-run.
+func runLeader(e *entry, now time.Time) (*models.JobRun, error) {
+	var run *models.JobRun
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", "arcadia.job."+e.Name).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var state models.ScheduledJob
+		if err := tx.First(&state, "name = ?", e.Name).Error; err != nil {
+			return err
+		}
+		trigger := models.JobTriggerSchedule
+		if state.RequestedAt != nil {
+			trigger = models.JobTriggerManual
+		} else if state.NextRunAt.After(now) {
+			return nil // Another instance ran this occurrence already
+		}
+
+		// ✅ Runs are only marked running under the lock, so any left over belong to a dead instance
+		err := database.DB.Model(&models.JobRun{}).Where("job = ? AND status = ?", e.Name, models.JobRunning).
+			Updates(map[string]interface{}{"status": models.JobFailed, "error": "interrupted: its instance stopped", "finished_at": now}).Error
+		if err != nil {
+			return err
+		}
+
+		if run, err = execute(e, trigger); err != nil {
+			return err
+		}
+
+		next := state.NextRunAt // A manual run leaves the schedule alone
+		if finished := *run.FinishedAt; !next.After(finished) {
+			next = e.schedule.Next(finished)
+		}
+		return tx.Model(&state).Updates(map[string]interface{}{"next_run_at": next, "requested_at": nil, "last_run_id": run.ID}).Error
+	})
+	return run, err
+}
+
+// ✅ Run the job once, recording the run (outside the lock transaction, so it shows as running)
+func execute(e *entry, trigger string) (*models.JobRun, error) {
+	run := &models.JobRun{
+		ID:        uuid.New(),
+		Job:       e.Name,
+		Status:    models.JobRunning,
+		Trigger:   trigger,
+		Instance:  instance,
+		StartedAt: time.Now(),
+	}
+	if err := database.DB.Create(run).Error; err != nil {
+		return nil, err
+	}
+
+	affected, err := call(e)
+	finished := time.Now()
+	run.Affected, run.FinishedAt, run.Status = affected, &finished, models.JobSucceeded
+	if err != nil {
+		run.Status, run.Error = models.JobFailed, err.Error()
+		log.Printf("❌ Job %s failed after %s: %v", e.Name, finished.Sub(run.StartedAt).Round(time.Millisecond), err)
+	} else if affected > 0 {
+		log.Printf("🧹 Job %s dealt with %d items in %s", e.Name, affected, finished.Sub(run.StartedAt).Round(time.Millisecond))
+	}
+
+	err = database.DB.Model(run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"affected":    run.Affected,
+		"error":       run.Error,
+		"finished_at": finished,
+	}).Error
+	return run, err
+}
+
+// ✅ Call the job (a panic counts as a failure)
+func call(e *entry) (affected int, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return e.Run(time.Now())
+}
+
+// ✅ Ask for a job to run at the next poll of any instance
+func Trigger(name string, now time.Time) error {
+	if lookup(name) == nil {
+		return ErrUnknownJob
+	}
+	if err := syncJobs(now); err != nil {
+		return err
+	}
+	return database.DB.Model(&models.ScheduledJob{}).Where("name = ?", name).Update("requested_at", now).Error
+}
+
+// ✅ State of every registered job, by name
+func List(now time.Time) ([]models.ScheduledJob, error) {
+	if err := syncJobs(now); err != nil {
+		return nil, err
+	}
+	var states []models.ScheduledJob
+	err := database.DB.Where("name IN ?", Names()).Order("name").Find(&states).Error
+	return states, err
+}
+
+// ✅ Remove finished runs older than JOB_RUN_RETENTION (default 30 days)
+func PruneRuns(now time.Time) (int, error) {
+	cutoff := now.Add(-env.Duration("JOB_RUN_RETENTION", 30*24*time.Hour))
+	result := database.DB.Where("status <> ? AND started_at < ?", models.JobRunning, cutoff).Delete(&models.JobRun{})
+	return int(result.RowsAffected), result.Error
+}
+
+// ✅ This process, as recorded on its runs ("<host>:<pid>")
+var instance = func() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}()
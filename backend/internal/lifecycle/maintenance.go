@@ -0,0 +1,73 @@
+package lifecycle
+
+import (
+	"time"
+
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ How long an email change confirmation link works (EMAIL_CHANGE_TTL, default 24h)
+func EmailChangeTTL() time.Duration {
+	return env.Duration("EMAIL_CHANGE_TTL", 24*time.Hour)
+}
+
+// ✅ How long audit events are kept (AUDIT_RETENTION, default 365 days; 0 keeps them forever)
+func AuditRetention() time.Duration {
+	return env.Duration("AUDIT_RETENTION", 365*24*time.Hour)
+}
+
+// ✅ Delete email change requests whose link has expired; returns how many went
+//
+// Runs as the "expire-email-changes" job. Until then the address stays
+// reserved for the request, so nobody else can ask for it.
+func ExpireEmailChanges(now time.Time) (int, error) {
+	result := database.DB.Where("created_at < ?", now.Add(-EmailChangeTTL())).Delete(&models.UserEmailChange{})
+	return int(result.RowsAffected), result.Error
+}
+
+// ✅ Delete sessions whose refresh token has expired; returns how many went
+//
+// Runs as the "prune-sessions" job.
+func PruneSessions(now time.Time) (int, error) {
+	result := database.DB.Where("expires_at < ?", now).Delete(&models.UserSession{})
+	return int(result.RowsAffected), result.Error
+}
+
+// ✅ Delete audit events older than the retention period; returns how many went
+//
+// Runs as the "prune-audit-events" job.
+func PruneAuditEvents(now time.Time) (int, error) {
+	retention := AuditRetention()
+	if retention <= 0 {
+		return 0, nil
+	}
+	result := database.DB.Where("created_at < ?", now.Add(-retention)).Delete(&models.AuditEvent{})
+	return int(result.RowsAffected), result.Error
+}
+
+// ✅ Forget used single-use links once they have expired anyway; returns how many went
+//
+// Runs as the "prune-used-action-tokens" job.
+func PruneUsedActionTokens(now time.Time) (int, error) {
+	result := database.DB.Where("expires_at < ?", now).Delete(&models.UsedActionToken{})
+	return int(result.RowsAffected), result.Error
+}
+
+// ✅ Delete device sign-ins whose codes have expired; returns how many went
+//
+// Runs as the "prune-device-authorizations" job (starting a new sign-in prunes them too).
+func PruneDeviceAuthorizations(now time.Time) (int, error) {
+	result := database.DB.Where("expires_at < ?", now).Delete(&models.DeviceAuthorization{})
+	return int(result.RowsAffected), result.Error
+}
+
+// ✅ Forget sent emails whose event has left the outbox (nothing can retry it); returns how many went
+//
+// Runs as the "prune-sent-emails" job.
+func PruneSentEmails(now time.Time) (int, error) {
+	result := database.DB.Where("event_id NOT IN (?)", database.DB.Model(&models.OutboxEvent{}).Select("id")).
+		Delete(&models.SentEmail{})
+	return int(result.RowsAffected), result.Error
+}
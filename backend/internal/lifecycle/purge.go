@@ -0,0 +1,186 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/avatar"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/mailer"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ How long a soft-deleted account is kept before it is hard-deleted (ACCOUNT_PURGE_GRACE, default 30 days)
+func PurgeGracePeriod() time.Duration {
+	return env.Duration("ACCOUNT_PURGE_GRACE", 30*24*time.Hour)
+}
+
+// ✅ How long before the purge the user is warned by email (ACCOUNT_PURGE_NOTICE, default 3 days)
+func PurgeNoticePeriod() time.Duration {
+	return env.Duration("ACCOUNT_PURGE_NOTICE", 3*24*time.Hour)
+}
+
+// ✅ When a soft-deleted account will be purged
+func PurgeDeadline(deletedAt time.Time) time.Time {
+	return deletedAt.Add(PurgeGracePeriod())
+}
+
+// ✅ Send pending purge notices, then hard-delete accounts past their grace period; returns how many were purged
+//
+// Runs as the "purge-deleted-users" job.
+func RunPurge(now time.Time) (int, error) {
+	notified, err := SendPurgeNotices(now)
+	if err != nil {
+		return 0, fmt.Errorf("send purge notices: %w", err)
+	}
+	if notified > 0 {
+		log.Printf("📧 Sent %d account purge notices", notified)
+	}
+	return PurgeDeletedAccounts(now)
+}
+
+// ✅ Email users whose soft-deleted account is about to be purged
+func SendPurgeNotices(now time.Time) (int, error) {
+	noticeFrom := now.Add(-(PurgeGracePeriod() - PurgeNoticePeriod()))
+
+	var users []models.User
+	err := database.DB.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at <= ? AND purge_notice_sent_at IS NULL", noticeFrom).
+		Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, user := range users {
+		deadline := PurgeDeadline(user.DeletedAt.Time)
+		err := mailer.Send(user.Email, "Your account will be permanently deleted",
+			fmt.Sprintf("Your ArcadiaGo account (%s) was deleted and will be permanently erased on %s.\n\nAfter that it cannot be restored.",
+				user.Username, deadline.Format(time.RFC1123)))
+		if err != nil {
+			log.Println("⚠️ Failed to send purge notice to user:", user.ID, err)
+			continue
+		}
+
+		database.DB.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Update("purge_notice_sent_at", now)
+		sent++
+	}
+	return sent, nil
+}
+
+// ✅ Hard-delete accounts whose grace period has passed, with everything that references them
+func PurgeDeletedAccounts(now time.Time) (int, error) {
+	cutoff := now.Add(-PurgeGracePeriod())
+
+	var userIDs []uuid.UUID
+	err := database.DB.Unscoped().Model(&models.User{}).
+		Where("deleted_at IS NOT NULL AND deleted_at <= ?", cutoff).
+		Pluck("id", &userIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, userID := range userIDs {
+		if err := PurgeUser(userID); err != nil {
+			log.Println("❌ Failed to purge user:", userID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// ✅ Permanently delete a user and all rows that belong to them, publishing UserPurged
+func PurgeUser(userID uuid.UUID) error {
+	var user models.User
+	if err := database.DB.Unscoped().Select("avatar_key").First(&user, "id = ?", userID).Error; err == nil {
+		avatar.Delete(context.Background(), user.AvatarKey)
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := handOverOrganizations(tx, userID); err != nil {
+			return err
+		}
+
+		dependents := []interface{}{
+			&models.UserSession{},
+			&models.UserEmailChange{},
+			&models.MagicLinkToken{},
+			&models.UsedActionToken{},
+			&models.TrustedDevice{},
+			&models.DeviceAuthorization{},
+			&models.Impersonation{},
+			&models.UsernameHistory{},
+			&models.Membership{},
+			&models.AuditEvent{},
+		}
+		for _, model := range dependents {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Delete(&models.User{}, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		return events.Publish(tx, events.Meta{}, events.UserPurged{UserID: userID})
+	})
+}
+
+// ✅ Give every organization the user is the only owner of a new owner
+//
+// The heir is the highest-ranked remaining member (active accounts before
+// deleted ones, then the longest-standing). An organization with nobody left
+// is deleted along with the user.
+func handOverOrganizations(tx *gorm.DB, userID uuid.UUID) error {
+	var orgIDs []uuid.UUID
+	err := tx.Model(&models.Membership{}).Where("user_id = ? AND role = ?", userID, models.OrgRoleOwner).Pluck("organization_id", &orgIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, orgID := range orgIDs {
+		// Lock the owner rows, as demoting or removing an owner does
+		var owners []uuid.UUID
+		err := tx.Model(&models.Membership{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("organization_id = ? AND role = ? AND user_id <> ?", orgID, models.OrgRoleOwner, userID).
+			Pluck("user_id", &owners).Error
+		if err != nil {
+			return err
+		}
+		if len(owners) > 0 {
+			continue
+		}
+
+		var heir models.Membership
+		err = tx.Joins("JOIN users ON users.id = memberships.user_id").
+			Where("memberships.organization_id = ? AND memberships.user_id <> ?", orgID, userID).
+			Order("users.deleted_at IS NOT NULL").
+			Order(fmt.Sprintf("memberships.role = '%s' DESC", models.OrgRoleAdmin)).
+			Order("memberships.created_at").
+			First(&heir).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Delete(&models.Organization{}, "id = ?", orgID).Error; err != nil {
+				return err
+			}
+			log.Println("🧹 Deleted organization left without members:", orgID)
+		case err != nil:
+			return err
+		default:
+			if err := tx.Model(&heir).Update("role", models.OrgRoleOwner).Error; err != nil {
+				return err
+			}
+			log.Println("✅ Ownership of organization", orgID, "passed to user", heir.UserID)
+		}
+	}
+	return nil
+}
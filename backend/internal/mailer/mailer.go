@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"errors"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// ✅ Mailer delivers plain-text emails
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// ✅ Active Mailer (SMTP by default, replaceable for tests or other providers)
+var active Mailer = NewSMTPMailer()
+
+// ✅ Replace the Active Mailer
+func SetMailer(m Mailer) {
+	active = m
+}
+
+// ✅ Send an email through the active mailer
+func Send(to, subject, body string) error {
+	return active.Send(to, subject, body)
+}
+
+// ✅ SMTP Mailer (MailHog in development)
+type SMTPMailer struct {
+	Host string
+	Port string
+	From string
+}
+
+// ✅ Create an SMTP Mailer from SMTP_HOST / SMTP_PORT / SMTP_FROM
+func NewSMTPMailer() *SMTPMailer {
+	return &SMTPMailer{
+		Host: getEnv("SMTP_HOST", "localhost"),
+		Port: getEnv("SMTP_PORT", "1025"), // MailHog SMTP port
+		From: getEnv("SMTP_FROM", "no-reply@arcadiago.dev"),
+	}
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	msg, err := message(m.From, to, subject, body)
+	if err != nil {
+		log.Println("❌ Refused to send email:", err)
+		return err
+	}
+
+	if err := smtp.SendMail(m.Host+":"+m.Port, nil, m.From, []string{to}, msg); err != nil {
+		log.Println("❌ Failed to send email:", err)
+		return err
+	}
+
+	log.Println("📧 Email sent successfully to:", to)
+	return nil
+}
+
+// ✅ Returned for an address that would break out of its header
+var ErrHeaderInjection = errors.New("mailer: line break in an address")
+
+// ✅ Build the message: addresses must be single lines, the subject is MIME-encoded when needed
+//
+// Subjects carry user-chosen text (organization names), so a CR or LF in one
+// is encoded rather than allowed to start a header of its own.
+func message(from, to, subject, body string) ([]byte, error) {
+	if strings.ContainsAny(from, "\r\n") || strings.ContainsAny(to, "\r\n") {
+		return nil, ErrHeaderInjection
+	}
+	return []byte(
+		"From: " + from + "\r\n" +
+			"To: " + to + "\r\n" +
+			"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+			"MIME-Version: 1.0\r\n" +
+			"Content-Type: text/plain; charset=utf-8\r\n" +
+			"\r\n" +
+			body + "\r\n",
+	), nil
+}
+
+// ✅ Read an environment variable with a fallback
+func getEnv(envVar, defaultValue string) string {
+	if value := os.Getenv(envVar); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"errors"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestMessageEncodesSubject(t *testing.T) {
+	msg, err := message("no-reply@arcadiago.dev", "ada@example.com", "You're invited to join Acme\r\nBcc: mallory@example.com", "Hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(msg)))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if bcc := parsed.Header.Get("Bcc"); bcc != "" {
+		t.Fatalf("subject injected a Bcc header: %q", bcc)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "You're invited to join Acme\r\nBcc: mallory@example.com" {
+		t.Errorf("decoded subject %q, %v", subject, err)
+	}
+
+	// Plain subjects stay readable
+	msg, _ = message("no-reply@arcadiago.dev", "ada@example.com", "Confirm Email Change", "Hello")
+	if !strings.Contains(string(msg), "\r\nSubject: Confirm Email Change\r\n") {
+		t.Errorf("plain subject was encoded:\n%s", msg)
+	}
+}
+
+func TestMessageRejectsBrokenAddresses(t *testing.T) {
+	for _, to := range []string{"ada@example.com\r\nBcc: mallory@example.com", "ada@example.com\n"} {
+		if _, err := message("no-reply@arcadiago.dev", to, "Hi", "Hello"); !errors.Is(err, ErrHeaderInjection) {
+			t.Errorf("message to %q: %v", to, err)
+		}
+	}
+}
@@ -3,33 +3,94 @@ package middleware
 import (
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
 	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
 )
 
 // ✅ AuthMiddleware - Protects routes by requiring authentication
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token, err := c.Cookie("auth_token")
-		if err != nil {
+		token, ok := accessToken(c)
+		if !ok {
 			log.Println("⚠️ No authentication token found")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthenticated.New())
 			return
 		}
 
 		claims, err := auth.ValidateToken(token, false)
 		if err != nil {
 			log.Println("❌ Invalid authentication token:", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthenticated.WithDetail("Invalid token"))
+			return
+		}
+
+		// ✅ Service tokens have no user to act as
+		if claims.IsService() {
+			log.Println("❌ Service token used on a user endpoint by client:", claims.ClientID)
+			apierror.Abort(c, apierror.UserTokenRequired.New())
+			return
+		}
+
+		// ✅ Reject tokens whose session was revoked (logout, deletion, remote sign-out)
+		if claims.IsImpersonation() {
+			if !ImpersonationActive(claims.SessionID, claims.UserID, claims.Actor.Subject) {
+				log.Println("❌ Impersonation ended or expired for user:", claims.UserID, "actor:", claims.Actor.Subject)
+				apierror.Abort(c, apierror.SessionRevoked.WithDetail("The impersonation has ended"))
+				return
+			}
+			defer recordImpersonatedRequest(c, claims) // Everything done while impersonating is audited, reads and refusals included
+			if c.Request.Method == http.MethodDelete {
+				apierror.Abort(c, apierror.ImpersonationBlocked.WithDetail("Nothing can be deleted while impersonating"))
+				return
+			}
+			c.Set("actor_id", claims.Actor.Subject) // Admin behind the request (recorded in audit events)
+			c.Set("impersonation_id", claims.SessionID)
+		} else if !sessionActive(claims.SessionID, claims.UserID) {
+			log.Println("❌ Session revoked or expired for user:", claims.UserID)
+			apierror.Abort(c, apierror.SessionRevoked.New())
 			return
 		}
 
 		// ✅ Store user_id in context instead of email
 		c.Set("user_id", claims.UserID)
+		c.Set("org_id", claims.OrgID) // Active organization ("" when none)
+		c.Set("session_id", claims.SessionID)
+		c.Set("auth_time", claims.AuthTime) // Unix seconds of the last credential check
 
 		c.Next()
 	}
 }
+
+// ✅ Access token from an "Authorization: Bearer" header (API clients, impersonation), else the auth_token cookie
+//
+// An explicit header wins, so a support tool can send an impersonation
+// token from a browser that also holds the admin's own cookie.
+func accessToken(c *gin.Context) (string, bool) {
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") && strings.TrimSpace(token) != "" {
+		return strings.TrimSpace(token), true
+	}
+	if token, err := c.Cookie("auth_token"); err == nil && token != "" {
+		return token, true
+	}
+	return "", false
+}
+
+// ✅ Check that a session exists, belongs to the user and has not expired
+func sessionActive(sessionID, userID string) bool {
+	if sessionID == "" {
+		return false
+	}
+
+	var count int64
+	database.DB.Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND expires_at > ?", sessionID, userID, time.Now()).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm/clause"
+)
+
+var errNoClientCredentials = errors.New("no client credentials")
+
+// ✅ RequireServiceClient - Protects OAuth endpoints with client credentials
+//
+// Each client uses the one method it was registered with:
+//   - client_secret: HTTP Basic (ID and secret form-encoded as RFC 6749 §2.3.1
+//     requires) or client_id / client_secret form fields
+//   - private_key_jwt: client_assertion / client_assertion_type form fields (RFC 7523)
+//   - self_signed_tls_client_auth: the pinned certificate, plus a client_id field (RFC 8705)
+//
+// Sets "client_id" and "service_client", and "client_cert_thumbprint" for mTLS clients.
+func RequireServiceClient() gin.HandlerFunc {
+	return requireClient(false)
+}
+
+// ✅ RequireClient - Like RequireServiceClient, but also admits public clients
+//
+// A public client (auth method "none", e.g. a CLI) cannot keep a secret, so
+// it only sends its client_id; the endpoint decides what it may do.
+func RequireClient() gin.HandlerFunc {
+	return requireClient(true)
+}
+
+func requireClient(allowPublic bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client, thumbprint, err := authenticateClient(c, allowPublic)
+		if err != nil {
+			if errors.Is(err, errNoClientCredentials) {
+				rejectClient(c, "No client credentials")
+				return
+			}
+			log.Println("❌ Client authentication failed:", err)
+			rejectClient(c, "Invalid client credentials")
+			return
+		}
+
+		touchClient(client.ID)
+		c.Set("client_id", client.ID)
+		c.Set("service_client", client)
+		if thumbprint != "" {
+			c.Set("client_cert_thumbprint", thumbprint)
+		}
+		c.Next()
+	}
+}
+
+// ✅ Authenticate the calling client by whichever credentials it presented
+func authenticateClient(c *gin.Context, allowPublic bool) (*models.ServiceClient, string, error) {
+	if assertion := c.PostForm("client_assertion"); assertion != "" {
+		client, err := authenticateAssertion(c, assertion)
+		return client, "", err
+	}
+
+	if clientID, secret, ok := clientSecret(c); ok {
+		client, err := enabledClient(clientID, models.ClientAuthSecret)
+		if err != nil {
+			return nil, "", err
+		}
+		if subtle.ConstantTimeCompare([]byte(auth.HashURLToken(secret)), []byte(client.SecretHash)) != 1 {
+			return nil, "", errors.New("wrong secret for client " + clientID)
+		}
+		return client, "", nil
+	}
+
+	if cert := clientCertificate(c); cert != nil {
+		clientID := c.PostForm("client_id")
+		if clientID == "" {
+			return nil, "", errors.New("mTLS request without client_id")
+		}
+		client, err := enabledClient(clientID, models.ClientAuthTLS)
+		if err != nil {
+			return nil, "", err
+		}
+		thumbprint := auth.CertThumbprint(cert)
+		if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(client.CertThumbprint)) != 1 {
+			return nil, "", errors.New("unexpected certificate for client " + clientID)
+		}
+		return client, thumbprint, nil
+	}
+
+	// ✅ A bare client_id only identifies a public client (a confidential one must prove itself)
+	if clientID := c.PostForm("client_id"); clientID != "" {
+		if !allowPublic {
+			return nil, "", errors.New("client " + clientID + " sent only its client_id")
+		}
+		client, err := enabledClient(clientID, models.ClientAuthNone)
+		return client, "", err
+	}
+
+	return nil, "", errNoClientCredentials
+}
+
+// ✅ Client ID and secret from the Authorization header, else the form body
+func clientSecret(c *gin.Context) (string, string, bool) {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		id, errID := url.QueryUnescape(id)
+		secret, errSecret := url.QueryUnescape(secret)
+		return id, secret, errID == nil && errSecret == nil && id != ""
+	}
+	id, secret := c.PostForm("client_id"), c.PostForm("client_secret")
+	return id, secret, id != "" && secret != ""
+}
+
+// ✅ Check a private_key_jwt assertion and burn its jti
+func authenticateAssertion(c *gin.Context, assertion string) (*models.ServiceClient, error) {
+	if c.PostForm("client_assertion_type") != auth.ClientAssertionType {
+		return nil, errors.New("unsupported client_assertion_type")
+	}
+	clientID, err := auth.ClientAssertionIssuer(assertion)
+	if err != nil {
+		return nil, err
+	}
+	if id := c.PostForm("client_id"); id != "" && id != clientID {
+		return nil, errors.New("client_id does not match the assertion issuer")
+	}
+
+	client, err := enabledClient(clientID, models.ClientAuthPrivateKeyJWT)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := auth.VerifyClientAssertion(assertion, client.ID, client.PublicKeyPEM, assertionAudiences(c))
+	if err != nil {
+		return nil, err
+	}
+
+	// ✅ Each assertion works once (expired IDs are pruned as new ones arrive)
+	now := time.Now()
+	database.DB.Where("expires_at < ?", now.Add(-time.Minute)).Delete(&models.ClientAssertion{})
+	used := database.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ClientAssertion{ClientID: client.ID, JTI: claims.ID, ExpiresAt: claims.ExpiresAt.Time})
+	if used.Error != nil {
+		return nil, used.Error
+	}
+	if used.RowsAffected == 0 {
+		return nil, errors.New("replayed client assertion for client " + client.ID)
+	}
+	return client, nil
+}
+
+// ✅ URLs an assertion may be addressed to: this endpoint, or the server as a whole
+//
+// With APP_BASE_URL set its origin is authoritative; otherwise the request's
+// own Host is used.
+func assertionAudiences(c *gin.Context) []string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	origin := scheme + "://" + c.Request.Host
+
+	var audiences []string
+	if base := strings.TrimRight(os.Getenv("APP_BASE_URL"), "/"); base != "" {
+		if parsed, err := url.Parse(base); err == nil && parsed.Host != "" {
+			origin = parsed.Scheme + "://" + parsed.Host
+			audiences = append(audiences, base)
+		}
+	}
+	return append(audiences, origin+c.Request.URL.Path)
+}
+
+// ✅ Verified client certificate: from the TLS connection, or a trusted proxy header
+//
+// Behind a TLS-terminating proxy set MTLS_CLIENT_CERT_HEADER to the header
+// it forwards the verified certificate in, as URL-encoded PEM (e.g. nginx's
+// $ssl_client_escaped_cert). Only do so when clients cannot reach the server
+// around the proxy, since the header is trusted as is.
+func clientCertificate(c *gin.Context) *x509.Certificate {
+	if c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0 {
+		return c.Request.TLS.PeerCertificates[0]
+	}
+
+	header := os.Getenv("MTLS_CLIENT_CERT_HEADER")
+	if header == "" || c.GetHeader(header) == "" {
+		return nil
+	}
+	decoded, err := url.QueryUnescape(c.GetHeader(header))
+	if err != nil {
+		return nil
+	}
+	block, _ := pem.Decode([]byte(decoded))
+	if block == nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	return cert
+}
+
+// ✅ Load an enabled client registered for the given authentication method
+func enabledClient(clientID, method string) (*models.ServiceClient, error) {
+	var client models.ServiceClient
+	if err := database.DB.Where("id = ? AND disabled_at IS NULL", clientID).First(&client).Error; err != nil {
+		return nil, errors.New("unknown or disabled client " + clientID)
+	}
+	if client.AuthMethod != method {
+		return nil, errors.New("client " + clientID + " must authenticate with " + client.AuthMethod)
+	}
+	return &client, nil
+}
+
+// ✅ Record that a client authenticated (informational, at most once a minute per client)
+func touchClient(clientID string) {
+	now := time.Now()
+	database.DB.Model(&models.ServiceClient{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", clientID, now.Add(-time.Minute)).
+		Update("last_used_at", now)
+}
+
+// ✅ 401 invalid_client, with the challenge RFC 6749 §5.2 asks for
+func rejectClient(c *gin.Context, detail string) {
+	c.Header("WWW-Authenticate", `Basic realm="ArcadiaGo"`)
+	apierror.Abort(c, apierror.InvalidClient.WithDetail(detail))
+}
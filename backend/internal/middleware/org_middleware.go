@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ RequireOrgRole - Restricts `/orgs/:org_id/...` routes to members holding at least minRole
+func RequireOrgRole(minRole string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			apierror.Abort(c, apierror.Unauthenticated.New())
+			return
+		}
+
+		orgID, err := uuid.Parse(c.Param("org_id"))
+		if err != nil {
+			apierror.Abort(c, apierror.InvalidParameter.WithDetail("Invalid organization ID"))
+			return
+		}
+
+		var membership models.Membership
+		if err := database.DB.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&membership).Error; err != nil {
+			log.Println("⚠️ Organization access denied for user:", userID, "org:", orgID)
+			apierror.Abort(c, apierror.OrgNotFound.New())
+			return
+		}
+
+		if models.OrgRoleRank(membership.Role) < models.OrgRoleRank(minRole) {
+			log.Println("⚠️ Insufficient organization role for user:", userID, "role:", membership.Role)
+			apierror.Abort(c, apierror.OrgInsufficientRole.New())
+			return
+		}
+
+		// ✅ Expose the caller's membership to handlers
+		c.Set("org_membership", membership)
+
+		c.Next()
+	}
+}
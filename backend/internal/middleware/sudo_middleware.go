@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+)
+
+// ✅ Sudo Mode Window (SUDO_MODE_WINDOW, default 10m)
+func SudoModeWindow() time.Duration {
+	return env.Duration("SUDO_MODE_WINDOW", 10*time.Minute)
+}
+
+// ✅ RequireRecentAuth - Requires the user to have re-entered their credentials within d
+//
+// Must run after AuthMiddleware. Clients that get `auth.reauthentication_required`
+// should call POST /reauthenticate and retry.
+func RequireRecentAuth(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authTime := time.Unix(c.GetInt64("auth_time"), 0)
+		if c.GetInt64("auth_time") == 0 || time.Since(authTime) > d {
+			log.Println("⚠️ Recent authentication required for user:", c.GetString("user_id"))
+			apierror.Abort(c, apierror.ReauthRequired.WithDetail("Please confirm your password to continue"))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ✅ Audit Event Model (security-relevant actions on an account)
+type AuditEvent struct {
+	ID        uuid.UUID  `gorm:"primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"index;not null" json:"user_id"`   // Account the event is about
+	ActorID   *uuid.UUID `gorm:"index" json:"actor_id,omitempty"` // Who performed it, when not the user themselves
+	Action    string     `gorm:"index;not null" json:"action"`    // e.g. "auth.login", "user.password_changed"
+	IPAddress string     `json:"ip_address"`
+	UserAgent string     `json:"user_agent"`
+	Metadata  string     `gorm:"type:text" json:"metadata,omitempty"` // JSON-encoded details
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
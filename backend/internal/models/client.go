@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ✅ How a service client proves its identity
+const (
+	ClientAuthSecret        = "client_secret"               // Shared secret, as HTTP Basic or form fields
+	ClientAuthPrivateKeyJWT = "private_key_jwt"             // Signed JWT assertion (RFC 7523)
+	ClientAuthTLS           = "self_signed_tls_client_auth" // Pinned mTLS certificate (RFC 8705); tokens are bound to it
+	ClientAuthNone          = "none"                        // Public client (CLI, TV app): only names itself, may only sign users in
+)
+
+// ✅ Service Client Model (backends that call the OAuth endpoints with client credentials, and public device-flow apps)
+type ServiceClient struct {
+	ID             string `gorm:"primaryKey"` // Public client_id, e.g. "svc_3f9a..."
+	Name           string `gorm:"not null"`
+	AuthMethod     string `gorm:"not null;default:'client_secret'"`
+	SecretHash     string `gorm:"not null;default:''" json:"-"`  // SHA-256 of the client secret (shown once, at creation)
+	PublicKeyPEM   string `gorm:"type:text;not null;default:''"` // private_key_jwt: key its assertions are signed with
+	CertThumbprint string `gorm:"not null;default:''"`           // mTLS: base64url SHA-256 of its certificate
+	AllowedScopes  string `gorm:"not null;default:''"`           // Space-separated scopes it may request
+	DisabledAt     *time.Time
+	LastUsedAt     *time.Time
+	CreatedAt      time.Time
+}
+
+// ✅ Client Assertion Model (private_key_jwt assertion IDs already used, kept until they expire)
+type ClientAssertion struct {
+	ClientID  string    `gorm:"primaryKey"`
+	JTI       string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+// ✅ Device Authorization Model (a pending RFC 8628 device sign-in)
+//
+// The device polls with the device code while the user enters the user code
+// on another screen; the row is deleted once the device collects its tokens.
+type DeviceAuthorization struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	ClientID       string    `gorm:"index;not null"`
+	DeviceCodeHash string    `gorm:"uniqueIndex;not null"` // SHA-256 of the device code
+	UserCode       string    `gorm:"uniqueIndex;not null"` // Normalized: upper case, no dash
+	DeviceName     string    `gorm:"not null"`             // Labels the resulting session
+	IPAddress      string
+	PollInterval   int `gorm:"not null"` // Seconds, raised on every slow_down
+	LastPolledAt   *time.Time
+	UserID         *uuid.UUID `gorm:"index"` // Set on approval
+	OrgID          *uuid.UUID // Organization active in the approving session
+	AuthTime       *time.Time // Approving session's last sign-in (approval is not a fresh one)
+	ApprovedAt     *time.Time
+	DeniedAt       *time.Time
+	ExpiresAt      time.Time `gorm:"index;not null"`
+	CreatedAt      time.Time
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ✅ Event delivery states
+const (
+	EventPending = "pending" // Waiting for the subscriber (again)
+	EventDone    = "done"    // The subscriber handled it
+	EventDead    = "dead"    // The subscriber kept failing; logged, not retried
+)
+
+// ✅ Outbox Event Model (a domain event, written in the same transaction as the change it describes)
+type OutboxEvent struct {
+	ID        uuid.UUID `gorm:"primaryKey"`
+	Name      string    `gorm:"index;not null"`     // e.g. "user.registered"
+	Payload   string    `gorm:"type:text;not null"` // JSON of the typed event
+	Meta      string    `gorm:"type:text;not null"` // JSON of events.Meta: who and from where
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// ✅ Event Delivery Model (one outbox event still to be, or already, handled by one subscriber)
+type EventDelivery struct {
+	EventID       uuid.UUID  `gorm:"primaryKey"`
+	Subscriber    string     `gorm:"primaryKey"` // e.g. "mailer", "webhooks"
+	Status        string     `gorm:"index:idx_event_deliveries_due,priority:1;not null;default:'pending'"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"index:idx_event_deliveries_due,priority:2;not null"`
+	LastError     string     `gorm:"type:text;not null;default:''"`
+	FinishedAt    *time.Time // When it became done or dead
+}
+
+// ✅ Sent Email Model (an email a subscriber sent for one event delivery, so a retry does not send it twice)
+type SentEmail struct {
+	DeliveryKey string    `gorm:"primaryKey"`     // events.Delivery.Key
+	EventID     uuid.UUID `gorm:"index;not null"` // Kept while the event is in the outbox
+	SentAt      time.Time `gorm:"not null"`
+}
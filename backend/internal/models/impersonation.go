@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ✅ Impersonation Model (a support admin acting as a user for a short while)
+//
+// Impersonation tokens carry this row's ID as their session: they stop
+// working when it ends, expires, or the admin's own session does.
+type Impersonation struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	ActorID        uuid.UUID `gorm:"index;not null"` // The admin
+	ActorSessionID uuid.UUID `gorm:"not null"`       // Admin session it was started from
+	UserID         uuid.UUID `gorm:"index;not null"`
+	Reason         string    `gorm:"type:text;not null"` // Ticket or explanation, shown to the user
+	ExpiresAt      time.Time `gorm:"not null"`
+	EndedAt        *time.Time
+	CreatedAt      time.Time
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ✅ Job run states
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed" // Returned an error, panicked, or its instance died mid-run
+)
+
+// ✅ Job run triggers
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual" // Requested by an admin
+)
+
+// ✅ Scheduled Job Model (one row per registered job, shared by every replica)
+type ScheduledJob struct {
+	Name        string     `gorm:"primaryKey"`
+	Schedule    string     `gorm:"not null"` // Cron expression, e.g. "*/15 * * * *"
+	NextRunAt   time.Time  `gorm:"not null"`
+	RequestedAt *time.Time // An admin asked for a run before NextRunAt
+	LastRunID   *uuid.UUID
+	UpdatedAt   time.Time
+}
+
+// ✅ Job Run Model (one execution of a job and its outcome)
+type JobRun struct {
+	ID         uuid.UUID `gorm:"primaryKey"`
+	Job        string    `gorm:"index:idx_job_runs_job,priority:1;not null"`
+	Status     string    `gorm:"not null"`
+	Trigger    string    `gorm:"not null"`
+	Instance   string    `gorm:"not null"`           // Host and process that ran it
+	Affected   int       `gorm:"not null;default:0"` // Rows, accounts or messages it dealt with
+	Error      string    `gorm:"type:text;not null;default:''"`
+	StartedAt  time.Time `gorm:"index:idx_job_runs_job,priority:2;not null"`
+	FinishedAt *time.Time
+}
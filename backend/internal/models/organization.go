@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ✅ Organization Roles (highest privilege first)
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// ✅ Rank an organization role so roles can be compared (0 = unknown)
+func OrgRoleRank(role string) int {
+	switch role {
+	case OrgRoleOwner:
+		return 3
+	case OrgRoleAdmin:
+		return 2
+	case OrgRoleMember:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ✅ Organization Model (tenant)
+type Organization struct {
+	ID              uuid.UUID      `gorm:"primaryKey" json:"id"`
+	Name            string         `gorm:"not null" json:"name"`
+	Slug            string         `gorm:"unique;not null" json:"slug"`
+	ScopedUsernames bool           `gorm:"not null;default:false" json:"scoped_usernames"` // Usernames of users created through this org are unique per org only
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// ✅ Membership Model (user's role inside an organization)
+type Membership struct {
+	ID             uuid.UUID    `gorm:"primaryKey" json:"id"`
+	OrganizationID uuid.UUID    `gorm:"uniqueIndex:idx_memberships_org_user;not null" json:"organization_id"`
+	UserID         uuid.UUID    `gorm:"uniqueIndex:idx_memberships_org_user;index;not null" json:"user_id"`
+	Role           string       `gorm:"not null;default:'member'" json:"role"`
+	Organization   Organization `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	User           User         `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// ✅ Username uniqueness scope for users created through an organization
+func (o *Organization) UsernameScope() string {
+	if o == nil || !o.ScopedUsernames {
+		return ""
+	}
+	return o.ID.String()
+}
+
+// ✅ Organization Invitation Model (email invite, possibly for someone without an account)
+type OrgInvitation struct {
+	ID             uuid.UUID    `gorm:"primaryKey" json:"id"`
+	OrganizationID uuid.UUID    `gorm:"index;not null" json:"organization_id"`
+	Organization   Organization `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Email          string       `gorm:"index;not null" json:"email"`
+	Role           string       `gorm:"not null" json:"role"`
+	TokenHash      string       `gorm:"uniqueIndex;not null" json:"-"` // SHA-256 of the emailed token
+	InvitedByID    uuid.UUID    `gorm:"not null" json:"invited_by"`
+	ExpiresAt      time.Time    `gorm:"not null" json:"expires_at"`
+	AcceptedAt     *time.Time   `json:"accepted_at,omitempty"`
+	RevokedAt      *time.Time   `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// ✅ Check whether an invitation can still be accepted
+func (i *OrgInvitation) IsPending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -9,13 +9,35 @@ import (
 
 // ✅ User Model (Main Table)
 type User struct {
-	ID        uuid.UUID      `gorm:"primaryKey"`
-	Email     string         `gorm:"unique;not null"`
-	Username  string         `gorm:"unique;not null"`
-	Password  string         `gorm:"not null"`
-	DeletedAt gorm.DeletedAt `gorm:"index"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID                uuid.UUID `gorm:"primaryKey"`
+	Email             string    `gorm:"unique;not null"`
+	Username          string    `gorm:"not null;uniqueIndex:idx_users_scope_username,priority:2"`
+	UsernameScope     string    `gorm:"not null;default:'';uniqueIndex:idx_users_scope_username,priority:1"` // "" = global, or org ID for org-scoped usernames
+	UsernameKey       string    `gorm:"not null;default:''"`                                                 // Case-folded username, unique per scope (index created in database.Migrate)
+	Password          string    `gorm:"not null"`
+	DisplayName       string    `gorm:"size:64;not null;default:''"`
+	AvatarURL         string    `gorm:"size:2048;not null;default:''"`
+	AvatarKey         string    `gorm:"size:255;not null;default:''"` // Storage prefix of an uploaded avatar ("" for none or an external URL)
+	Locale            string    `gorm:"size:35;not null;default:''"`  // BCP 47 language tag, e.g. "en-GB"
+	Timezone          string    `gorm:"size:64;not null;default:''"`  // IANA zone name, e.g. "Europe/London"
+	Bio               string    `gorm:"size:500;not null;default:''"`
+	EmailVerifiedAt   *time.Time
+	IsAdmin           bool           `gorm:"not null;default:false"` // Support staff (may impersonate users), granted with cmd/admins
+	PurgeNoticeSentAt *time.Time     // Set once the "account will be purged" email has gone out
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// ✅ Username History Model (previous names, held back from other users for a while)
+type UsernameHistory struct {
+	ID            uuid.UUID `gorm:"primaryKey"`
+	UserID        uuid.UUID `gorm:"index;not null"`
+	Username      string    `gorm:"not null"`
+	UsernameKey   string    `gorm:"not null;index:idx_username_history_lookup,priority:2"`
+	UsernameScope string    `gorm:"not null;default:'';index:idx_username_history_lookup,priority:1"`
+	ChangedAt     time.Time `gorm:"not null"`
+	HeldUntil     time.Time `gorm:"not null"` // Until then only UserID may take the name again, and lookups redirect
 }
 
 // ✅ Email Change Request Model
@@ -29,10 +51,48 @@ type UserEmailChange struct {
 
 // ✅ User Sessions Model
 type UserSession struct {
+	ID         uuid.UUID `gorm:"primaryKey"`
+	UserID     uuid.UUID `gorm:"index;not null;constraint:OnDelete:CASCADE"` // Foreign key reference to User
+	TokenHash  string    `gorm:"not null" json:"-"`                          // SHA-256 of the current refresh token
+	IPAddress  string
+	UserAgent  string
+	ClientID   string    `gorm:"not null;default:''"` // Device-flow client the session was issued to ("" for browser sign-ins)
+	DeviceName string    `gorm:"not null;default:''"` // Label chosen by that client, e.g. "arcadia-cli on build-01"
+	ExpiresAt  time.Time `gorm:"index"`
+	AuthTime   time.Time // Last password (or equivalent) entry, drives sudo mode
+	CreatedAt  time.Time
+}
+
+// ✅ Magic Link Sign-In Token Model
+type MagicLinkToken struct {
 	ID        uuid.UUID `gorm:"primaryKey"`
 	UserID    uuid.UUID `gorm:"index;not null;constraint:OnDelete:CASCADE"` // Foreign key reference to User
-	TokenHash string    `gorm:"not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`                       // SHA-256 of the emailed token
+	NonceHash string    `gorm:"not null"`                                   // SHA-256 of the requesting browser's nonce cookie
 	IPAddress string
-	UserAgent string
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
 	CreatedAt time.Time
 }
+
+// ✅ Used Action Token Model (single-use emailed links, kept until the token expires)
+type UsedActionToken struct {
+	ID        string    `gorm:"primaryKey"` // The token's jti
+	UserID    uuid.UUID `gorm:"index;not null"`
+	Purpose   string    `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	UsedAt    time.Time `gorm:"not null"`
+}
+
+// ✅ Trusted Device Model (devices the user has signed in from)
+type TrustedDevice struct {
+	ID          uuid.UUID `gorm:"primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"uniqueIndex:idx_trusted_devices_user_fingerprint;not null" json:"-"`
+	Fingerprint string    `gorm:"uniqueIndex:idx_trusted_devices_user_fingerprint;not null" json:"-"` // SHA-256 of the user agent and network
+	Name        string    `json:"name"`                                                               // Browser / OS summary, editable by the user
+	UserAgent   string    `json:"user_agent"`
+	LastIP      string    `json:"last_ip"`
+	Location    string    `json:"location"` // From the offline GeoIP database, when configured
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
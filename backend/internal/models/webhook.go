@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ✅ Webhook delivery states
+const (
+	WebhookPending   = "pending"   // Waiting for its (next) attempt
+	WebhookDelivered = "delivered" // The endpoint answered 2xx
+	WebhookDead      = "dead"      // Out of attempts; only a manual redelivery sends it again
+)
+
+// ✅ Webhook Endpoint Model (a URL other systems registered to hear about user lifecycle events)
+type WebhookEndpoint struct {
+	ID          uuid.UUID `gorm:"primaryKey"`
+	URL         string    `gorm:"not null"`
+	Description string    `gorm:"not null;default:''"`
+	Events      string    `gorm:"not null;default:''"` // Space-separated event types it receives; empty = all
+	Secret      string    `gorm:"not null" json:"-"`   // HMAC key for the signature header (kept in clear: signing needs it)
+	DisabledAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ✅ Webhook Delivery Model (one event queued for one endpoint, and the outcome of its attempts)
+//
+// Rows are the durable queue and the delivery log at once: pending ones are
+// due at NextAttemptAt, the others are kept until WEBHOOK_DELIVERY_RETENTION.
+type WebhookDelivery struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	EndpointID     uuid.UUID `gorm:"uniqueIndex:idx_webhook_deliveries_event,priority:1;not null"`
+	EventID        uuid.UUID `gorm:"uniqueIndex:idx_webhook_deliveries_event,priority:2;index;not null"` // Same for every endpoint and retry: receivers deduplicate on it
+	Event          string    `gorm:"not null"`                                                           // e.g. "user.registered"
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"index:idx_webhook_deliveries_due,priority:1;not null;default:'pending'"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2;not null"`
+	LastAttemptAt  *time.Time
+	ResponseStatus int    `gorm:"not null;default:0"` // HTTP status of the last attempt (0 = no response)
+	LastError      string `gorm:"type:text;not null;default:''"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `gorm:"index"`
+}
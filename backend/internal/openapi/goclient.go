@@ -0,0 +1,271 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ✅ Generate a typed Go client from the document
+//
+// The output declares one struct per component schema and one Client method
+// per operation. It relies on the hand-written helpers in the target package
+// (Client.do, Client.doForm, Client.doMultipart, Client.doRaw), see pkg/apiclient/client.go.
+func (d *Document) GoClient(pkg, generator string) ([]byte, error) {
+	var b bytes.Buffer
+
+	names := make([]string, 0, len(d.Components.Schemas))
+	for name := range d.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d.goStruct(&b, name, d.Components.Schemas[name])
+	}
+
+	type entry struct {
+		path, method string
+		op           *PathOp
+	}
+	var entries []entry
+	for path, byMethod := range d.Paths {
+		for method, op := range byMethod {
+			entries = append(entries, entry{path, method, op})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].op.OperationID < entries[j].op.OperationID })
+	for _, e := range entries {
+		if err := d.goMethod(&b, e.path, e.method, e.op); err != nil {
+			return nil, err
+		}
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by %s from the OpenAPI document. DO NOT EDIT.\n\n", generator)
+	fmt.Fprintf(&src, "package %s\n\nimport (\n", pkg)
+	for _, imp := range []string{"context", "io", "net/http", "net/url", "time"} {
+		if bytes.Contains(b.Bytes(), []byte(imp[strings.LastIndex(imp, "/")+1:]+".")) {
+			fmt.Fprintf(&src, "\t%q\n", imp)
+		}
+	}
+	src.WriteString(")\n\n")
+	src.Write(b.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("openapi: formatting generated client: %w", err)
+	}
+	return out, nil
+}
+
+func (d *Document) goStruct(b *bytes.Buffer, name string, s *Schema) {
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	props := make([]string, 0, len(s.Properties))
+	for prop := range s.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+	required := map[string]bool{}
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	for _, prop := range props {
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(b, "\t%s %s `json:%q`\n", goName(prop), d.goType(s.Properties[prop]), tag)
+	}
+	b.WriteString("}\n\n")
+}
+
+func (d *Document) goType(s *Schema) string {
+	if s.Ref != "" {
+		return s.RefName()
+	}
+	var t string
+	switch s.Type {
+	case "string":
+		t = "string"
+		if s.Format == "date-time" {
+			t = "time.Time"
+		}
+	case "integer":
+		t = "int64"
+	case "number":
+		t = "float64"
+	case "boolean":
+		t = "bool"
+	case "array":
+		t = "[]" + d.goType(s.Items) // Nullable: set vs. absent matters in merge-style bodies
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "map[string]" + d.goType(s.AdditionalProperties)
+		}
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+	if s.Nullable {
+		return "*" + t
+	}
+	return t
+}
+
+func (d *Document) goMethod(b *bytes.Buffer, path, method string, op *PathOp) error {
+	name := goName(op.OperationID)
+
+	// ✅ Success response: the lowest 2xx status
+	var result *Response
+	statuses := make([]int, 0, len(op.Responses))
+	for code := range op.Responses {
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 {
+			statuses = append(statuses, n)
+		}
+	}
+	sort.Ints(statuses)
+	if len(statuses) == 0 {
+		return fmt.Errorf("openapi: %s has no success response", op.OperationID)
+	}
+	result = op.Responses[strconv.Itoa(statuses[0])]
+
+	params := []string{"ctx context.Context"}
+	pathExpr := strconv.Quote(path)
+	query := ""
+	for _, p := range op.Parameters {
+		arg := goArg(p.Name)
+		params = append(params, arg+" string")
+		switch p.In {
+		case "path":
+			pathExpr = strings.Replace(pathExpr, "{"+p.Name+"}", `"+url.PathEscape(`+arg+`)+"`, 1)
+		case "query":
+			query += fmt.Sprintf("\tquery.Set(%q, %s)\n", p.Name, arg)
+		}
+	}
+	pathExpr = strings.TrimSuffix(strings.ReplaceAll(pathExpr, `+""`, ""), `+""`)
+
+	var multipartField string
+	var form bool
+	bodyArg := "nil"
+	if op.RequestBody != nil {
+		if mt, ok := op.RequestBody.Content["multipart/form-data"]; ok {
+			for field := range mt.Schema.Properties {
+				multipartField = field
+			}
+			params = append(params, "filename string", "file io.Reader")
+		} else if mt, ok := op.RequestBody.Content["application/x-www-form-urlencoded"]; ok {
+			params = append(params, "body "+d.goType(mt.Schema))
+			bodyArg, form = "body", true
+		} else {
+			params = append(params, "body "+d.goType(op.RequestBody.Content["application/json"].Schema))
+			bodyArg = "body"
+		}
+	}
+
+	fmt.Fprintf(b, "// %s calls %s %s", name, strings.ToUpper(method), path)
+	if op.Summary != "" {
+		fmt.Fprintf(b, ": %s", strings.TrimSuffix(op.Summary, "."))
+	}
+	b.WriteString("\n")
+	httpMethod := "http.Method" + strings.ToUpper(method[:1]) + method[1:]
+
+	var binary bool
+	var out string
+	for contentType, mt := range result.Content {
+		if contentType == "application/json" {
+			out = d.goType(mt.Schema)
+		} else {
+			binary = true
+		}
+	}
+
+	switch {
+	case binary:
+		fmt.Fprintf(b, "//\n// The caller must close the returned body.\nfunc (c *Client) %s(%s) (io.ReadCloser, error) {\n", name, strings.Join(params, ", "))
+	case out == "":
+		fmt.Fprintf(b, "func (c *Client) %s(%s) error {\n", name, strings.Join(params, ", "))
+	case !isNamed(out):
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(params, ", "), out)
+	default:
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (*%s, error) {\n", name, strings.Join(params, ", "), out)
+	}
+	b.WriteString("\tquery := url.Values{}\n" + query)
+	do := "do"
+	if form {
+		do = "doForm"
+	}
+
+	switch {
+	case binary:
+		fmt.Fprintf(b, "\treturn c.doRaw(ctx, %s, %s, query, %s)\n}\n\n", httpMethod, pathExpr, bodyArg)
+	case multipartField != "":
+		fmt.Fprintf(b, "\tvar out %s\n\tif err := c.doMultipart(ctx, %s, %s, query, %q, filename, file, &out); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n\n", out, httpMethod, pathExpr, multipartField)
+	case out == "":
+		fmt.Fprintf(b, "\treturn c.%s(ctx, %s, %s, query, %s, nil)\n}\n\n", do, httpMethod, pathExpr, bodyArg)
+	case !isNamed(out):
+		fmt.Fprintf(b, "\tvar out %s\n\terr := c.%s(ctx, %s, %s, query, %s, &out)\n\treturn out, err\n}\n\n", out, do, httpMethod, pathExpr, bodyArg)
+	default:
+		fmt.Fprintf(b, "\tvar out %s\n\tif err := c.%s(ctx, %s, %s, query, %s, &out); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n\n", out, do, httpMethod, pathExpr, bodyArg)
+	}
+	return nil
+}
+
+// ✅ Named (component) types are returned by pointer, maps and slices by value
+func isNamed(goType string) bool {
+	return goType != "" && unicode.IsUpper(rune(goType[0]))
+}
+
+// ✅ Initialisms kept upper-case in Go identifiers
+var initialisms = map[string]string{"id": "ID", "ip": "IP", "url": "URL", "api": "API", "json": "JSON", "uri": "URI"}
+
+// ✅ snake_case or camelCase -> exported Go identifier ("avatar_url" -> "AvatarURL")
+func goName(s string) string {
+	var words []string
+	start := 0
+	for i, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r): // "_", "-", "." and e.g. "#" in "x5t#S256"
+			words = append(words, s[start:i])
+			start = i + 1
+		case unicode.IsUpper(r) && i > start:
+			words = append(words, s[start:i])
+			start = i
+		}
+	}
+	words = append(words, s[start:])
+
+	var out strings.Builder
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		if up, ok := initialisms[strings.ToLower(w)]; ok {
+			out.WriteString(up)
+			continue
+		}
+		out.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return out.String()
+}
+
+// ✅ Parameter name -> unexported Go identifier ("org_id" -> "orgID")
+func goArg(s string) string {
+	name := goName(s)
+	for long, up := range initialisms {
+		if name == up {
+			return long
+		}
+	}
+	i := 0
+	for i < len(name) && unicode.IsUpper(rune(name[i])) {
+		i++
+	}
+	if i > 1 && i < len(name) {
+		i-- // Keep the capital that starts the next word ("URLPath" -> "urlPath")
+	}
+	return strings.ToLower(name[:i]) + name[i:]
+}
@@ -0,0 +1,306 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ✅ Auth is what a caller needs to reach an operation
+type Auth int
+
+const (
+	Public    Auth = iota
+	Session        // Valid access token (auth_token cookie or bearer header)
+	Sudo           // Session plus a recent re-authentication
+	OrgAdmin       // Session plus admin role in the :org_id organization
+	Client         // Service client credentials (OAuth endpoints)
+	AnyClient      // Client credentials, or only the client_id form field of a public client
+	Admin          // Sudo session of a support admin
+)
+
+// ✅ Multipart marks a multipart/form-data body carrying one file field
+type Multipart struct {
+	Field string
+}
+
+// ✅ Form marks an application/x-www-form-urlencoded body (OAuth endpoints)
+//
+// Body is the DTO it binds into; its json and form tags must agree.
+type Form struct {
+	Body interface{}
+}
+
+// ✅ Binary marks a non-JSON response body (downloads)
+type Binary struct {
+	ContentType string
+}
+
+// ✅ Operation describes one route: how to call it and what it returns
+//
+// Path is written the Gin way (":param"). Query, Body and every Responses value
+// are zero values of the Go types the handler binds and renders, so the schema
+// is derived from the same types the code uses.
+type Operation struct {
+	Method    string
+	Path      string
+	Handler   gin.HandlerFunc
+	ID        string
+	Summary   string
+	Tag       string
+	Auth      Auth
+	Query     interface{}
+	Body      interface{}
+	Responses map[int]interface{}
+}
+
+// ✅ Info is the document's title and version
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// ✅ Server is a base URL the paths are relative to
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// ✅ Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string                        `json:"openapi"`
+	Info       Info                          `json:"info"`
+	Servers    []Server                      `json:"servers,omitempty"`
+	Paths      map[string]map[string]*PathOp `json:"paths"`
+	Components Components                    `json:"components"`
+	Rules      map[string]func(*Schema)      `json:"-"` // Custom binding tags -> schema constraints
+	types      map[string]reflect.Type
+	ops        map[string]map[string]*Operation
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// ✅ PathOp is a rendered operation object
+type PathOp struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Security    []map[string][]string `json:"security"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// ✅ Names of the security schemes (the access token as a cookie or a bearer header, client credentials)
+const (
+	CookieAuth = "cookieAuth"
+	BearerAuth = "bearerAuth"
+	ClientAuth = "clientAuth"
+)
+
+// ✅ Either scheme authenticates a request
+var sessionSecurity = []map[string][]string{{CookieAuth: {}}, {BearerAuth: {}}}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// ✅ Convert a Gin path ("/orgs/:org_id") to an OpenAPI path ("/orgs/{org_id}")
+func PathOf(ginPath string) string {
+	return pathParam.ReplaceAllString(ginPath, "{$1}")
+}
+
+// ✅ Build a document from an operation table
+//
+// problem is the error body every operation may return (RFC 7807); rules map
+// custom binding tags to schema constraints.
+func New(info Info, problem interface{}, rules map[string]func(*Schema), ops []Operation) *Document {
+	d := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*PathOp{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				CookieAuth: {Type: "apiKey", In: "cookie", Name: "auth_token", Description: "Access token set by /login, /refresh and the magic link"},
+				BearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "The same access token in an Authorization header (API clients)"},
+				ClientAuth: {Type: "http", Scheme: "basic", Description: "Service client ID and secret (form-encoded, RFC 6749 §2.3.1); client_id / client_secret form fields also work. Clients registered for private_key_jwt send client_assertion / client_assertion_type fields instead, mTLS clients their certificate and a client_id field."},
+			},
+		},
+		Rules: rules,
+		types: map[string]reflect.Type{},
+		ops:   map[string]map[string]*Operation{},
+	}
+
+	problemSchema := d.schemaOf(reflect.TypeOf(problem), false)
+	for i := range ops {
+		op := &ops[i]
+		path := PathOf(op.Path)
+		if d.Paths[path] == nil {
+			d.Paths[path] = map[string]*PathOp{}
+			d.ops[path] = map[string]*Operation{}
+		}
+		method := strings.ToLower(op.Method)
+		if _, dup := d.Paths[path][method]; dup {
+			panic("openapi: duplicate operation " + op.Method + " " + op.Path)
+		}
+		d.Paths[path][method] = d.render(op, problemSchema)
+		d.ops[path][method] = op
+	}
+	return d
+}
+
+// ✅ Look up the source operation of a method and Gin path
+func (d *Document) Operation(method, ginPath string) (*Operation, bool) {
+	op, ok := d.ops[PathOf(ginPath)][strings.ToLower(method)]
+	return op, ok
+}
+
+// ✅ Every operation in the document, sorted by path then method
+func (d *Document) Operations() []*Operation {
+	var ops []*Operation
+	for _, byMethod := range d.ops {
+		for _, op := range byMethod {
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return ops
+}
+
+func (d *Document) render(op *Operation, problem *Schema) *PathOp {
+	out := &PathOp{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Responses:   map[string]*Response{},
+		Security:    []map[string][]string{},
+	}
+	if op.Tag != "" {
+		out.Tags = []string{op.Tag}
+	}
+	switch op.Auth {
+	case Session:
+		out.Security = sessionSecurity
+	case Sudo:
+		out.Security = sessionSecurity
+		out.Description = "Requires sudo mode: re-authenticate first if the last sign-in is too old."
+	case OrgAdmin:
+		out.Security = sessionSecurity
+		out.Description = "Requires the admin or owner role in the organization."
+	case Admin:
+		out.Security = sessionSecurity
+		out.Description = "Requires a support admin account in sudo mode."
+	case Client:
+		out.Security = []map[string][]string{{ClientAuth: {}}}
+	case AnyClient:
+		out.Security = []map[string][]string{{ClientAuth: {}}, {}}
+		out.Description = "Public clients (registered with auth method none) send only a client_id form field."
+	}
+
+	for _, name := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		s := &Schema{Type: "string"}
+		if strings.HasSuffix(name[1], "_id") {
+			s.Format = "uuid"
+		}
+		out.Parameters = append(out.Parameters, &Parameter{Name: name[1], In: "path", Required: true, Schema: s})
+	}
+	if op.Query != nil {
+		for _, f := range Fields(reflect.TypeOf(op.Query), "form") {
+			s := d.schemaOf(f.Type, true)
+			d.applyBinding(s, f.Binding)
+			out.Parameters = append(out.Parameters, &Parameter{Name: f.Name, In: "query", Required: hasRule(f.Binding, "required"), Schema: s})
+		}
+	}
+
+	switch body := op.Body.(type) {
+	case nil:
+	case Multipart:
+		out.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			"multipart/form-data": {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{body.Field: {Type: "string", Format: "binary"}},
+				Required:   []string{body.Field},
+			}},
+		}}
+	case Form:
+		out.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			"application/x-www-form-urlencoded": {Schema: d.schemaOf(reflect.TypeOf(body.Body), true)},
+		}}
+	default:
+		out.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			"application/json": {Schema: d.schemaOf(reflect.TypeOf(body), true)},
+		}}
+	}
+
+	for status, body := range op.Responses {
+		resp := &Response{Description: http.StatusText(status)}
+		switch body := body.(type) {
+		case nil:
+		case Binary:
+			resp.Content = map[string]*MediaType{body.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}}}
+		default:
+			resp.Content = map[string]*MediaType{"application/json": {Schema: d.schemaOf(reflect.TypeOf(body), false)}}
+		}
+		out.Responses[strconv.Itoa(status)] = resp
+	}
+	out.Responses["default"] = &Response{
+		Description: "Error (RFC 7807 problem details, see GET /errors for codes)",
+		Content:     map[string]*MediaType{"application/problem+json": {Schema: problem}},
+	}
+	return out
+}
+
+// ✅ Serve the document as JSON (rendered once)
+func Handler(d *Document) gin.HandlerFunc {
+	body, err := json.Marshal(d)
+	if err != nil {
+		panic("openapi: " + err.Error())
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
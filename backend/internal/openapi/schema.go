@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ✅ Schema is the subset of the OpenAPI 3.0 schema object we generate
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// ✅ Name of the component a $ref points at ("" for inline schemas)
+func (s *Schema) RefName() string {
+	return strings.TrimPrefix(s.Ref, componentPrefix)
+}
+
+const componentPrefix = "#/components/schemas/"
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// ✅ Field is one JSON (or query) field of a Go struct
+type Field struct {
+	Name     string
+	Type     reflect.Type
+	Binding  string // Raw `binding` tag
+	Optional bool   // omitempty
+}
+
+// ✅ List the serialized fields of a struct type, flattening embedded structs
+//
+// tag is "json" for bodies and "form" for query strings.
+func Fields(t reflect.Type, tag string) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, Fields(sf.Type, tag)...)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, Field{
+			Name:     name,
+			Type:     sf.Type,
+			Binding:  sf.Tag.Get("binding"),
+			Optional: strings.Contains(opts, "omitempty"),
+		})
+	}
+	return fields
+}
+
+// ✅ Schema of a Go type, registering named structs as components
+//
+// In request mode a field is required when its binding tag says so, in
+// response mode whenever it is not omitempty (it is always serialized).
+func (d *Document) schemaOf(t reflect.Type, request bool) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := d.schemaOf(t.Elem(), request)
+		if s.Ref != "" {
+			return s
+		}
+		s.Nullable = true
+		return s
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem(), request)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem(), request)}
+	case reflect.Struct:
+		return d.component(t, request)
+	default:
+		return &Schema{}
+	}
+}
+
+// ✅ Register a struct as a named component and return a reference to it
+func (d *Document) component(t reflect.Type, request bool) *Schema {
+	name := t.Name()
+	ref := &Schema{Ref: componentPrefix + name}
+	if name == "" {
+		return d.structSchema(t, request)
+	}
+	if seen, ok := d.types[name]; ok {
+		if seen != t {
+			panic("openapi: two types named " + name + ": " + seen.PkgPath() + " and " + t.PkgPath())
+		}
+		return ref
+	}
+	d.types[name] = t
+	d.Components.Schemas[name] = nil // Placeholder, so recursive types terminate
+	d.Components.Schemas[name] = d.structSchema(t, request)
+	return ref
+}
+
+func (d *Document) structSchema(t reflect.Type, request bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, f := range Fields(t, "json") {
+		prop := d.schemaOf(f.Type, request)
+		if prop.Ref == "" {
+			d.applyBinding(prop, f.Binding)
+		}
+		s.Properties[f.Name] = prop
+		if request && hasRule(f.Binding, "required") || !request && !f.Optional {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+	return s
+}
+
+// ✅ Translate binding rules into schema constraints
+func (d *Document) applyBinding(s *Schema, binding string) {
+	for _, rule := range strings.Split(binding, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "max", "min":
+			n, err := strconv.Atoi(arg)
+			if err != nil || s.Type != "string" {
+				continue
+			}
+			if name == "max" {
+				s.MaxLength = &n
+			} else {
+				s.MinLength = &n
+			}
+		case "email":
+			s.Format = "email"
+		default:
+			if apply, ok := d.Rules[name]; ok {
+				apply(s)
+			}
+		}
+	}
+}
+
+func hasRule(binding, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
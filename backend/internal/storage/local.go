@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ✅ Local Filesystem Storage, served by the API itself under MediaPath
+type LocalStorage struct {
+	Dir     string // Root directory (LOCAL_STORAGE_DIR, default ./uploads)
+	BaseURL string // Public URL of the media route (LOCAL_STORAGE_URL, default http://localhost:8080/media)
+}
+
+// ✅ Route prefix the API serves local objects from
+const MediaPath = "/media"
+
+// ✅ Create a Local Storage from LOCAL_STORAGE_DIR / LOCAL_STORAGE_URL
+func NewLocalStorage() *LocalStorage {
+	return &LocalStorage{
+		Dir:     getEnv("LOCAL_STORAGE_DIR", "./uploads"),
+		BaseURL: strings.TrimRight(getEnv("LOCAL_STORAGE_URL", "http://localhost:8080"+MediaPath), "/"),
+	}
+}
+
+// ✅ Resolve a key to a path inside Dir (rejects traversal)
+func (s *LocalStorage) Path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(clean)), nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	target, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.BaseURL + "/" + strings.TrimLeft(key, "/")
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePath(t *testing.T) {
+	s := &LocalStorage{Dir: "/srv/uploads"}
+
+	valid := map[string]string{
+		"avatars/u1/256.jpg":        "/srv/uploads/avatars/u1/256.jpg",
+		"/avatars/u1/256.jpg":       "/srv/uploads/avatars/u1/256.jpg",
+		"avatars/../u1/256.jpg":     "/srv/uploads/u1/256.jpg",
+		"../../etc/passwd":          "/srv/uploads/etc/passwd", // Cleaned against the root, never above Dir
+		"avatars/./u1//64.jpg":      "/srv/uploads/avatars/u1/64.jpg",
+		"avatars/u1/with space.jpg": "/srv/uploads/avatars/u1/with space.jpg",
+	}
+	for key, want := range valid {
+		got, err := s.Path(key)
+		if err != nil || got != filepath.FromSlash(want) {
+			t.Errorf("Path(%q) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+
+	for _, key := range []string{"", "/", "..", "../..", `avatars\..\..\secret`} {
+		if got, err := s.Path(key); err == nil {
+			t.Errorf("Path(%q) = %q, want an error", key, got)
+		}
+	}
+}
+
+func TestLocalStoragePutAndDelete(t *testing.T) {
+	s := &LocalStorage{Dir: t.TempDir(), BaseURL: "http://localhost:8080/media"}
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "avatars/u1/256.jpg", strings.NewReader("jpeg bytes"), 10, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	target := filepath.Join(s.Dir, "avatars", "u1", "256.jpg")
+	if data, err := os.ReadFile(target); err != nil || string(data) != "jpeg bytes" {
+		t.Fatalf("stored object = %q, %v", data, err)
+	}
+
+	// No temporary files are left next to the object
+	entries, _ := os.ReadDir(filepath.Dir(target))
+	if len(entries) != 1 {
+		t.Errorf("want only the object in its directory, got %d entries", len(entries))
+	}
+
+	if got, want := s.URL("/avatars/u1/256.jpg"), "http://localhost:8080/media/avatars/u1/256.jpg"; got != want {
+		t.Errorf("URL = %s, want %s", got, want)
+	}
+
+	if err := s.Delete(ctx, "avatars/u1/256.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("object still exists after Delete: %v", err)
+	}
+	if err := s.Delete(ctx, "avatars/u1/256.jpg"); err != nil {
+		t.Errorf("deleting a missing object: %v", err)
+	}
+	if err := s.Put(ctx, "/", strings.NewReader("x"), 1, "text/plain"); err == nil {
+		t.Error("Put accepted the root as a key")
+	}
+}
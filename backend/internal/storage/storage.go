@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// ✅ Storage keeps user-uploaded objects (avatars, ...) and knows how to link to them
+//
+// Keys are slash-separated paths such as "avatars/<user>/<version>/256.jpg".
+// Objects are never overwritten in place: callers write new keys and delete
+// the old ones, so serving URLs can be cached forever.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// ✅ Cache-Control sent with stored objects (keys are immutable)
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// ✅ Active Storage (STORAGE_BACKEND=local|s3, replaceable for tests)
+var active Storage = newFromEnv()
+
+// ✅ Replace the Active Storage
+func SetStorage(s Storage) {
+	active = s
+}
+
+// ✅ Get the Active Storage
+func Active() Storage {
+	return active
+}
+
+// ✅ Build the configured backend, falling back to local storage
+func newFromEnv() Storage {
+	switch strings.ToLower(os.Getenv("STORAGE_BACKEND")) {
+	case "", "local":
+		return NewLocalStorage()
+	case "s3":
+		s3, err := NewS3Storage()
+		if err != nil {
+			log.Println("❌ Invalid S3 storage configuration, falling back to local storage:", err)
+			return NewLocalStorage()
+		}
+		return s3
+	default:
+		log.Println("⚠️ WARNING: Unknown STORAGE_BACKEND, using local storage")
+		return NewLocalStorage()
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,26 @@
+package validation
+
+import (
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"github.com/thejpness/ArcadiaGo/internal/openapi"
+)
+
+// ✅ How each custom binding tag shows up in the OpenAPI document
+//
+// Password rules are configurable at runtime, so they are only described.
+func OpenAPIRules() map[string]func(*openapi.Schema) {
+	return map[string]func(*openapi.Schema){
+		"email_addr": func(s *openapi.Schema) { s.Format = "email" },
+		"username":   func(s *openapi.Schema) { s.Pattern = auth.UsernamePattern },
+		"password": func(s *openapi.Schema) {
+			s.Format = "password"
+			s.Description = "Must satisfy the server's password policy; violations are listed in the problem's errors"
+		},
+		"org_role": func(s *openapi.Schema) {
+			s.Enum = []string{models.OrgRoleOwner, models.OrgRoleAdmin, models.OrgRoleMember}
+		},
+		"org_slug":    func(s *openapi.Schema) { s.Pattern = OrgSlugPattern },
+		"single_line": func(s *openapi.Schema) { s.Pattern = SingleLinePattern },
+	}
+}
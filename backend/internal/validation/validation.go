@@ -0,0 +1,192 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/auth"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+)
+
+// ✅ Organization slugs (compared after trimming and lower-casing, as CreateOrganization stores them)
+const OrgSlugPattern = `^[a-z0-9][a-z0-9-]{2,47}$`
+
+var orgSlugRegex = regexp.MustCompile(OrgSlugPattern)
+
+// ✅ Names shown in emails and lists: no control characters (CR, LF, tab, ...)
+const SingleLinePattern = `^[^\p{Cc}]*$`
+
+// ✅ Custom binding tags, usable in `binding:"..."` on request DTOs
+var rules = map[string]func(string) bool{
+	"email_addr":  func(v string) bool { return auth.ValidateEmail(strings.TrimSpace(v)) == nil },
+	"username":    func(v string) bool { return auth.ValidateUsername(v) == nil },
+	"password":    func(v string) bool { return auth.ValidatePasswordSyntax(v) == nil },
+	"org_role":    func(v string) bool { return models.OrgRoleRank(v) > 0 },
+	"org_slug":    func(v string) bool { return orgSlugRegex.MatchString(strings.ToLower(strings.TrimSpace(v))) },
+	"single_line": func(v string) bool { return !strings.ContainsFunc(v, unicode.IsControl) },
+}
+
+// ✅ Validator for the `binding:"..."` tags of request DTOs
+//
+// It is ArcadiaGo's own, so embedding the server leaves Gin's global validator
+// and decoder settings alone.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	engine := validator.New()
+	engine.SetTagName("binding")
+
+	// Report fields by their JSON / form names
+	engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "uri"} {
+			if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	for tag, rule := range rules {
+		if err := engine.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return rule(fl.Field().String())
+		}); err != nil {
+			panic(err)
+		}
+	}
+	return engine
+}
+
+// ✅ Validate a decoded request DTO against its binding tags
+func Struct(req interface{}) error {
+	return validate.Struct(req)
+}
+
+// ✅ Decode a JSON body into req, rejecting unknown fields, and validate it
+func DecodeJSON(body io.Reader, req interface{}) error {
+	if body == nil {
+		return errors.New("missing request body")
+	}
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(req); err != nil {
+		return err
+	}
+	return Struct(req)
+}
+
+// ✅ Map form values into req by their `form` tags and validate it
+func DecodeForm(values url.Values, req interface{}) error {
+	if err := binding.MapFormWithTag(req, values, "form"); err != nil {
+		return err
+	}
+	return Struct(req)
+}
+
+// ✅ Turn a binding error into a problem
+//
+// Validation failures become request.validation_failed with one entry per
+// failed rule; malformed JSON and unknown fields become request.invalid_body.
+func Problem(err error) *apierror.Problem {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		problem := apierror.ValidationFailed.New()
+		for _, fe := range validationErrs {
+			problem.WithFields(fieldErrors(fe)...)
+		}
+		return problem
+	}
+
+	// encoding/json reports unknown fields as: json: unknown field "name"
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field = strings.Trim(field, `"`)
+		return apierror.InvalidBody.WithDetail("Unknown field " + field).
+			WithFields(apierror.FieldError{Field: field, Code: "unknown", Message: "unknown field"})
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return apierror.InvalidBody.New().
+			WithFields(apierror.FieldError{Field: typeErr.Field, Code: "type", Message: "must be a " + typeErr.Type.String()})
+	}
+
+	return apierror.InvalidBody.New()
+}
+
+// ✅ Describe one failed rule (password failures list every encoding and length violation)
+func fieldErrors(fe validator.FieldError) []apierror.FieldError {
+	field := fieldPath(fe)
+	value, _ := fe.Value().(string)
+
+	switch fe.Tag() {
+	case "password":
+		var policyErr *auth.PasswordPolicyError
+		if errors.As(auth.ValidatePasswordSyntax(value), &policyErr) {
+			out := make([]apierror.FieldError, 0, len(policyErr.Violations))
+			for _, v := range policyErr.Violations {
+				out = append(out, apierror.FieldError{Field: field, Code: v.Rule, Message: v.Message})
+			}
+			return out
+		}
+	case "username":
+		if err := auth.ValidateUsername(value); err != nil {
+			code := "format"
+			if errors.Is(err, auth.ErrUsernameReserved) {
+				code = "reserved"
+			}
+			return []apierror.FieldError{{Field: field, Code: code, Message: err.Error()}}
+		}
+	}
+
+	return []apierror.FieldError{{Field: field, Code: fe.Tag(), Message: message(fe)}}
+}
+
+// ✅ Field path without the top-level struct name ("RegisterRequest.email" -> "email")
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+// ✅ Human-readable message for a failed rule
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email_addr":
+		return "must be a valid email address"
+	case "org_role":
+		return "must be one of owner, admin, member"
+	case "org_slug":
+		return "must be 3-48 lowercase letters, numbers or dashes"
+	case "single_line":
+		return "must be a single line without control characters"
+	case "uuid":
+		return "must be a UUID"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return "is invalid"
+	}
+}
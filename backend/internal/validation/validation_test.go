@@ -0,0 +1,74 @@
+package validation
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+type testRequest struct {
+	Email string `json:"email" form:"email" binding:"required,email_addr"`
+	Role  string `json:"role,omitempty" form:"role" binding:"omitempty,org_role"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var req testRequest
+	if err := DecodeJSON(strings.NewReader(`{"email":"ada@example.com","role":"admin"}`), &req); err != nil {
+		t.Fatalf("DecodeJSON: %v", err)
+	}
+	if req.Email != "ada@example.com" || req.Role != "admin" {
+		t.Errorf("decoded %+v", req)
+	}
+
+	tests := map[string]struct {
+		body, code, field, rule string
+	}{
+		"unknown field": {`{"email":"ada@example.com","is_admin":true}`, "request.invalid_body", "is_admin", "unknown"},
+		"wrong type":    {`{"email":42}`, "request.invalid_body", "email", "type"},
+		"missing":       {`{}`, "request.validation_failed", "email", "required"},
+		"custom rule":   {`{"email":"ada@example.com","role":"god"}`, "request.validation_failed", "role", "org_role"},
+		"malformed":     {`{"email":`, "request.invalid_body", "", ""},
+	}
+	for name, tt := range tests {
+		err := DecodeJSON(strings.NewReader(tt.body), &testRequest{})
+		if err == nil {
+			t.Errorf("%s: DecodeJSON accepted %s", name, tt.body)
+			continue
+		}
+		problem := Problem(err)
+		if problem.Code != tt.code {
+			t.Errorf("%s: code %q, want %q", name, problem.Code, tt.code)
+		}
+		if tt.field != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != tt.field || problem.Errors[0].Code != tt.rule) {
+			t.Errorf("%s: field errors %+v, want %s/%s", name, problem.Errors, tt.field, tt.rule)
+		}
+	}
+
+	// Gin's own settings are left alone for the host application
+	if binding.EnableDecoderDisallowUnknownFields {
+		t.Error("Gin's global decoder was switched to disallow unknown fields")
+	}
+}
+
+func TestDecodeForm(t *testing.T) {
+	var req testRequest
+	if err := DecodeForm(url.Values{"email": {"ada@example.com"}, "extra": {"ignored"}}, &req); err != nil || req.Email != "ada@example.com" {
+		t.Fatalf("DecodeForm = %v, %+v", err, req)
+	}
+	if problem := Problem(DecodeForm(url.Values{}, &testRequest{})); problem.Code != "request.validation_failed" {
+		t.Errorf("missing email: code %q", problem.Code)
+	}
+}
+
+func TestSingleLine(t *testing.T) {
+	type named struct {
+		Name string `json:"name" binding:"required,single_line"`
+	}
+	for name, want := range map[string]bool{"Analytical Engines": true, "Café Ω": true, "Acme\r\nBcc: x@example.com": false, "Acme\n": false, "Ac\tme": false} {
+		if err := Struct(&named{Name: name}); (err == nil) != want {
+			t.Errorf("single_line(%q) = %v, want ok=%t", name, err, want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/env"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ Deliveries claimed per round
+const batchSize = 20
+
+// ✅ Wakes the dispatcher when a delivery is queued, so it does not wait for the next poll
+var wake = make(chan struct{}, 1)
+
+// ✅ Ask the dispatcher to look for due deliveries now
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// ✅ Per-attempt timeout (WEBHOOK_TIMEOUT, default 10s)
+func attemptTimeout() time.Duration {
+	return env.Duration("WEBHOOK_TIMEOUT", 10*time.Second)
+}
+
+// ✅ Attempts before a delivery is dead (WEBHOOK_MAX_ATTEMPTS, default 12: about 17 hours of retries)
+func MaxAttempts() int {
+	if n, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && n > 0 {
+		return n
+	}
+	return 12
+}
+
+// ✅ How long finished deliveries stay in the log (WEBHOOK_DELIVERY_RETENTION, default 30 days)
+func retention() time.Duration {
+	return env.Duration("WEBHOOK_DELIVERY_RETENTION", 30*24*time.Hour)
+}
+
+// ✅ Wait after a failed attempt: 30s doubling per attempt, at most 6h
+func RetryDelay(attempt int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempt && delay < 6*time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, 6*time.Hour)
+}
+
+// ✅ HTTP client for deliveries: redirects are not followed (a 3xx is a failed attempt)
+var httpClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// ✅ Start the delivery loop in the background (WEBHOOK_POLL_INTERVAL, default 10s)
+//
+// Several instances may run it: each claims its deliveries with SKIP LOCKED.
+// It stops once ctx is cancelled, after the attempt in flight; the returned
+// channel is closed when it has.
+func StartDispatcher(ctx context.Context) <-chan struct{} {
+	interval := env.Duration("WEBHOOK_POLL_INTERVAL", 10*time.Second)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			for ctx.Err() == nil {
+				sent, err := DeliverDue(ctx, time.Now())
+				if err != nil {
+					log.Println("❌ Failed to deliver webhooks:", err)
+				}
+				if err != nil || sent < batchSize {
+					break
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-wake:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	log.Printf("📬 Webhook dispatcher polling every %s (up to %d attempts per delivery)", interval, MaxAttempts())
+	return done
+}
+
+// ✅ Claim the deliveries due at now and attempt each once; returns how many were attempted
+//
+// Once ctx is cancelled no further attempt starts; the deliveries left over
+// are retried when their claim runs out.
+func DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	timeout := attemptTimeout()
+
+	// ✅ Claiming pushes NextAttemptAt past the attempt, so a crashed instance's claims come back
+	//
+	// Attempts run one after another, each within timeout, so every claim lasts
+	// until its own turn in the batch is over rather than the batch's start.
+	var due []models.WebhookDelivery
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookPending, now).
+			Where("endpoint_id IN (?)", tx.Model(&models.WebhookEndpoint{}).Select("id").Where("disabled_at IS NULL")).
+			Order("next_attempt_at").Limit(batchSize).
+			Find(&due).Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		for i, delivery := range due {
+			lease := now.Add(time.Duration(i+1)*timeout + time.Minute)
+			if err := tx.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Update("next_attempt_at", lease).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	endpoints := map[uuid.UUID]*models.WebhookEndpoint{}
+	for _, delivery := range due {
+		if _, ok := endpoints[delivery.EndpointID]; ok {
+			continue
+		}
+		var endpoint models.WebhookEndpoint
+		if err := database.DB.First(&endpoint, "id = ?", delivery.EndpointID).Error; err != nil {
+			return 0, err
+		}
+		endpoints[delivery.EndpointID] = &endpoint
+	}
+
+	attempted := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		attempt(&due[i], endpoints[due[i].EndpointID], timeout)
+		attempted++
+	}
+	return attempted, nil
+}
+
+// ✅ POST one delivery and record the outcome: delivered, retry later, or dead
+func attempt(delivery *models.WebhookDelivery, endpoint *models.WebhookEndpoint, timeout time.Duration) {
+	status, err := post(delivery, endpoint, timeout)
+
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"last_attempt_at": now,
+		"response_status": status,
+		"last_error":      "",
+	}
+	switch {
+	case err == nil:
+		updates["status"] = models.WebhookDelivered
+		updates["delivered_at"] = now
+	case attempts >= MaxAttempts():
+		updates["status"] = models.WebhookDead
+		updates["last_error"] = err.Error()
+		log.Printf("❌ Webhook delivery %s to %s is dead after %d attempts: %v", delivery.ID, endpoint.URL, attempts, err)
+	default:
+		updates["next_attempt_at"] = now.Add(RetryDelay(attempts))
+		updates["last_error"] = err.Error()
+		log.Printf("⚠️ Webhook delivery %s to %s failed (attempt %d): %v", delivery.ID, endpoint.URL, attempts, err)
+	}
+
+	if err := database.DB.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		log.Println("❌ Failed to record webhook attempt:", delivery.ID, err)
+	}
+}
+
+// ✅ Send the signed request; any answer but 2xx is an error (the status is returned when there was one)
+func post(delivery *models.WebhookDelivery, endpoint *models.WebhookEndpoint, timeout time.Duration) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ArcadiaGo-Webhooks/1")
+	req.Header.Set(IDHeader, delivery.EventID.String())
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, time.Now(), body))
+
+	client := *httpClient
+	client.Timeout = timeout
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr interface{ Timeout() bool }
+		if errors.As(err, &urlErr) && urlErr.Timeout() {
+			return 0, fmt.Errorf("no response within %s", timeout)
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // Lets the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.New("endpoint answered " + resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// ✅ Send a delivery again from scratch (it may have been delivered or be dead)
+func Redeliver(endpointID, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	result := database.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND endpoint_id = ?", deliveryID, endpointID).
+		Updates(map[string]interface{}{
+			"status":          models.WebhookPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"delivered_at":    nil,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var delivery models.WebhookDelivery
+	if err := database.DB.First(&delivery, "id = ?", deliveryID).Error; err != nil {
+		return nil, err
+	}
+	Wake()
+	return &delivery, nil
+}
+
+// ✅ Remove finished deliveries older than the retention period; returns how many went
+func Prune(now time.Time) (int, error) {
+	result := database.DB.Where("status <> ? AND created_at < ?", models.WebhookPending, now.Add(-retention())).
+		Delete(&models.WebhookDelivery{})
+	return int(result.RowsAffected), result.Error
+}
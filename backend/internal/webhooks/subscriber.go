@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/thejpness/ArcadiaGo/internal/events"
+)
+
+// ✅ Subscribe the "webhooks" subscriber, which turns domain events into deliveries
+//
+// Deliveries are only queued from here, off the outbox: the webhook event ID
+// is the domain event ID, so a redelivered domain event queues nothing new.
+func Subscribe() {
+	events.On("webhooks", func(_ context.Context, d events.Delivery, e events.UserRegistered) error {
+		return enqueue(d.EventID, UserRegistered, d.OccurredAt, userData(e.UserID, e.Email, e.Username))
+	})
+	events.On("webhooks", func(_ context.Context, d events.Delivery, e events.EmailVerified) error {
+		return enqueue(d.EventID, UserEmailVerified, d.OccurredAt, userData(e.UserID, e.Email, e.Username))
+	})
+	events.On("webhooks", func(_ context.Context, d events.Delivery, e events.EmailChanged) error {
+		data := userData(e.UserID, e.NewEmail, e.Username)
+		data["old_email"] = e.OldEmail
+		return enqueue(d.EventID, UserEmailChanged, d.OccurredAt, data)
+	})
+	events.On("webhooks", func(_ context.Context, d events.Delivery, e events.UserDeleted) error {
+		data := userData(e.UserID, e.Email, e.Username)
+		data["purge_after"] = e.PurgeAfter
+		return enqueue(d.EventID, UserDeleted, d.OccurredAt, data)
+	})
+	events.On("webhooks", func(_ context.Context, d events.Delivery, e events.UserRestored) error {
+		return enqueue(d.EventID, UserRestored, d.OccurredAt, userData(e.UserID, e.Email, e.Username))
+	})
+	events.On("webhooks", func(_ context.Context, d events.Delivery, e events.UserPurged) error {
+		return enqueue(d.EventID, UserPurged, d.OccurredAt, map[string]interface{}{"user_id": e.UserID})
+	})
+	events.On("webhooks", func(_ context.Context, d events.Delivery, e events.SessionRevoked) error {
+		return enqueue(d.EventID, SessionRevoked, d.OccurredAt, map[string]interface{}{"user_id": e.UserID, "session_id": e.SessionID, "reason": e.Reason})
+	})
+}
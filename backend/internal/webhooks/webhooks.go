@@ -0,0 +1,139 @@
+// Package webhooks tells other systems about user lifecycle events.
+//
+// It subscribes to the domain event bus (see Subscribe): each event stores one
+// delivery per subscribed endpoint, and the dispatcher (StartDispatcher) POSTs them:
+//
+//	POST <endpoint URL>
+//	Content-Type: application/json
+//	Arcadia-Webhook-Id: <event ID, the same on every retry>
+//	Arcadia-Webhook-Event: user.registered
+//	Arcadia-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+//
+//	{"id": "…", "type": "user.registered", "created_at": "…", "data": {"user_id": "…", …}}
+//
+// Receivers verify the signature with the endpoint's secret (see
+// arcadia.VerifyWebhook), reject stale timestamps and deduplicate on the ID.
+// Failed attempts are retried with exponential backoff until
+// WEBHOOK_MAX_ATTEMPTS, then the delivery is dead until redelivered.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"gorm.io/gorm/clause"
+)
+
+// ✅ Event types endpoints can subscribe to
+const (
+	UserRegistered    = "user.registered"
+	UserEmailVerified = "user.email_verified"
+	UserEmailChanged  = "user.email_changed"
+	UserDeleted       = "user.deleted"  // Soft delete: restorable until the purge
+	UserRestored      = "user.restored" // A soft-deleted account came back
+	UserPurged        = "user.purged"   // Erased for good (data has only user_id)
+	SessionRevoked    = "session.revoked"
+)
+
+// ✅ Every event type, in catalogue order
+var Events = []string{UserRegistered, UserEmailVerified, UserEmailChanged, UserDeleted, UserRestored, UserPurged, SessionRevoked}
+
+// ✅ Headers sent with every delivery
+const (
+	IDHeader        = "Arcadia-Webhook-Id"
+	EventHeader     = "Arcadia-Webhook-Event"
+	SignatureHeader = "Arcadia-Signature"
+)
+
+// ✅ Event is the JSON body of a delivery
+type Event struct {
+	ID        uuid.UUID              `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// ✅ Event data describing a user
+func userData(userID uuid.UUID, email, username string) map[string]interface{} {
+	return map[string]interface{}{"user_id": userID, "email": email, "username": username}
+}
+
+// ✅ Queue an event for every enabled endpoint subscribed to it
+//
+// Only the "webhooks" subscriber calls it, for domain events published in the
+// transaction of their change (events.Publish): an event is queued exactly
+// when its change committed, and an error fails that subscriber's delivery,
+// which the event bus retries. The event ID identifies the event to
+// receivers; queueing the same ID again adds nothing for endpoints that
+// already have it.
+func enqueue(eventID uuid.UUID, event string, at time.Time, data map[string]interface{}) error {
+	var endpoints []models.WebhookEndpoint
+	if err := database.DB.Where("disabled_at IS NULL").Find(&endpoints).Error; err != nil {
+		return err
+	}
+
+	payload := Event{ID: eventID, Type: event, CreatedAt: at.UTC(), Data: data}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, endpoint := range endpoints {
+		if !Subscribed(endpoint.Events, event) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:            uuid.New(),
+			EndpointID:    endpoint.ID,
+			EventID:       eventID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        models.WebhookPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error; err != nil {
+		return err
+	}
+	Wake()
+	return nil
+}
+
+// ✅ Whether an endpoint's event filter (space-separated, empty = all) includes the event
+func Subscribed(filter, event string) bool {
+	events := strings.Fields(filter)
+	return len(events) == 0 || slices.Contains(events, event)
+}
+
+// ✅ Signature header value for a body sent at the given time
+func Sign(secret string, at time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", at.Unix())
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", at.Unix(), hex.EncodeToString(mac.Sum(nil)))
+}
+
+// ✅ New random endpoint secret ("whsec_" + 256 bits, base64url)
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,18 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
+	_ "time/tzdata" // Profile time zones are validated even on hosts without zoneinfo
 
-	"github.com/didip/tollbooth/v7"
-	"github.com/didip/tollbooth/v7/limiter"
-	"github.com/didip/tollbooth_gin"
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/thejpness/ArcadiaGo/internal/database"
-	"github.com/thejpness/ArcadiaGo/internal/handlers"
-	"github.com/thejpness/ArcadiaGo/internal/middleware"
+	"github.com/thejpness/ArcadiaGo/pkg/server"
 )
 
 func main() {
@@ -21,87 +20,30 @@ func main() {
 		log.Println("⚠️ Warning: No .env file found, using system environment variables")
 	}
 
-	// Initialize and Migrate Database
+	// Initialize Database (migrated by the server)
 	database.InitDB()
-	database.Migrate()
 
 	if database.DB == nil {
 		log.Fatal("❌ Failed to connect to the database")
 	}
 
-	// Get server port from environment variables
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	// Create a new Gin router
-	r := gin.New()
-
-	// Middleware Stack
-	r.Use(gin.Logger())            // Logs all requests
-	r.Use(gin.Recovery())          // Prevents crashes from panics
-	r.Use(middleware.CORSConfig()) // Enables CORS
-	r.Use(setupSecurityHeaders())  // Adds security headers
-	r.Use(setupRateLimiter())      // Enables Rate Limiting
-
-	// Default Root Route
-	r.GET("/", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "API is running"})
-	})
-
-	// ✅ Public API Routes (No Authentication Required)
-	publicRoutes := r.Group("/")
-	{
-		publicRoutes.POST("/register", handlers.RegisterUser)
-		publicRoutes.POST("/login", handlers.LoginUser)
-		publicRoutes.POST("/logout", handlers.LogoutUser)
-		publicRoutes.GET("/confirm-email", handlers.ConfirmEmailVerification) // Fixed function name
-	} // ✅ Closing bracket was missing
-
-	// ✅ Protected Routes (Require Authentication)
-	authenticated := r.Group("/")
-	authenticated.Use(middleware.AuthMiddleware()) // Secure all endpoints below
-	{
-		// User Profile
-		authenticated.GET("/user", handlers.GetUserProfile)
-		authenticated.POST("/refresh", handlers.RefreshToken)
-
-		// User Management
-		authenticated.POST("/update-email", handlers.RequestEmailChange) // Request email change
-		authenticated.POST("/update-password", handlers.UpdatePassword)  // Change password
-		authenticated.POST("/update-username", handlers.UpdateUsername)  // Change username
+	// Routes, middleware, migrations, the job runner, the webhook and event dispatchers
+	srv := server.New(server.ConfigFromEnv(), server.Deps{DB: database.DB})
 
-		// Account Management
-		authenticated.POST("/delete-account", handlers.SoftDeleteUser) // Soft delete account
-		authenticated.POST("/restore-account", handlers.RestoreUser)   // Restore deleted account
+	go func() {
+		if err := srv.Start(); err != nil {
+			log.Fatalf("❌ Server failed: %v", err)
+		}
+	}()
 
-		// Session Management
-		authenticated.GET("/active-sessions", handlers.GetActiveSessions) // List active sessions
-		authenticated.POST("/logout-session", handlers.LogoutSession)     // Logout from a specific session
-	}
-
-	// Start the server
-	log.Printf("🚀 Server running on port %s", port)
-	log.Fatal(r.Run(":" + port))
-}
-
-// ✅ Rate Limiting (10 requests per minute per IP)
-func setupRateLimiter() gin.HandlerFunc {
-	lmt := tollbooth.NewLimiter(10, &limiter.ExpirableOptions{
-		DefaultExpirationTTL: time.Minute, // Set rate limit expiration
-	})
-	lmt.SetIPLookups([]string{"RemoteAddr", "X-Forwarded-For", "X-Real-IP"}) // Track request IP
-
-	return tollbooth_gin.LimitHandler(lmt)
-}
+	// Finish in-flight requests on Ctrl+C / SIGTERM
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
 
-// ✅ Security Headers Middleware
-func setupSecurityHeaders() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("X-Frame-Options", "DENY")
-		c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
-		c.Writer.Header().Set("Content-Security-Policy", "default-src 'self'")
-		c.Next()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Println("❌ Graceful shutdown failed:", err)
 	}
 }
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thejpness/ArcadiaGo/internal/handlers"
+	"github.com/thejpness/ArcadiaGo/internal/openapi"
+	"github.com/thejpness/ArcadiaGo/internal/storage"
+	"github.com/thejpness/ArcadiaGo/pkg/server"
+)
+
+// Contract tests: the router, the handlers and the OpenAPI document must agree.
+
+const modulePath = "github.com/thejpness/ArcadiaGo/"
+
+// Packages whose handlers are checked against the operation table
+var handlerPackages = []string{"internal/handlers", "internal/apierror"}
+
+// Handlers that write a pre-rendered body instead of calling c.JSON
+var opaqueHandlers = map[string]bool{"OpenAPISpec": true}
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc := handlers.OpenAPIDocument()
+
+	routed := map[string]bool{}
+	for _, route := range server.New(server.Config{}, server.Deps{}).Routes() {
+		if strings.HasPrefix(route.Path, storage.MediaPath+"/") {
+			continue // Static files, only mounted for local storage
+		}
+		key := route.Method + " " + route.Path
+		routed[key] = true
+
+		op, ok := doc.Operation(route.Method, route.Path)
+		if !ok {
+			t.Errorf("%s is routed but missing from handlers.Operations", key)
+			continue
+		}
+		if name := funcName(op.Handler); name != route.Handler {
+			t.Errorf("%s is routed to %s but documented as %s", key, route.Handler, name)
+		}
+	}
+
+	for _, op := range doc.Operations() {
+		if key := op.Method + " " + op.Path; !routed[key] {
+			t.Errorf("%s is documented but not routed", key)
+		}
+	}
+}
+
+func TestHandlersMatchOpenAPI(t *testing.T) {
+	funcs := parseHandlerFuncs(t)
+
+	for _, op := range handlers.Operations() {
+		full := funcName(op.Handler)
+		short := full[strings.LastIndex(full, ".")+1:]
+		decl, ok := funcs[full]
+		if !ok {
+			t.Errorf("%s %s: source of %s not found", op.Method, op.Path, full)
+			continue
+		}
+		if opaqueHandlers[short] {
+			continue
+		}
+
+		got := analyzeHandler(funcs, decl, pkgOf(full))
+		for _, err := range got.errors {
+			t.Errorf("%s %s (%s): %s", op.Method, op.Path, short, err)
+		}
+		where := fmt.Sprintf("%s %s (%s)", op.Method, op.Path, short)
+
+		// Request body
+		switch body := op.Body.(type) {
+		case nil:
+			if got.body != "" || got.rawBody || got.formFile != "" {
+				t.Errorf("%s: reads a request body the spec doesn't declare", where)
+			}
+		case openapi.Multipart:
+			if got.formFile != body.Field {
+				t.Errorf("%s: spec declares multipart field %q, handler reads %q", where, body.Field, got.formFile)
+			}
+		case openapi.Form:
+			if want := reflect.TypeOf(body.Body).Name(); got.body != want || !got.form {
+				t.Errorf("%s: spec declares form body %s, handler binds %q (form: %t)", where, want, got.body, got.form)
+			}
+		default:
+			want := reflect.TypeOf(body).Name()
+			if got.form {
+				t.Errorf("%s: spec declares a JSON body, handler binds a form", where)
+			}
+			if got.body != want && !(got.body == "" && got.rawBody) {
+				t.Errorf("%s: spec declares body %s, handler binds %q", where, want, got.body)
+			}
+		}
+
+		// Query string
+		want := ""
+		if op.Query != nil {
+			want = reflect.TypeOf(op.Query).Name()
+		}
+		if got.query != want {
+			t.Errorf("%s: spec declares query %q, handler binds %q", where, want, got.query)
+		}
+
+		// Success responses
+		declared := map[string]bool{}
+		for status, body := range op.Responses {
+			declared[responseKey(status, body)] = true
+		}
+		for key := range got.responses {
+			if !declared[key] {
+				t.Errorf("%s: handler responds %s, not in the spec", where, key)
+			}
+		}
+		for key := range declared {
+			if !got.responses[key] {
+				t.Errorf("%s: spec declares %s, handler never responds with it", where, key)
+			}
+		}
+	}
+}
+
+func TestOpenAPIDocumentIsConsistent(t *testing.T) {
+	doc := handlers.OpenAPIDocument()
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every $ref resolves
+	var refs []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				if ref, ok := child.(string); ok && k == "$ref" {
+					refs = append(refs, ref)
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		t.Fatal(err)
+	}
+	walk(generic)
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("unresolved $ref %s", ref)
+		}
+	}
+
+	// Operation IDs are unique
+	seen := map[string]string{}
+	for path, byMethod := range doc.Paths {
+		for method, op := range byMethod {
+			where := strings.ToUpper(method) + " " + path
+			if op.OperationID == "" {
+				t.Errorf("%s has no operationId", where)
+			} else if other, dup := seen[op.OperationID]; dup {
+				t.Errorf("operationId %s used by %s and %s", op.OperationID, other, where)
+			}
+			seen[op.OperationID] = where
+		}
+	}
+}
+
+func TestGeneratedClientIsUpToDate(t *testing.T) {
+	want, err := handlers.OpenAPIDocument().GoClient("apiclient", "apiclientgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join("pkg", "apiclient", "client_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("pkg/apiclient/client_gen.go is stale, run: go generate ./pkg/apiclient")
+	}
+}
+
+func funcName(fn gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
+func pkgOf(fullName string) string {
+	return fullName[:strings.LastIndex(fullName, ".")]
+}
+
+func responseKey(status int, body interface{}) string {
+	switch body := body.(type) {
+	case nil:
+		return fmt.Sprintf("%d (empty)", status)
+	case openapi.Binary:
+		return fmt.Sprintf("%d (binary)", status)
+	default:
+		return fmt.Sprintf("%d %s", status, reflect.TypeOf(body).Name())
+	}
+}
+
+// Top-level functions of the handler packages, keyed by "<import path>.<name>"
+func parseHandlerFuncs(t *testing.T) map[string]*ast.FuncDecl {
+	t.Helper()
+	funcs := map[string]*ast.FuncDecl{}
+	fset := token.NewFileSet()
+	for _, dir := range handlerPackages {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range files {
+			if strings.HasSuffix(path, "_test.go") {
+				continue
+			}
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range file.Decls {
+				if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil {
+					funcs[modulePath+dir+"."+fn.Name.Name] = fn
+				}
+			}
+		}
+	}
+	return funcs
+}
+
+// What a handler binds and renders, found by walking its body and the
+// same-package functions it passes the gin context to
+type handlerShape struct {
+	body, query string
+	rawBody     bool   // Reads the body without a DTO (e.g. merge patch)
+	form        bool   // Body is bound from a urlencoded form
+	formFile    string // Multipart file field
+	responses   map[string]bool
+	errors      []string
+}
+
+var statusCodes = map[string]int{
+	"StatusOK":               http.StatusOK,
+	"StatusCreated":          http.StatusCreated,
+	"StatusAccepted":         http.StatusAccepted,
+	"StatusNoContent":        http.StatusNoContent,
+	"StatusMovedPermanently": http.StatusMovedPermanently,
+	"StatusFound":            http.StatusFound,
+	"StatusSeeOther":         http.StatusSeeOther,
+}
+
+func analyzeHandler(funcs map[string]*ast.FuncDecl, decl *ast.FuncDecl, pkg string) *handlerShape {
+	shape := &handlerShape{responses: map[string]bool{}}
+	visited := map[*ast.FuncDecl]bool{}
+
+	var visit func(fn *ast.FuncDecl)
+	visit = func(fn *ast.FuncDecl) {
+		if visited[fn] || fn.Body == nil {
+			return
+		}
+		visited[fn] = true
+
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			switch callee := call.Fun.(type) {
+			case *ast.Ident:
+				switch callee.Name {
+				case "bindJSON", "bindForm", "bindQuery":
+					name := varType(fn, call.Args[1])
+					if name == "" {
+						shape.errors = append(shape.errors, "cannot resolve the type bound by "+callee.Name)
+					}
+					switch callee.Name {
+					case "bindJSON":
+						shape.body = name
+					case "bindForm":
+						shape.body, shape.form = name, true
+					default:
+						shape.query = name
+					}
+					return false
+				}
+				if next, ok := funcs[pkg+"."+callee.Name]; ok && passesContext(call) {
+					visit(next)
+				}
+			case *ast.SelectorExpr:
+				if recv, ok := callee.X.(*ast.Ident); !ok || recv.Name != "c" {
+					return true
+				}
+				switch callee.Sel.Name {
+				case "ShouldBindJSON", "BindJSON":
+					shape.rawBody = true
+				case "FormFile":
+					if lit, ok := call.Args[0].(*ast.BasicLit); ok {
+						shape.formFile = strings.Trim(lit.Value, `"`)
+					}
+				case "JSON":
+					status, ok := statusOf(call.Args[0])
+					typ := exprType(funcs, pkg, fn, call.Args[1])
+					if !ok || typ == "" {
+						shape.errors = append(shape.errors, "cannot resolve c.JSON status or type (use http.StatusX and a response DTO)")
+						return true
+					}
+					shape.responses[fmt.Sprintf("%d %s", status, typ)] = true
+				case "Status":
+					if status, ok := statusOf(call.Args[0]); ok {
+						shape.responses[fmt.Sprintf("%d (binary)", status)] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	visit(decl)
+	return shape
+}
+
+func passesContext(call *ast.CallExpr) bool {
+	for _, arg := range call.Args {
+		if id, ok := arg.(*ast.Ident); ok && id.Name == "c" {
+			return true
+		}
+	}
+	return false
+}
+
+func statusOf(expr ast.Expr) (int, bool) {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return 0, false
+	}
+	status, ok := statusCodes[sel.Sel.Name]
+	return status, ok
+}
+
+// Unqualified name of a type expression ("models.User" -> "User")
+func typeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.StarExpr:
+		return typeName(e.X)
+	}
+	return ""
+}
+
+// Declared type of the variable behind "&x" or "x"
+func varType(fn *ast.FuncDecl, expr ast.Expr) string {
+	if u, ok := expr.(*ast.UnaryExpr); ok {
+		expr = u.X
+	}
+	id, ok := expr.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	var found string
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				if name.Name == id.Name && n.Type != nil {
+					found = typeName(n.Type)
+				}
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if l, ok := lhs.(*ast.Ident); ok && l.Name == id.Name && n.Tok == token.DEFINE && len(n.Rhs) == len(n.Lhs) {
+					if lit, ok := n.Rhs[i].(*ast.CompositeLit); ok {
+						found = typeName(lit.Type)
+					}
+				}
+			}
+		}
+		return found == ""
+	})
+	return found
+}
+
+// Type of a rendered value: composite literal, constructor call or variable
+func exprType(funcs map[string]*ast.FuncDecl, pkg string, fn *ast.FuncDecl, expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		return typeName(e.Type)
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok {
+			if decl, ok := funcs[pkg+"."+id.Name]; ok && decl.Type.Results != nil && len(decl.Type.Results.List) == 1 {
+				return typeName(decl.Type.Results.List[0].Type)
+			}
+		}
+	case *ast.Ident:
+		if name := varType(fn, e); name != "" {
+			return name
+		}
+		if call := assignedCall(fn, e); call != nil {
+			return exprType(funcs, pkg, fn, call)
+		}
+		return paramType(fn, e)
+	}
+	return ""
+}
+
+// Declared type of a parameter of fn ("func respond(c *gin.Context, resp T)")
+func paramType(fn *ast.FuncDecl, id *ast.Ident) string {
+	for _, field := range fn.Type.Params.List {
+		for _, name := range field.Names {
+			if name.Name == id.Name {
+				return typeName(field.Type)
+			}
+		}
+	}
+	return ""
+}
+
+// Call whose single result initializes the variable ("x := f(...)")
+func assignedCall(fn *ast.FuncDecl, id *ast.Ident) *ast.CallExpr {
+	var found *ast.CallExpr
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok && assign.Tok == token.DEFINE && len(assign.Lhs) == 1 && len(assign.Rhs) == 1 {
+			if l, ok := assign.Lhs[0].(*ast.Ident); ok && l.Name == id.Name {
+				found, _ = assign.Rhs[0].(*ast.CallExpr)
+			}
+		}
+		return found == nil
+	})
+	return found
+}
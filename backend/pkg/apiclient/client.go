@@ -0,0 +1,175 @@
+// Package apiclient is a typed Go client for the ArcadiaGo API.
+//
+// Types and methods live in client_gen.go, generated from the OpenAPI
+// document; this file holds the transport. Authentication uses the same
+// cookies as the browser, kept in the client's cookie jar:
+//
+//	c, _ := apiclient.New("https://auth.example.com")
+//	_, err := c.Login(ctx, apiclient.LoginRequest{Email: email, Password: password})
+//	profile, err := c.GetProfile(ctx)
+//
+// Failed calls return a *Problem (RFC 7807) when the server sent one.
+package apiclient
+
+//go:generate go run ../../cmd/apiclientgen -out client_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+)
+
+// ✅ Client calls one ArcadiaGo server
+type Client struct {
+	BaseURL string       // e.g. "https://auth.example.com", without a trailing slash
+	HTTP    *http.Client // Must have a cookie jar for authenticated calls
+}
+
+// ✅ New client with its own cookie jar
+func New(baseURL string) (*Client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTP: &http.Client{Jar: jar}}, nil
+}
+
+// ✅ Problem is returned as the error of a failed call
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%s (%d): %s", p.Code, p.Status, p.Detail)
+	}
+	return fmt.Sprintf("%s (%d): %s", p.Code, p.Status, p.Title)
+}
+
+// ✅ Send a JSON request and decode the JSON response into out (if non-nil)
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	resp, err := c.send(ctx, method, path, query, reader, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ✅ Send a form-encoded request (OAuth endpoints) and decode the JSON response into out
+func (c *Client) doForm(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	// Generated request types carry json tags only; reuse them as form field names
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return err
+	}
+	form := url.Values{}
+	for name, value := range fields {
+		form.Set(name, fmt.Sprint(value))
+	}
+
+	resp, err := c.send(ctx, method, path, query, strings.NewReader(form.Encode()), "application/x-www-form-urlencoded")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ✅ Upload one file as multipart/form-data
+func (c *Client) doMultipart(ctx context.Context, method, path string, query url.Values, field, filename string, file io.Reader, out interface{}) error {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile(field, filename)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	resp, err := c.send(ctx, method, path, query, &buf, form.FormDataContentType())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ✅ Send a request and hand back the raw response body (downloads)
+func (c *Client) doRaw(ctx context.Context, method, path string, query url.Values, body interface{}) (io.ReadCloser, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	resp, err := c.send(ctx, method, path, query, reader, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ✅ Send a request, turning error statuses into a *Problem
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json, application/problem+json")
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 400 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	problem := &Problem{Status: int64(resp.StatusCode), Title: resp.Status}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(problem); err != nil {
+		problem.Code = "http." + fmt.Sprint(resp.StatusCode)
+	}
+	return nil, problem
+}
@@ -0,0 +1,1131 @@
+// Code generated by apiclientgen from the OpenAPI document. DO NOT EDIT.
+
+package apiclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type AcceptInvitationRequest struct {
+	Password string `json:"password"`
+	Token    string `json:"token"`
+	Username string `json:"username,omitempty"`
+}
+
+type AccountDeletedResponse struct {
+	Message       string    `json:"message"`
+	RestoreBefore time.Time `json:"restore_before"`
+}
+
+type Actor struct {
+	Sub string `json:"sub"`
+}
+
+type AddOrgMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role,omitempty"`
+}
+
+type AvatarResponse struct {
+	AvatarURL string            `json:"avatar_url"`
+	Sizes     map[string]string `json:"sizes"`
+}
+
+type CatalogueResponse struct {
+	Codes []Code `json:"codes"`
+}
+
+type Code struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Status      int64  `json:"status"`
+	Title       string `json:"title"`
+}
+
+type Confirmation struct {
+	X5tS256 string `json:"x5t#S256"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role,omitempty"`
+}
+
+type CreateOrganizationRequest struct {
+	Name            string `json:"name"`
+	ScopedUsernames bool   `json:"scoped_usernames,omitempty"`
+	Slug            string `json:"slug"`
+}
+
+type CreateWebhookRequest struct {
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events,omitempty"`
+	URL         string   `json:"url"`
+}
+
+type DeviceApprovalRequest struct {
+	Approve  bool   `json:"approve,omitempty"`
+	UserCode string `json:"user_code"`
+}
+
+type DeviceAuthorizationInfo struct {
+	ClientName string    `json:"client_name"`
+	CreatedAt  time.Time `json:"created_at"`
+	DeviceName string    `json:"device_name"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	IPAddress  string    `json:"ip_address"`
+	UserCode   string    `json:"user_code"`
+}
+
+type DeviceCodeRequest struct {
+	ClientID   string `json:"client_id,omitempty"`
+	DeviceName string `json:"device_name,omitempty"`
+}
+
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+}
+
+type DeviceListResponse struct {
+	Devices []TrustedDevice `json:"devices"`
+}
+
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email"`
+}
+
+type EventStatsResponse struct {
+	Dead     map[string]int64 `json:"dead"`
+	Failures map[string]int64 `json:"failures"`
+	Handled  map[string]int64 `json:"handled"`
+	Pending  map[string]int64 `json:"pending"`
+}
+
+type FieldError struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ImpersonationResponse struct {
+	AccessToken     string    `json:"access_token"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	ExpiresIn       int64     `json:"expires_in"`
+	ImpersonationID string    `json:"impersonation_id"`
+	TokenType       string    `json:"token_type"`
+	UserID          string    `json:"user_id"`
+}
+
+type IntrospectionResponse struct {
+	Act       Actor        `json:"act,omitempty"`
+	Active    bool         `json:"active"`
+	AuthTime  int64        `json:"auth_time,omitempty"`
+	ClientID  string       `json:"client_id,omitempty"`
+	Cnf       Confirmation `json:"cnf,omitempty"`
+	Exp       int64        `json:"exp,omitempty"`
+	Iat       int64        `json:"iat,omitempty"`
+	OrgID     string       `json:"org_id,omitempty"`
+	Scope     string       `json:"scope,omitempty"`
+	Sid       string       `json:"sid,omitempty"`
+	Sub       string       `json:"sub,omitempty"`
+	TokenType string       `json:"token_type,omitempty"`
+	UserID    string       `json:"user_id,omitempty"`
+}
+
+type InvitationAcceptedResponse struct {
+	Message string `json:"message"`
+	OrgID   string `json:"org_id"`
+}
+
+type InvitationListResponse struct {
+	Invitations []OrgInvitation `json:"invitations"`
+}
+
+type InvitationPreview struct {
+	AccountExists bool      `json:"account_exists"`
+	Email         string    `json:"email"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	Organization  string    `json:"organization"`
+	Role          string    `json:"role"`
+}
+
+type InvitationResponse struct {
+	Invitation OrgInvitation `json:"invitation"`
+	Message    string        `json:"message,omitempty"`
+}
+
+type JWK struct {
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	E   string `json:"e,omitempty"`
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n,omitempty"`
+	Use string `json:"use"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JobInfo struct {
+	LastRun     JobRunInfo `json:"last_run,omitempty"`
+	Name        string     `json:"name"`
+	NextRunAt   time.Time  `json:"next_run_at"`
+	RequestedAt *time.Time `json:"requested_at,omitempty"`
+	Schedule    string     `json:"schedule"`
+}
+
+type JobListResponse struct {
+	Jobs []JobInfo `json:"jobs"`
+}
+
+type JobRunInfo struct {
+	Affected   int64      `json:"affected"`
+	Error      string     `json:"error,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ID         string     `json:"id"`
+	Instance   string     `json:"instance"`
+	Job        string     `json:"job"`
+	StartedAt  time.Time  `json:"started_at"`
+	Status     string     `json:"status"`
+	Trigger    string     `json:"trigger"`
+}
+
+type JobRunListResponse struct {
+	Runs []JobRunInfo `json:"runs"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	OrgID    string `json:"org_id,omitempty"`
+	Password string `json:"password"`
+}
+
+type LogoutSessionRequest struct {
+	SessionID string `json:"session_id"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+type MemberAddedResponse struct {
+	Membership Membership `json:"membership"`
+	Message    string     `json:"message"`
+}
+
+type MemberListResponse struct {
+	Members []OrgMember `json:"members"`
+}
+
+type Membership struct {
+	CreatedAt      time.Time `json:"created_at"`
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organization_id"`
+	Role           string    `json:"role"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	UserID         string    `json:"user_id"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+type OrgInvitation struct {
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Email          string     `json:"email"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	ID             string     `json:"id"`
+	InvitedBy      string     `json:"invited_by"`
+	OrganizationID string     `json:"organization_id"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	Role           string     `json:"role"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type OrgMember struct {
+	Email    string    `json:"email"`
+	Joined   time.Time `json:"joined"`
+	Role     string    `json:"role"`
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+}
+
+type OrgSummary struct {
+	Active bool   `json:"active"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Slug   string `json:"slug"`
+}
+
+type OrgSwitchedResponse struct {
+	Message string `json:"message"`
+	OrgID   string `json:"org_id"`
+}
+
+type Organization struct {
+	CreatedAt       time.Time `json:"created_at"`
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	ScopedUsernames bool      `json:"scoped_usernames"`
+	Slug            string    `json:"slug"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type OrganizationListResponse struct {
+	Organizations []OrgSummary `json:"organizations"`
+}
+
+type OrganizationResponse struct {
+	Organization Organization `json:"organization"`
+}
+
+type PasswordResetConfirmRequest struct {
+	NewPassword string `json:"new_password"`
+	Token       string `json:"token"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type Problem struct {
+	Code      string       `json:"code"`
+	Detail    string       `json:"detail,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Status    int64        `json:"status"`
+	Title     string       `json:"title"`
+	Type      string       `json:"type"`
+}
+
+type ProfilePatchRequest struct {
+	AvatarURL   *string `json:"avatar_url,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	DisplayName *string `json:"display_name,omitempty"`
+	Locale      *string `json:"locale,omitempty"`
+	Timezone    *string `json:"timezone,omitempty"`
+}
+
+type PublicProfile struct {
+	AvatarURL   string    `json:"avatar_url"`
+	Bio         string    `json:"bio"`
+	DisplayName string    `json:"display_name"`
+	Joined      time.Time `json:"joined"`
+	Username    string    `json:"username"`
+}
+
+type ReauthenticateRequest struct {
+	Password string `json:"password"`
+}
+
+type ReauthenticateResponse struct {
+	ElevatedUntil time.Time `json:"elevated_until"`
+	Message       string    `json:"message"`
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Username string `json:"username"`
+}
+
+type RenameDeviceRequest struct {
+	Name string `json:"name"`
+}
+
+type ReportNotMeRequest struct {
+	Token string `json:"token"`
+}
+
+type RestoreAccountInfo struct {
+	DeletedAt     time.Time `json:"deleted_at"`
+	Email         string    `json:"email"`
+	RestoreBefore time.Time `json:"restore_before"`
+	Username      string    `json:"username"`
+}
+
+type RestoreAccountRequest struct {
+	Token string `json:"token"`
+}
+
+type SessionInfo struct {
+	CreatedAt  time.Time `json:"created_at"`
+	Current    bool      `json:"current"`
+	DeviceName string    `json:"device_name,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	ID         string    `json:"id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+}
+
+type SessionListResponse struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+type StartImpersonationRequest struct {
+	Reason string `json:"reason"`
+	UserID string `json:"user_id"`
+}
+
+type SwitchOrganizationRequest struct {
+	OrgID string `json:"org_id"`
+}
+
+type TokenGrantRequest struct {
+	ClientID     string `json:"client_id,omitempty"`
+	DeviceCode   string `json:"device_code,omitempty"`
+	GrantType    string `json:"grant_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type TokenRequest struct {
+	Token         string `json:"token"`
+	TokenTypeHint string `json:"token_type_hint,omitempty"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	TokenType    string `json:"token_type"`
+}
+
+type TrustedDevice struct {
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	ID          string    `json:"id"`
+	LastIP      string    `json:"last_ip"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	Location    string    `json:"location"`
+	Name        string    `json:"name"`
+	UserAgent   string    `json:"user_agent"`
+}
+
+type UpdateOrgMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type UpdatePasswordRequest struct {
+	NewPassword string `json:"new_password"`
+	OldPassword string `json:"old_password"`
+}
+
+type UpdateUsernameRequest struct {
+	NewUsername string `json:"new_username"`
+}
+
+type UpdateWebhookRequest struct {
+	Description *string   `json:"description,omitempty"`
+	Enabled     *bool     `json:"enabled,omitempty"`
+	Events      *[]string `json:"events,omitempty"`
+	URL         *string   `json:"url,omitempty"`
+}
+
+type UserProfile struct {
+	AvatarURL     string    `json:"avatar_url"`
+	Bio           string    `json:"bio"`
+	DisplayName   string    `json:"display_name"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	ID            string    `json:"id"`
+	Joined        time.Time `json:"joined"`
+	Locale        string    `json:"locale"`
+	Timezone      string    `json:"timezone"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Username      string    `json:"username"`
+}
+
+type UsernameRedirectResponse struct {
+	Location string `json:"location"`
+	Username string `json:"username"`
+}
+
+type WebhookCreatedResponse struct {
+	Secret  string      `json:"secret"`
+	Webhook WebhookInfo `json:"webhook"`
+}
+
+type WebhookDeliveryInfo struct {
+	Attempts       int64      `json:"attempts"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	Event          string     `json:"event"`
+	EventID        string     `json:"event_id"`
+	ID             string     `json:"id"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	Payload        string     `json:"payload"`
+	ResponseStatus int64      `json:"response_status,omitempty"`
+	Status         string     `json:"status"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryInfo `json:"deliveries"`
+}
+
+type WebhookInfo struct {
+	CreatedAt   time.Time `json:"created_at"`
+	Description string    `json:"description"`
+	Enabled     bool      `json:"enabled"`
+	Events      []string  `json:"events"`
+	ID          string    `json:"id"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	URL         string    `json:"url"`
+}
+
+type WebhookListResponse struct {
+	Webhooks []WebhookInfo `json:"webhooks"`
+}
+
+// AcceptInvitation calls POST /invitations/accept: Accept an invitation (signing in or registering)
+func (c *Client) AcceptInvitation(ctx context.Context, body AcceptInvitationRequest) (*InvitationAcceptedResponse, error) {
+	query := url.Values{}
+	var out InvitationAcceptedResponse
+	if err := c.do(ctx, http.MethodPost, "/invitations/accept", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddOrgMember calls POST /orgs/{org_id}/members: Add an existing user by email
+func (c *Client) AddOrgMember(ctx context.Context, orgID string, body AddOrgMemberRequest) (*MemberAddedResponse, error) {
+	query := url.Values{}
+	var out MemberAddedResponse
+	if err := c.do(ctx, http.MethodPost, "/orgs/"+url.PathEscape(orgID)+"/members", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AnswerDeviceAuthorization calls POST /device: Approve or decline a device sign-in
+func (c *Client) AnswerDeviceAuthorization(ctx context.Context, body DeviceApprovalRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/device", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmEmail calls GET /confirm-email: Confirm an email change from the emailed link
+func (c *Client) ConfirmEmail(ctx context.Context, token string) (*MessageResponse, error) {
+	query := url.Values{}
+	query.Set("token", token)
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodGet, "/confirm-email", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmPasswordReset calls POST /password-reset/confirm: Set a new password from a reset link
+func (c *Client) ConfirmPasswordReset(ctx context.Context, body PasswordResetConfirmRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/password-reset/confirm", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateOrgInvitation calls POST /orgs/{org_id}/invitations: Invite someone by email
+func (c *Client) CreateOrgInvitation(ctx context.Context, orgID string, body CreateInvitationRequest) (*InvitationResponse, error) {
+	query := url.Values{}
+	var out InvitationResponse
+	if err := c.do(ctx, http.MethodPost, "/orgs/"+url.PathEscape(orgID)+"/invitations", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateOrganization calls POST /orgs: Create an organization (caller becomes owner)
+func (c *Client) CreateOrganization(ctx context.Context, body CreateOrganizationRequest) (*OrganizationResponse, error) {
+	query := url.Values{}
+	var out OrganizationResponse
+	if err := c.do(ctx, http.MethodPost, "/orgs", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateWebhook calls POST /admin/webhooks: Register a webhook endpoint
+func (c *Client) CreateWebhook(ctx context.Context, body CreateWebhookRequest) (*WebhookCreatedResponse, error) {
+	query := url.Values{}
+	var out WebhookCreatedResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/webhooks", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteAccount calls POST /delete-account: Delete the account (restorable for a grace period)
+func (c *Client) DeleteAccount(ctx context.Context) (*AccountDeletedResponse, error) {
+	query := url.Values{}
+	var out AccountDeletedResponse
+	if err := c.do(ctx, http.MethodPost, "/delete-account", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteAvatar calls DELETE /user/avatar: Remove the profile picture
+func (c *Client) DeleteAvatar(ctx context.Context) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodDelete, "/user/avatar", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWebhook calls DELETE /admin/webhooks/{webhook_id}: Delete a webhook endpoint and its delivery log
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodDelete, "/admin/webhooks/"+url.PathEscape(webhookID), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportUserData calls GET /user/export: Download all personal data
+//
+// The caller must close the returned body.
+func (c *Client) ExportUserData(ctx context.Context) (io.ReadCloser, error) {
+	query := url.Values{}
+	return c.doRaw(ctx, http.MethodGet, "/user/export", query, nil)
+}
+
+// GetDeviceAuthorization calls GET /device: Look up a pending device sign-in by user code
+func (c *Client) GetDeviceAuthorization(ctx context.Context, userCode string) (*DeviceAuthorizationInfo, error) {
+	query := url.Values{}
+	query.Set("user_code", userCode)
+	var out DeviceAuthorizationInfo
+	if err := c.do(ctx, http.MethodGet, "/device", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetEventStats calls GET /admin/events/stats: Domain event counters and delivery backlog per subscriber
+func (c *Client) GetEventStats(ctx context.Context) (*EventStatsResponse, error) {
+	query := url.Values{}
+	var out EventStatsResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/events/stats", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJWKS calls GET /.well-known/jwks.json: Public keys that verify access tokens
+func (c *Client) GetJWKS(ctx context.Context) (*JWKSResponse, error) {
+	query := url.Values{}
+	var out JWKSResponse
+	if err := c.do(ctx, http.MethodGet, "/.well-known/jwks.json", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOpenAPI calls GET /openapi.json: This document
+func (c *Client) GetOpenAPI(ctx context.Context) (map[string]interface{}, error) {
+	query := url.Values{}
+	var out map[string]interface{}
+	err := c.do(ctx, http.MethodGet, "/openapi.json", query, nil, &out)
+	return out, err
+}
+
+// GetProfile calls GET /user: Current user's profile
+func (c *Client) GetProfile(ctx context.Context) (*UserProfile, error) {
+	query := url.Values{}
+	var out UserProfile
+	if err := c.do(ctx, http.MethodGet, "/user", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetProfileByUsername calls GET /users/{username}: Public profile by username
+func (c *Client) GetProfileByUsername(ctx context.Context, username string) (*PublicProfile, error) {
+	query := url.Values{}
+	var out PublicProfile
+	if err := c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(username), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRestoreAccount calls GET /restore-account: Check an emailed restore link before restoring
+func (c *Client) GetRestoreAccount(ctx context.Context, token string) (*RestoreAccountInfo, error) {
+	query := url.Values{}
+	query.Set("token", token)
+	var out RestoreAccountInfo
+	if err := c.do(ctx, http.MethodGet, "/restore-account", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetStatus calls GET /: Health check
+func (c *Client) GetStatus(ctx context.Context) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodGet, "/", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// IntrospectToken calls POST /introspect: Check whether a token is active (RFC 7662)
+func (c *Client) IntrospectToken(ctx context.Context, body TokenRequest) (*IntrospectionResponse, error) {
+	query := url.Values{}
+	var out IntrospectionResponse
+	if err := c.doForm(ctx, http.MethodPost, "/introspect", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// IssueToken calls POST /token: Get a token (client_credentials, device_code or refresh_token grant)
+func (c *Client) IssueToken(ctx context.Context, body TokenGrantRequest) (*TokenResponse, error) {
+	query := url.Values{}
+	var out TokenResponse
+	if err := c.doForm(ctx, http.MethodPost, "/token", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListDevices calls GET /devices: List trusted devices
+func (c *Client) ListDevices(ctx context.Context) (*DeviceListResponse, error) {
+	query := url.Values{}
+	var out DeviceListResponse
+	if err := c.do(ctx, http.MethodGet, "/devices", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListErrorCodes calls GET /errors: Error code catalogue
+func (c *Client) ListErrorCodes(ctx context.Context) (*CatalogueResponse, error) {
+	query := url.Values{}
+	var out CatalogueResponse
+	if err := c.do(ctx, http.MethodGet, "/errors", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListJobRuns calls GET /admin/jobs/{job}/runs: Run history of a job
+func (c *Client) ListJobRuns(ctx context.Context, job string, status string, limit string) (*JobRunListResponse, error) {
+	query := url.Values{}
+	query.Set("status", status)
+	query.Set("limit", limit)
+	var out JobRunListResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/jobs/"+url.PathEscape(job)+"/runs", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListJobs calls GET /admin/jobs: Scheduled maintenance jobs with their next and last run
+func (c *Client) ListJobs(ctx context.Context) (*JobListResponse, error) {
+	query := url.Values{}
+	var out JobListResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/jobs", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListOrgInvitations calls GET /orgs/{org_id}/invitations: List pending invitations
+func (c *Client) ListOrgInvitations(ctx context.Context, orgID string) (*InvitationListResponse, error) {
+	query := url.Values{}
+	var out InvitationListResponse
+	if err := c.do(ctx, http.MethodGet, "/orgs/"+url.PathEscape(orgID)+"/invitations", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListOrgMembers calls GET /orgs/{org_id}/members: List members
+func (c *Client) ListOrgMembers(ctx context.Context, orgID string) (*MemberListResponse, error) {
+	query := url.Values{}
+	var out MemberListResponse
+	if err := c.do(ctx, http.MethodGet, "/orgs/"+url.PathEscape(orgID)+"/members", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListOrganizations calls GET /orgs: List my organizations
+func (c *Client) ListOrganizations(ctx context.Context) (*OrganizationListResponse, error) {
+	query := url.Values{}
+	var out OrganizationListResponse
+	if err := c.do(ctx, http.MethodGet, "/orgs", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSessions calls GET /active-sessions: List sessions
+func (c *Client) ListSessions(ctx context.Context) (*SessionListResponse, error) {
+	query := url.Values{}
+	var out SessionListResponse
+	if err := c.do(ctx, http.MethodGet, "/active-sessions", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhookDeliveries calls GET /admin/webhooks/{webhook_id}/deliveries: Delivery log of a webhook endpoint
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID string, status string, limit string) (*WebhookDeliveryListResponse, error) {
+	query := url.Values{}
+	query.Set("status", status)
+	query.Set("limit", limit)
+	var out WebhookDeliveryListResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/webhooks/"+url.PathEscape(webhookID)+"/deliveries", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhooks calls GET /admin/webhooks: List webhook endpoints
+func (c *Client) ListWebhooks(ctx context.Context) (*WebhookListResponse, error) {
+	query := url.Values{}
+	var out WebhookListResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/webhooks", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Login calls POST /login: Sign in with email and password (sets auth cookies)
+func (c *Client) Login(ctx context.Context, body LoginRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/login", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Logout calls POST /logout: Sign out of the current session
+func (c *Client) Logout(ctx context.Context) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/logout", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LogoutSession calls POST /logout-session: Sign out another session
+func (c *Client) LogoutSession(ctx context.Context, body LogoutSessionRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/logout-session", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PreviewInvitation calls GET /invitations: Preview an invitation from its emailed token
+func (c *Client) PreviewInvitation(ctx context.Context, token string) (*InvitationPreview, error) {
+	query := url.Values{}
+	query.Set("token", token)
+	var out InvitationPreview
+	if err := c.do(ctx, http.MethodGet, "/invitations", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Reauthenticate calls POST /reauthenticate: Enter sudo mode
+func (c *Client) Reauthenticate(ctx context.Context, body ReauthenticateRequest) (*ReauthenticateResponse, error) {
+	query := url.Values{}
+	var out ReauthenticateResponse
+	if err := c.do(ctx, http.MethodPost, "/reauthenticate", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RedeliverWebhook calls POST /admin/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver: Send a delivery again with fresh attempts
+func (c *Client) RedeliverWebhook(ctx context.Context, webhookID string, deliveryID string) (*WebhookDeliveryInfo, error) {
+	query := url.Values{}
+	var out WebhookDeliveryInfo
+	if err := c.do(ctx, http.MethodPost, "/admin/webhooks/"+url.PathEscape(webhookID)+"/deliveries/"+url.PathEscape(deliveryID)+"/redeliver", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RefreshToken calls POST /refresh: Rotate the auth cookies
+func (c *Client) RefreshToken(ctx context.Context) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/refresh", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Register calls POST /register: Create an account
+func (c *Client) Register(ctx context.Context, body RegisterRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/register", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveDevice calls DELETE /devices/{device_id}: Forget a device and sign it out
+func (c *Client) RemoveDevice(ctx context.Context, deviceID string) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodDelete, "/devices/"+url.PathEscape(deviceID), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveOrgMember calls DELETE /orgs/{org_id}/members/{user_id}: Remove a member
+func (c *Client) RemoveOrgMember(ctx context.Context, orgID string, userID string) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodDelete, "/orgs/"+url.PathEscape(orgID)+"/members/"+url.PathEscape(userID), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RenameDevice calls PATCH /devices/{device_id}: Rename a trusted device
+func (c *Client) RenameDevice(ctx context.Context, deviceID string, body RenameDeviceRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPatch, "/devices/"+url.PathEscape(deviceID), query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReportNotMe calls POST /not-me: Sign out a device reported from a new-device email
+func (c *Client) ReportNotMe(ctx context.Context, body ReportNotMeRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/not-me", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RequestDeviceCode calls POST /device/code: Start a device sign-in (RFC 8628)
+func (c *Client) RequestDeviceCode(ctx context.Context, body DeviceCodeRequest) (*DeviceCodeResponse, error) {
+	query := url.Values{}
+	var out DeviceCodeResponse
+	if err := c.doForm(ctx, http.MethodPost, "/device/code", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RequestEmailChange calls POST /update-email: Email a confirmation link to a new address
+func (c *Client) RequestEmailChange(ctx context.Context, body EmailChangeRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/update-email", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RequestMagicLink calls POST /login/magic-link: Email a passwordless sign-in link
+func (c *Client) RequestMagicLink(ctx context.Context, body MagicLinkRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/login/magic-link", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RequestPasswordReset calls POST /password-reset: Email a password reset link
+func (c *Client) RequestPasswordReset(ctx context.Context, body PasswordResetRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/password-reset", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResendOrgInvitation calls POST /orgs/{org_id}/invitations/{invite_id}/resend: Resend an invitation with a fresh link
+func (c *Client) ResendOrgInvitation(ctx context.Context, orgID string, inviteID string) (*InvitationResponse, error) {
+	query := url.Values{}
+	var out InvitationResponse
+	if err := c.do(ctx, http.MethodPost, "/orgs/"+url.PathEscape(orgID)+"/invitations/"+url.PathEscape(inviteID)+"/resend", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RestoreAccount calls POST /restore-account: Restore a deleted account with the emailed token
+func (c *Client) RestoreAccount(ctx context.Context, body RestoreAccountRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/restore-account", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeOrgInvitation calls DELETE /orgs/{org_id}/invitations/{invite_id}: Revoke an invitation
+func (c *Client) RevokeOrgInvitation(ctx context.Context, orgID string, inviteID string) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodDelete, "/orgs/"+url.PathEscape(orgID)+"/invitations/"+url.PathEscape(inviteID), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeToken calls POST /revoke: Revoke a token and its session (RFC 7009)
+func (c *Client) RevokeToken(ctx context.Context, body TokenRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.doForm(ctx, http.MethodPost, "/revoke", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RunJob calls POST /admin/jobs/{job}/run: Run a job at the next poll instead of waiting for its schedule
+func (c *Client) RunJob(ctx context.Context, job string) (*JobInfo, error) {
+	query := url.Values{}
+	var out JobInfo
+	if err := c.do(ctx, http.MethodPost, "/admin/jobs/"+url.PathEscape(job)+"/run", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartImpersonation calls POST /admin/impersonations: Act as a user with a short-lived token
+func (c *Client) StartImpersonation(ctx context.Context, body StartImpersonationRequest) (*ImpersonationResponse, error) {
+	query := url.Values{}
+	var out ImpersonationResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/impersonations", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StopImpersonation calls POST /impersonation/stop: End the impersonation the token belongs to
+func (c *Client) StopImpersonation(ctx context.Context) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/impersonation/stop", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SwitchOrganization calls POST /orgs/switch: Switch the active organization
+func (c *Client) SwitchOrganization(ctx context.Context, body SwitchOrganizationRequest) (*OrgSwitchedResponse, error) {
+	query := url.Values{}
+	var out OrgSwitchedResponse
+	if err := c.do(ctx, http.MethodPost, "/orgs/switch", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateOrgMemberRole calls PATCH /orgs/{org_id}/members/{user_id}: Change a member's role
+func (c *Client) UpdateOrgMemberRole(ctx context.Context, orgID string, userID string, body UpdateOrgMemberRoleRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPatch, "/orgs/"+url.PathEscape(orgID)+"/members/"+url.PathEscape(userID), query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePassword calls POST /update-password: Change password
+func (c *Client) UpdatePassword(ctx context.Context, body UpdatePasswordRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/update-password", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateProfile calls PATCH /user: Update profile fields (merge patch, null clears)
+func (c *Client) UpdateProfile(ctx context.Context, body ProfilePatchRequest) (*UserProfile, error) {
+	query := url.Values{}
+	var out UserProfile
+	if err := c.do(ctx, http.MethodPatch, "/user", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateUsername calls POST /update-username: Change username
+func (c *Client) UpdateUsername(ctx context.Context, body UpdateUsernameRequest) (*MessageResponse, error) {
+	query := url.Values{}
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/update-username", query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateWebhook calls PATCH /admin/webhooks/{webhook_id}: Change or disable a webhook endpoint
+func (c *Client) UpdateWebhook(ctx context.Context, webhookID string, body UpdateWebhookRequest) (*WebhookInfo, error) {
+	query := url.Values{}
+	var out WebhookInfo
+	if err := c.do(ctx, http.MethodPatch, "/admin/webhooks/"+url.PathEscape(webhookID), query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UploadAvatar calls POST /user/avatar: Upload a profile picture
+func (c *Client) UploadAvatar(ctx context.Context, filename string, file io.Reader) (*AvatarResponse, error) {
+	query := url.Values{}
+	var out AvatarResponse
+	if err := c.doMultipart(ctx, http.MethodPost, "/user/avatar", query, "avatar", filename, file, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VerifyMagicLink calls GET /login/magic-link/verify: Sign in from an emailed link
+func (c *Client) VerifyMagicLink(ctx context.Context, token string) (*MessageResponse, error) {
+	query := url.Values{}
+	query.Set("token", token)
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodGet, "/login/magic-link/verify", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
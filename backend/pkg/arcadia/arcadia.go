@@ -0,0 +1,118 @@
+// Package arcadia lets other Go services trust ArcadiaGo sessions.
+//
+// Verify access tokens with the published keys (no shared secret) and read
+// the caller from the request context:
+//
+//	verifier := arcadia.NewJWKSVerifier("https://auth.example.com/.well-known/jwks.json")
+//	mux.Handle("/orders", arcadia.Middleware(verifier)(ordersHandler))
+//
+//	func ordersHandler(w http.ResponseWriter, r *http.Request) {
+//		claims, _ := arcadia.FromContext(r.Context())
+//		...claims.UserID...
+//	}
+//
+// JWKS verification is local and fast but cannot see logouts before the token
+// expires; an IntrospectionVerifier asks the server (RFC 7662) and does. Gin
+// services use GinMiddleware. Client calls ArcadiaGo on behalf of a user,
+// ClientCredentials gets service tokens for backend jobs, VerifyWebhook checks
+// webhook deliveries, and the arcadiatest package mints tokens for unit tests.
+package arcadia
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ✅ Claims of an ArcadiaGo access token
+//
+// User tokens carry UserID; service tokens (client_credentials grant) carry
+// ClientID instead, check IsService before acting on behalf of a user.
+// Impersonation tokens are user tokens with the support admin in Actor.
+type Claims struct {
+	UserID       string        `json:"user_id,omitempty"`
+	OrgID        string        `json:"org_id,omitempty"`    // Active organization ("" when none)
+	SessionID    string        `json:"sid,omitempty"`       // Session the token belongs to
+	AuthTime     int64         `json:"auth_time,omitempty"` // When the user last entered their credentials (Unix seconds)
+	Scope        string        `json:"scope,omitempty"`     // Space-separated scopes; empty for full user sessions
+	ClientID     string        `json:"client_id,omitempty"` // Service client of a client_credentials token
+	Confirmation *Confirmation `json:"cnf,omitempty"`       // Certificate a service token is bound to
+	Actor        *Actor        `json:"act,omitempty"`       // Support admin acting as the user
+	jwt.RegisteredClaims
+}
+
+// ✅ Actor names who acts on the subject's behalf (RFC 8693 §4.1)
+type Actor struct {
+	Subject string `json:"sub"` // User ID of the admin
+}
+
+// ✅ Confirmation binds a token to the client's mTLS certificate (RFC 8705)
+type Confirmation struct {
+	X5tS256 string `json:"x5t#S256"` // base64url SHA-256 of the certificate's DER encoding
+}
+
+// ✅ Whether this is a service (client_credentials) token rather than a user's
+func (c *Claims) IsService() bool {
+	return c.UserID == "" && c.ClientID != ""
+}
+
+// ✅ Whether a support admin is acting as the user (audit with Actor.Subject)
+func (c *Claims) IsImpersonation() bool {
+	return c.Actor != nil
+}
+
+// ✅ Whether the token was granted a scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(strings.Fields(c.Scope), scope)
+}
+
+// ✅ Whether a certificate-bound token was presented with its certificate (unbound tokens always pass)
+//
+// Pass the client certificate of the TLS connection the token arrived on
+// (r.TLS.PeerCertificates[0]).
+func (c *Claims) BoundTo(cert *x509.Certificate) bool {
+	if c.Confirmation == nil {
+		return true
+	}
+	if cert == nil {
+		return false
+	}
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:]) == c.Confirmation.X5tS256
+}
+
+// ✅ When the user last proved their credentials (zero when unknown)
+func (c *Claims) LastAuthenticated() time.Time {
+	if c.AuthTime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.AuthTime, 0)
+}
+
+// ✅ Verifier checks an access token and returns its claims
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+// ✅ Returned (wrapped) for any token that must not be trusted
+var ErrInvalidToken = errors.New("arcadia: invalid token")
+
+type contextKey struct{}
+
+// ✅ Attach verified claims to a context
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// ✅ Claims stored by the middleware (false for unauthenticated requests)
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,269 @@
+package arcadia_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thejpness/ArcadiaGo/pkg/arcadia"
+	"github.com/thejpness/ArcadiaGo/pkg/arcadia/arcadiatest"
+)
+
+// keyServer serves the key set of whichever issuer is current and counts fetches
+type keyServer struct {
+	mu      sync.Mutex
+	issuer  *arcadiatest.Issuer
+	failing bool
+	fetches atomic.Int32
+}
+
+func (s *keyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.fetches.Add(1)
+	s.mu.Lock()
+	issuer, failing := s.issuer, s.failing
+	s.mu.Unlock()
+	if failing {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	issuer.ServeHTTP(w, r)
+}
+
+func (s *keyServer) set(issuer *arcadiatest.Issuer, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issuer, s.failing = issuer, failing
+}
+
+func newKeyServer(t *testing.T, issuer *arcadiatest.Issuer) (*keyServer, string) {
+	t.Helper()
+	keys := &keyServer{issuer: issuer}
+	srv := httptest.NewServer(keys)
+	t.Cleanup(srv.Close)
+	return keys, srv.URL
+}
+
+func TestJWKSVerifierKeyRotation(t *testing.T) {
+	old, current := arcadiatest.NewIssuer(), arcadiatest.NewIssuer()
+	keys, url := newKeyServer(t, old)
+	verifier := arcadia.NewJWKSVerifier(url)
+	ctx := context.Background()
+
+	claims, err := verifier.Verify(ctx, old.UserToken("user-1"))
+	if err != nil || claims.UserID != "user-1" || claims.SessionID == "" {
+		t.Fatalf("Verify = %+v, %v", claims, err)
+	}
+
+	// The server rotates: a token signed with the new key makes the verifier refetch at once
+	keys.set(current, false)
+	if _, err := verifier.Verify(ctx, current.UserToken("user-2")); err != nil {
+		t.Fatalf("token signed with the rotated key: %v", err)
+	}
+	if n := keys.fetches.Load(); n != 2 {
+		t.Errorf("%d fetches, want 2", n)
+	}
+
+	// The retired key is gone with the refetch
+	if _, err := verifier.Verify(ctx, old.UserToken("user-1")); !errors.Is(err, arcadia.ErrInvalidToken) {
+		t.Errorf("token signed with the retired key: %v", err)
+	}
+}
+
+func TestJWKSVerifierUnknownKid(t *testing.T) {
+	trusted, stranger := arcadiatest.NewIssuer(), arcadiatest.NewIssuer()
+	keys, url := newKeyServer(t, trusted)
+	verifier := arcadia.NewJWKSVerifier(url)
+	ctx := context.Background()
+
+	if _, err := verifier.Verify(ctx, trusted.UserToken("user-1")); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := verifier.Verify(ctx, stranger.UserToken("user-1")); !errors.Is(err, arcadia.ErrInvalidToken) {
+			t.Fatalf("token from an unknown key: %v", err)
+		}
+	}
+	// One refetch for the first unknown kid, then at most once a minute
+	if n := keys.fetches.Load(); n != 2 {
+		t.Errorf("%d fetches, want 2", n)
+	}
+}
+
+func TestJWKSVerifierCacheExpiry(t *testing.T) {
+	issuer := arcadiatest.NewIssuer()
+	keys, url := newKeyServer(t, issuer)
+	verifier := &arcadia.JWKSVerifier{URL: url, TTL: 200 * time.Millisecond}
+	ctx := context.Background()
+	token := issuer.UserToken("user-1")
+
+	for range 2 {
+		if _, err := verifier.Verify(ctx, token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := keys.fetches.Load(); n != 1 {
+		t.Fatalf("%d fetches within the TTL, want 1", n)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if _, err := verifier.Verify(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if n := keys.fetches.Load(); n != 2 {
+		t.Fatalf("%d fetches after the TTL, want 2", n)
+	}
+
+	// A failed refresh keeps the keys it had
+	keys.set(issuer, true)
+	time.Sleep(250 * time.Millisecond)
+	if _, err := verifier.Verify(ctx, token); err != nil {
+		t.Errorf("Verify while the key set is unavailable: %v", err)
+	}
+	if n := keys.fetches.Load(); n != 3 {
+		t.Errorf("%d fetches, want 3", n)
+	}
+}
+
+func TestJWKSVerifierRejects(t *testing.T) {
+	issuer := arcadiatest.NewIssuer()
+	verifier := issuer.Verifier()
+	tests := map[string]string{
+		"expired":   issuer.ExpiredToken("user-1"),
+		"anonymous": issuer.Token(arcadia.Claims{}),
+		"garbage":   "not-a-token",
+		"foreign":   arcadiatest.NewIssuer().UserToken("user-1"),
+	}
+	for name, token := range tests {
+		if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, arcadia.ErrInvalidToken) {
+			t.Errorf("%s: Verify = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestIntrospectionVerifier(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if id, secret, ok := r.BasicAuth(); !ok || id != "svc_orders" || secret != "s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		response := map[string]interface{}{"active": false}
+		if r.PostFormValue("token") == "live-token" {
+			response = map[string]interface{}{
+				"active": true, "sub": "user-1", "user_id": "user-1", "sid": "session-1",
+				"exp": time.Now().Add(time.Hour).Unix(), "act": map[string]string{"sub": "admin-1"},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer srv.Close()
+
+	verifier := arcadia.NewIntrospectionVerifier(srv.URL, "svc_orders", "s3cret")
+	ctx := context.Background()
+
+	claims, err := verifier.Verify(ctx, "live-token")
+	if err != nil || claims.UserID != "user-1" || claims.SessionID != "session-1" || !claims.IsImpersonation() {
+		t.Fatalf("active token: %+v, %v", claims, err)
+	}
+
+	// Inactive answers are errors, and cached like active ones
+	for range 2 {
+		if _, err := verifier.Verify(ctx, "revoked-token"); !errors.Is(err, arcadia.ErrInvalidToken) {
+			t.Fatalf("inactive token: %v", err)
+		}
+	}
+	if _, err := verifier.Verify(ctx, "live-token"); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d introspection calls, want 2 (one per token)", n)
+	}
+
+	// Wrong credentials are an error, not an inactive token
+	wrong := arcadia.NewIntrospectionVerifier(srv.URL, "svc_orders", "guess")
+	if _, err := wrong.Verify(ctx, "live-token"); err == nil || errors.Is(err, arcadia.ErrInvalidToken) {
+		t.Errorf("rejected credentials: %v", err)
+	}
+}
+
+func TestBoundTo(t *testing.T) {
+	cert, other := &x509.Certificate{Raw: []byte("client certificate")}, &x509.Certificate{Raw: []byte("another certificate")}
+	sum := sha256.Sum256(cert.Raw)
+	bound := &arcadia.Claims{ClientID: "svc_orders", Confirmation: &arcadia.Confirmation{X5tS256: base64.RawURLEncoding.EncodeToString(sum[:])}}
+
+	if !bound.BoundTo(cert) {
+		t.Error("bound token rejected with its own certificate")
+	}
+	if bound.BoundTo(other) || bound.BoundTo(nil) {
+		t.Error("bound token accepted without its certificate")
+	}
+	if unbound := (&arcadia.Claims{ClientID: "svc_orders"}); !unbound.BoundTo(nil) {
+		t.Error("unbound token rejected")
+	}
+
+	// The middleware checks the connection's certificate
+	issuer := arcadiatest.NewIssuer()
+	token := issuer.Token(*bound)
+	handler := arcadia.Middleware(issuer.Verifier())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := arcadia.FromContext(r.Context()); !ok || claims.ClientID != "svc_orders" {
+			t.Errorf("claims in context: %+v", claims)
+		}
+	}))
+	for name, tt := range map[string]struct {
+		cert *x509.Certificate
+		want int
+	}{"own certificate": {cert, http.StatusOK}, "another certificate": {other, http.StatusUnauthorized}, "no certificate": {nil, http.StatusUnauthorized}} {
+		req := httptest.NewRequest(http.MethodGet, "https://orders.example.com/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.TLS = &tls.ConnectionState{}
+		if tt.cert != nil {
+			req.TLS.PeerCertificates = []*x509.Certificate{tt.cert}
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", name, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	issuer := arcadiatest.NewIssuer()
+	handler := arcadia.Middleware(issuer.Verifier())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := arcadia.FromContext(r.Context())
+		w.Write([]byte(claims.UserID))
+	}))
+
+	for name, tt := range map[string]struct {
+		token string
+		want  int
+	}{"valid": {issuer.UserToken("user-1"), http.StatusOK}, "expired": {issuer.ExpiredToken("user-1"), http.StatusUnauthorized}, "missing": {"", http.StatusUnauthorized}} {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", name, rec.Code, tt.want)
+		}
+		if tt.want == http.StatusOK && rec.Body.String() != "user-1" {
+			t.Errorf("%s: handler saw user %q", name, rec.Body.String())
+		}
+		if tt.want == http.StatusUnauthorized && rec.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: Content-Type %q", name, rec.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package arcadia
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/thejpness/ArcadiaGo/pkg/apiclient"
+)
+
+// ✅ Client calls ArcadiaGo on behalf of the users whose tokens it is given
+//
+// Failed calls return an *apiclient.Problem when the server sent one.
+type Client struct {
+	BaseURL string       // e.g. "https://auth.example.com"
+	HTTP    *http.Client // http.DefaultClient when nil
+}
+
+// ✅ Client for the ArcadiaGo server at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// ✅ Verifier for this server's published keys
+func (c *Client) JWKSVerifier() *JWKSVerifier {
+	v := NewJWKSVerifier(c.BaseURL + "/.well-known/jwks.json")
+	v.HTTP = c.HTTP
+	return v
+}
+
+// ✅ Verifier that introspects every token here, as a registered service client
+//
+// Sees logouts and revoked sessions (within its cache TTL); see
+// IntrospectionVerifier.
+func (c *Client) IntrospectionVerifier(clientID, clientSecret string) *IntrospectionVerifier {
+	v := NewIntrospectionVerifier(c.BaseURL+"/introspect", clientID, clientSecret)
+	v.HTTP = c.HTTP
+	return v
+}
+
+// ✅ Service token source for a client_secret client of this server
+func (c *Client) ClientCredentials(clientID, clientSecret string, scopes ...string) *ClientCredentials {
+	cc := NewClientCredentials(c.BaseURL+"/token", clientID, clientSecret, scopes...)
+	cc.HTTP = c.HTTP
+	return cc
+}
+
+// ✅ Full generated API client authenticated with a user's access token
+func (c *Client) As(token string) *apiclient.Client {
+	base := http.DefaultTransport
+	if c.HTTP != nil && c.HTTP.Transport != nil {
+		base = c.HTTP.Transport
+	}
+	httpClient := &http.Client{Transport: bearerTransport{token: token, base: base}}
+	if c.HTTP != nil {
+		httpClient.Timeout = c.HTTP.Timeout
+	}
+	return &apiclient.Client{BaseURL: c.BaseURL, HTTP: httpClient}
+}
+
+// ✅ Profile of the token's user
+func (c *Client) User(ctx context.Context, token string) (*apiclient.UserProfile, error) {
+	return c.As(token).GetProfile(ctx)
+}
+
+// ✅ Public profile of any user, by username (moved names resolve to the new one)
+func (c *Client) PublicProfile(ctx context.Context, token, username string) (*apiclient.PublicProfile, error) {
+	return c.As(token).GetProfileByUsername(ctx, username)
+}
+
+// ✅ Sessions of the token's user (Current marks the token's own session)
+func (c *Client) Sessions(ctx context.Context, token string) ([]apiclient.SessionInfo, error) {
+	resp, err := c.As(token).ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Sessions, nil
+}
+
+// ✅ Sign one of the token user's sessions out
+func (c *Client) RevokeSession(ctx context.Context, token, sessionID string) error {
+	_, err := c.As(token).LogoutSession(ctx, apiclient.LogoutSessionRequest{SessionID: sessionID})
+	return err
+}
+
+// ✅ Adds the access token to every request
+type bearerTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,175 @@
+package arcadia
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/thejpness/ArcadiaGo/pkg/apiclient"
+)
+
+// ✅ ClientCredentials gets service tokens for a backend (client_credentials grant)
+//
+// Tokens are cached and renewed shortly before they expire:
+//
+//	creds := arcadia.NewClientCredentials("https://auth.example.com/token", clientID, secret, "orders:read")
+//	httpClient := &http.Client{Transport: creds.Transport(nil)}
+//
+// Clients registered for private_key_jwt set Key instead of ClientSecret;
+// mTLS clients configure the certificate on HTTP's transport.
+type ClientCredentials struct {
+	URL          string // Token endpoint, e.g. "https://auth.example.com/token"
+	ClientID     string
+	ClientSecret string        // client_secret clients
+	Key          crypto.Signer // private_key_jwt clients: signs a fresh assertion per request
+	Scopes       []string      // Requested scopes (every allowed scope when empty)
+	HTTP         *http.Client  // http.DefaultClient when nil
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// ✅ Token source for a client_secret client
+func NewClientCredentials(url, clientID, clientSecret string, scopes ...string) *ClientCredentials {
+	return &ClientCredentials{URL: url, ClientID: clientID, ClientSecret: clientSecret, Scopes: scopes}
+}
+
+// ✅ Current service token, fetching a new one when it is about to expire
+func (cc *ClientCredentials) Token(ctx context.Context) (string, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.token != "" && time.Until(cc.expiry) > 30*time.Second {
+		return cc.token, nil
+	}
+
+	resp, err := cc.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	cc.token = resp.AccessToken
+	cc.expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	return cc.token, nil
+}
+
+// ✅ Round tripper that adds the service token to every request (base defaults to http.DefaultTransport)
+func (cc *ClientCredentials) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return credentialsTransport{source: cc, base: base}
+}
+
+// ✅ Call the token endpoint
+func (cc *ClientCredentials) fetch(ctx context.Context) (*apiclient.TokenResponse, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(cc.Scopes) > 0 {
+		form.Set("scope", strings.Join(cc.Scopes, " "))
+	}
+	if cc.Key != nil {
+		assertion, err := cc.assertion()
+		if err != nil {
+			return nil, err
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", assertion)
+	} else if cc.ClientSecret == "" {
+		form.Set("client_id", cc.ClientID) // mTLS: the certificate authenticates
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	if cc.Key == nil && cc.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cc.ClientID), url.QueryEscape(cc.ClientSecret))
+	}
+
+	client := cc.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("arcadia: token request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		problem := &apiclient.Problem{Status: int64(resp.StatusCode), Title: resp.Status}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(problem); err != nil {
+			problem.Code = "http." + fmt.Sprint(resp.StatusCode)
+		}
+		return nil, problem
+	}
+
+	var out apiclient.TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("arcadia: decode token response: %w", err)
+	}
+	return &out, nil
+}
+
+// ✅ Signed private_key_jwt assertion for one token request (RFC 7523)
+func (cc *ClientCredentials) assertion() (string, error) {
+	var method jwt.SigningMethod
+	switch k := cc.Key.Public().(type) {
+	case *ecdsa.PublicKey:
+		method = jwt.SigningMethodES256
+		if k.Curve == elliptic.P384() {
+			method = jwt.SigningMethodES384
+		}
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return "", errors.New("arcadia: unsupported client key type")
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	return jwt.NewWithClaims(method, jwt.RegisteredClaims{
+		Issuer:    cc.ClientID,
+		Subject:   cc.ClientID,
+		Audience:  jwt.ClaimStrings{cc.URL},
+		ID:        hex.EncodeToString(jti),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+	}).SignedString(cc.Key)
+}
+
+// ✅ Adds the current service token to every request
+type credentialsTransport struct {
+	source *ClientCredentials
+	base   http.RoundTripper
+}
+
+func (t credentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
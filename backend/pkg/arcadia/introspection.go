@@ -0,0 +1,182 @@
+package arcadia
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ✅ IntrospectionVerifier asks the server whether a token is active (RFC 7662)
+//
+// Unlike a JWKSVerifier it sees logouts and revoked sessions, at the cost of
+// a request per token. Answers are cached for TTL (never past the token's
+// expiry), so a revocation takes at most TTL to reach this service.
+type IntrospectionVerifier struct {
+	URL          string // RFC 7662 introspection endpoint, e.g. "https://auth.example.com/introspect"
+	ClientID     string // Service client credentials (cmd/serviceclients), sent with HTTP Basic authentication
+	ClientSecret string
+	HTTP         *http.Client  // http.DefaultClient when nil
+	TTL          time.Duration // How long answers are cached (default 30 seconds, negative disables)
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]introspected
+}
+
+// ✅ Upper bound on cached answers
+const maxCachedTokens = 10000
+
+type introspected struct {
+	claims *Claims // nil for inactive tokens
+	until  time.Time
+}
+
+// ✅ Verifier that introspects tokens at url with the given client credentials
+func NewIntrospectionVerifier(url, clientID, clientSecret string) *IntrospectionVerifier {
+	return &IntrospectionVerifier{URL: url, ClientID: clientID, ClientSecret: clientSecret}
+}
+
+// ✅ RFC 7662 response (plus the ArcadiaGo session claims)
+type introspectionResponse struct {
+	Active    bool          `json:"active"`
+	Subject   string        `json:"sub"`
+	ClientID  string        `json:"client_id"`
+	Scope     string        `json:"scope"`
+	ExpiresAt int64         `json:"exp"`
+	IssuedAt  int64         `json:"iat"`
+	UserID    string        `json:"user_id"`
+	OrgID     string        `json:"org_id"`
+	SessionID string        `json:"sid"`
+	AuthTime  int64         `json:"auth_time"`
+	Cnf       *Confirmation `json:"cnf"`
+	Act       *Actor        `json:"act"`
+}
+
+// ✅ Check a token with the server (or the cache)
+func (v *IntrospectionVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	v.mu.Lock()
+	cached, ok := v.cache[key]
+	v.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		if cached.claims == nil {
+			return nil, fmt.Errorf("%w: inactive", ErrInvalidToken)
+		}
+		return cached.claims, nil
+	}
+
+	resp, err := v.introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims *Claims
+	if resp.Active {
+		claims = resp.claims()
+	}
+	v.remember(key, claims, now)
+
+	if claims == nil {
+		return nil, fmt.Errorf("%w: inactive", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// ✅ Call the introspection endpoint
+func (v *IntrospectionVerifier) introspect(ctx context.Context, token string) (*introspectionResponse, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if v.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(v.ClientID), url.QueryEscape(v.ClientSecret))
+	}
+
+	client := v.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("arcadia: introspect: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("arcadia: introspect: status %d", resp.StatusCode)
+	}
+
+	var out introspectionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("arcadia: decode introspection response: %w", err)
+	}
+	return &out, nil
+}
+
+// ✅ Claims of an active token
+func (r *introspectionResponse) claims() *Claims {
+	claims := &Claims{
+		UserID:       r.UserID,
+		OrgID:        r.OrgID,
+		SessionID:    r.SessionID,
+		AuthTime:     r.AuthTime,
+		Scope:        r.Scope,
+		ClientID:     r.ClientID,
+		Confirmation: r.Cnf,
+		Actor:        r.Act,
+	}
+	claims.Subject = r.Subject
+	if claims.UserID == "" && claims.ClientID == "" {
+		claims.UserID = r.Subject
+	}
+	if r.ExpiresAt != 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Unix(r.ExpiresAt, 0))
+	}
+	if r.IssuedAt != 0 {
+		claims.IssuedAt = jwt.NewNumericDate(time.Unix(r.IssuedAt, 0))
+	}
+	return claims
+}
+
+// ✅ Cache an answer until the TTL or the token's expiry, whichever comes first
+func (v *IntrospectionVerifier) remember(key [sha256.Size]byte, claims *Claims, now time.Time) {
+	ttl := v.TTL
+	if ttl == 0 {
+		ttl = 30 * time.Second
+	}
+	if ttl < 0 {
+		return
+	}
+	until := now.Add(ttl)
+	if claims != nil && claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(until) {
+		until = claims.ExpiresAt.Time
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.cache == nil {
+		v.cache = map[[sha256.Size]byte]introspected{}
+	}
+	if len(v.cache) >= maxCachedTokens {
+		for k, entry := range v.cache {
+			if now.After(entry.until) {
+				delete(v.cache, k)
+			}
+		}
+		if len(v.cache) >= maxCachedTokens {
+			v.cache = map[[sha256.Size]byte]introspected{} // Bound memory under a flood of distinct tokens
+		}
+	}
+	v.cache[key] = introspected{claims: claims, until: until}
+}
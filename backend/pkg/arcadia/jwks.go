@@ -0,0 +1,245 @@
+package arcadia
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ✅ JWKSet is a JSON Web Key Set (RFC 7517), as served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ✅ JWK is one public key
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// ✅ Algorithms access tokens may be signed with
+var signingAlgs = []string{"ES256", "ES384", "RS256", "EdDSA"}
+
+// ✅ Encode a public key (ECDSA P-256/P-384, RSA or Ed25519) as a JWK
+func NewJWK(kid string, key crypto.PublicKey) (JWK, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwk := JWK{Use: "sig", Kid: kid}
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk.Kty, jwk.Crv = "EC", k.Curve.Params().Name
+		jwk.X, jwk.Y = b64(k.X.FillBytes(make([]byte, size))), b64(k.Y.FillBytes(make([]byte, size)))
+		switch k.Curve {
+		case elliptic.P256():
+			jwk.Alg = "ES256"
+		case elliptic.P384():
+			jwk.Alg = "ES384"
+		default:
+			return JWK{}, errors.New("arcadia: unsupported EC curve")
+		}
+	case *rsa.PublicKey:
+		jwk.Kty, jwk.Alg = "RSA", "RS256"
+		jwk.N, jwk.E = b64(k.N.Bytes()), b64(big.NewInt(int64(k.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv, jwk.Alg, jwk.X = "OKP", "Ed25519", "EdDSA", b64(k)
+	default:
+		return JWK{}, fmt.Errorf("arcadia: unsupported key type %T", key)
+	}
+	return jwk, nil
+}
+
+// ✅ Decode the public key
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("arcadia: unsupported curve %q", k.Crv)
+		}
+		x, errX := decode(k.X)
+		y, errY := decode(k.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("arcadia: malformed EC key")
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("arcadia: EC point is not on the curve")
+		}
+		return key, nil
+	case "RSA":
+		n, errN := decode(k.N)
+		e, errE := decode(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("arcadia: malformed RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		x, err := decode(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("arcadia: malformed or unsupported OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("arcadia: unsupported key type %q", k.Kty)
+}
+
+// ✅ JWKSVerifier checks token signatures against the server's published keys
+//
+// Keys are cached for TTL. A token signed with an unknown key triggers an
+// early refetch (at most once a minute), so key rotation needs no restart.
+// It cannot notice revoked sessions before the token expires (at most an
+// hour); use an IntrospectionVerifier where that matters.
+type JWKSVerifier struct {
+	URL    string        // e.g. "https://auth.example.com/.well-known/jwks.json" ("" for a fixed key set)
+	HTTP   *http.Client  // http.DefaultClient when nil
+	TTL    time.Duration // How long fetched keys are trusted (default 5 minutes)
+	Leeway time.Duration // Allowed clock skew for exp / nbf (default 30 seconds)
+
+	mu        sync.Mutex
+	keys      map[string]verificationKey
+	fetchedAt time.Time
+	missedAt  time.Time
+}
+
+type verificationKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// ✅ Verifier that fetches keys from a JWKS URL
+func NewJWKSVerifier(url string) *JWKSVerifier {
+	return &JWKSVerifier{URL: url}
+}
+
+// ✅ Verifier for a fixed key set (tests, or keys distributed out of band)
+func NewKeySetVerifier(set JWKSet) *JWKSVerifier {
+	return &JWKSVerifier{keys: parseKeySet(set), fetchedAt: time.Now()}
+}
+
+// ✅ Check the signature, algorithm and lifetime of an access token
+func (v *JWKSVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	leeway := v.Leeway
+	if leeway == 0 {
+		leeway = 30 * time.Second
+	}
+
+	parsed, err := jwt.ParseWithClaims(token, &Claims{}, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.alg != "" && key.alg != t.Method.Alg() {
+			return nil, errors.New("algorithm does not match the key")
+		}
+		return key.key, nil
+	}, jwt.WithValidMethods(signingAlgs), jwt.WithExpirationRequired(), jwt.WithLeeway(leeway))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims := parsed.Claims.(*Claims)
+	if claims.UserID == "" && claims.ClientID == "" {
+		return nil, fmt.Errorf("%w: no user or client", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// ✅ Look up a key by ID, refreshing the cache when it is stale or the key is new
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (verificationKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	ttl := v.TTL
+	if ttl == 0 {
+		ttl = 5 * time.Minute
+	}
+	key, known := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > ttl
+	canRetry := time.Since(v.missedAt) > time.Minute
+
+	if v.URL != "" && (stale || (!known && canRetry)) {
+		keys, err := v.fetch(ctx)
+		switch {
+		case err == nil:
+			v.keys, v.fetchedAt = keys, time.Now()
+			key, known = keys[kid]
+		case v.keys == nil:
+			return verificationKey{}, err
+		}
+		// On a failed refresh keep using the previous keys
+	}
+	if !known {
+		v.missedAt = time.Now()
+		return verificationKey{}, fmt.Errorf("unknown key %q", kid)
+	}
+	return key, nil
+}
+
+// ✅ Download and parse the key set
+func (v *JWKSVerifier) fetch(ctx context.Context) (map[string]verificationKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := v.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("arcadia: fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("arcadia: fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("arcadia: decode JWKS: %w", err)
+	}
+	return parseKeySet(set), nil
+}
+
+// ✅ Index the usable signing keys by ID
+func parseKeySet(set JWKSet) map[string]verificationKey {
+	keys := map[string]verificationKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			continue // Key types this version does not understand
+		}
+		keys[jwk.Kid] = verificationKey{alg: jwk.Alg, key: public}
+	}
+	return keys
+}
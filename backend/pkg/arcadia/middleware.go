@@ -0,0 +1,128 @@
+package arcadia
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thejpness/ArcadiaGo/pkg/apiclient"
+)
+
+// ✅ Access token of a request: "Authorization: Bearer", else the auth_token cookie
+func TokenFromRequest(r *http.Request) string {
+	if scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " "); found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if cookie, err := r.Cookie("auth_token"); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// ✅ net/http middleware: reject requests without a valid access token
+//
+// Handlers read the caller with FromContext. Rejections are RFC 7807
+// problems with the same codes ArcadiaGo itself uses.
+func Middleware(v Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := TokenFromRequest(r)
+			if token == "" {
+				writeUnauthenticated(w, "No access token")
+				return
+			}
+			claims, err := v.Verify(r.Context(), token)
+			if err != nil {
+				writeUnauthenticated(w, "Invalid or expired access token")
+				return
+			}
+			if !presentedWithCertificate(r, claims) {
+				writeUnauthenticated(w, "Access token is bound to another client certificate")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+		})
+	}
+}
+
+// ✅ Gin key of the verified claims
+const GinClaimsKey = "arcadia.claims"
+
+// ✅ Gin middleware: reject requests without a valid access token
+//
+// Sets the claims on the request context (FromContext), under GinClaimsKey,
+// and as "user_id", "org_id" and "session_id" like ArcadiaGo's own handlers
+// (plus "client_id" for service tokens and "actor_id" for impersonation).
+func GinMiddleware(v Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := TokenFromRequest(c.Request)
+		if token == "" {
+			writeUnauthenticated(c.Writer, "No access token")
+			c.Abort()
+			return
+		}
+		claims, err := v.Verify(c.Request.Context(), token)
+		if err != nil {
+			writeUnauthenticated(c.Writer, "Invalid or expired access token")
+			c.Abort()
+			return
+		}
+		if !presentedWithCertificate(c.Request, claims) {
+			writeUnauthenticated(c.Writer, "Access token is bound to another client certificate")
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), claims))
+		c.Set(GinClaimsKey, claims)
+		c.Set("user_id", claims.UserID)
+		c.Set("org_id", claims.OrgID)
+		c.Set("session_id", claims.SessionID)
+		c.Set("client_id", claims.ClientID)
+		if claims.IsImpersonation() {
+			c.Set("actor_id", claims.Actor.Subject)
+		}
+		c.Next()
+	}
+}
+
+// ✅ Claims set by GinMiddleware
+func GinClaims(c *gin.Context) (*Claims, bool) {
+	claims, ok := c.Get(GinClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	typed, ok := claims.(*Claims)
+	return typed, ok
+}
+
+// ✅ Check a certificate-bound token against the connection's client certificate
+//
+// Only possible when this process terminates TLS; behind a TLS-terminating
+// proxy call Claims.BoundTo with the certificate the proxy forwards.
+func presentedWithCertificate(r *http.Request, claims *Claims) bool {
+	if r.TLS == nil {
+		return true
+	}
+	var cert *x509.Certificate
+	if len(r.TLS.PeerCertificates) > 0 {
+		cert = r.TLS.PeerCertificates[0]
+	}
+	return claims.BoundTo(cert)
+}
+
+// ✅ Write a 401 auth.unauthenticated problem
+func writeUnauthenticated(w http.ResponseWriter, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(apiclient.Problem{
+		Type:   "/errors#auth.unauthenticated",
+		Title:  "Authentication required",
+		Status: http.StatusUnauthorized,
+		Code:   "auth.unauthenticated",
+		Detail: detail,
+	})
+}
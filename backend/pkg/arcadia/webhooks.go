@@ -0,0 +1,83 @@
+package arcadia
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ✅ Webhook event types
+const (
+	EventUserRegistered    = "user.registered"
+	EventUserEmailVerified = "user.email_verified"
+	EventUserEmailChanged  = "user.email_changed"
+	EventUserDeleted       = "user.deleted"
+	EventUserRestored      = "user.restored"
+	EventUserPurged        = "user.purged"
+	EventSessionRevoked    = "session.revoked"
+)
+
+// ✅ Header carrying a delivery's signature ("t=<unix>,v1=<hex HMAC-SHA256>")
+const WebhookSignatureHeader = "Arcadia-Signature"
+
+// ✅ WebhookEvent is the body of a webhook delivery
+//
+// ID stays the same across retries and redeliveries; skip events already handled.
+type WebhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"` // e.g. {"user_id", "email", "username"} for user events
+}
+
+// ✅ Returned for a delivery whose signature is missing, wrong or too old
+var ErrInvalidWebhook = errors.New("arcadia: invalid webhook signature")
+
+// ✅ Check a delivery against the endpoint secret and decode it
+//
+// Pass the raw body and the Arcadia-Signature header; deliveries signed more
+// than tolerance ago (or ahead) are rejected as replays (5 minutes is usual):
+//
+//	body, _ := io.ReadAll(r.Body)
+//	event, err := arcadia.VerifyWebhook(secret, r.Header.Get(arcadia.WebhookSignatureHeader), body, 5*time.Minute)
+func VerifyWebhook(secret, signature string, body []byte, tolerance time.Duration) (*WebhookEvent, error) {
+	var timestamp string
+	var candidates []string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			candidates = append(candidates, value)
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(candidates) == 0 {
+		return nil, ErrInvalidWebhook
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return nil, ErrInvalidWebhook
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, candidate := range candidates {
+		if got, err := hex.DecodeString(candidate); err == nil && hmac.Equal(got, expected) {
+			var event WebhookEvent
+			if err := json.Unmarshal(body, &event); err != nil {
+				return nil, err
+			}
+			return &event, nil
+		}
+	}
+	return nil, ErrInvalidWebhook
+}
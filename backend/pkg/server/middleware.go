@@ -0,0 +1,36 @@
+package server
+
+import (
+	"time"
+
+	"github.com/didip/tollbooth/v7"
+	"github.com/didip/tollbooth/v7/limiter"
+	"github.com/gin-gonic/gin"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+)
+
+// ✅ Rate Limiting (requests per second per IP)
+func rateLimiter(rate float64) gin.HandlerFunc {
+	lmt := tollbooth.NewLimiter(rate, &limiter.ExpirableOptions{
+		DefaultExpirationTTL: time.Minute, // Set rate limit expiration
+	})
+	lmt.SetIPLookups([]string{"RemoteAddr", "X-Forwarded-For", "X-Real-IP"}) // Track request IP
+
+	return func(c *gin.Context) {
+		if httpError := tollbooth.LimitByRequest(lmt, c.Writer, c.Request); httpError != nil {
+			apierror.Abort(c, apierror.RateLimited.New())
+			return
+		}
+		c.Next()
+	}
+}
+
+// ✅ Security Headers Middleware
+func securityHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Set("X-Frame-Options", "DENY")
+		c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
+		c.Writer.Header().Set("Content-Security-Policy", "default-src 'self'")
+		c.Next()
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/handlers"
+	"github.com/thejpness/ArcadiaGo/internal/middleware"
+	"github.com/thejpness/ArcadiaGo/internal/models"
+	"github.com/thejpness/ArcadiaGo/internal/storage"
+)
+
+// ✅ Register the middleware stack and every route
+//
+// Each route must also be listed in handlers.Operations (the OpenAPI table),
+// the contract test in openapi_test.go checks both directions.
+func (s *Server) registerRoutes() {
+	api := s.engine.Group(s.prefix)
+
+	// Middleware Stack
+	if s.embedded {
+		// The host engine owns logging, recovery, CORS and unknown routes
+		api.Use(apierror.RequestID(), apierror.Middleware(), securityHeaders(), rateLimiter(s.cfg.RateLimitPerSecond))
+	} else {
+		s.engine.Use(apierror.RequestID())                  // Tags every request (and error response) with an ID
+		s.engine.Use(gin.Logger())                          // Logs all requests
+		s.engine.Use(gin.Recovery())                        // Prevents crashes from panics
+		s.engine.Use(apierror.Middleware())                 // Renders handler errors as RFC 7807 problem+json
+		s.engine.Use(middleware.CORSConfig())               // Enables CORS
+		s.engine.Use(securityHeaders())                     // Adds security headers
+		s.engine.Use(rateLimiter(s.cfg.RateLimitPerSecond)) // Enables Rate Limiting
+		s.engine.NoRoute(func(c *gin.Context) {
+			apierror.Abort(c, apierror.RouteNotFound.New())
+		})
+	}
+
+	// Default Root Route
+	api.GET("/", handlers.APIStatus)
+	api.GET("/errors", apierror.CatalogueHandler)    // Error code catalogue (problem "type" URIs point here)
+	api.GET("/openapi.json", handlers.OpenAPISpec)   // OpenAPI 3 document of this API
+	api.GET("/.well-known/jwks.json", handlers.JWKS) // Keys other services verify access tokens with
+
+	// ✅ Uploaded Media (only when objects are stored on the local filesystem)
+	if local, ok := storage.Active().(*storage.LocalStorage); ok {
+		media := api.Group(storage.MediaPath)
+		media.Use(func(c *gin.Context) {
+			c.Header("Cache-Control", storage.ImmutableCacheControl) // Keys are versioned, content never changes
+			c.Header("Cross-Origin-Resource-Policy", "cross-origin")
+		})
+		media.Static("/", local.Dir)
+	}
+
+	// ✅ Public API Routes (No Authentication Required)
+	publicRoutes := api.Group("/")
+	{
+		publicRoutes.POST("/register", handlers.RegisterUser)
+		publicRoutes.POST("/login", handlers.LoginUser)
+		publicRoutes.POST("/logout", handlers.LogoutUser)
+		publicRoutes.POST("/login/magic-link", handlers.RequestMagicLink)      // Email a passwordless sign-in link
+		publicRoutes.GET("/login/magic-link/verify", handlers.VerifyMagicLink) // Sign in from the emailed link
+		publicRoutes.GET("/confirm-email", handlers.ConfirmEmailVerification)  // Fixed function name
+		publicRoutes.GET("/restore-account", handlers.GetRestoreAccount)       // Check an emailed restore link
+		publicRoutes.POST("/restore-account", handlers.RestoreUser)            // Restore deleted account (token in the body)
+		publicRoutes.POST("/not-me", handlers.ReportNotMe)                     // "This wasn't me" from new-device emails (token in the body)
+		publicRoutes.POST("/password-reset", handlers.RequestPasswordReset)    // Email a password reset link
+		publicRoutes.POST("/password-reset/confirm", handlers.ConfirmPasswordReset)
+		publicRoutes.GET("/invitations", handlers.GetInvitation)            // Preview organization invitation
+		publicRoutes.POST("/invitations/accept", handlers.AcceptInvitation) // Accept invitation (link or register)
+	} // ✅ Closing bracket was missing
+
+	// ✅ OAuth Client Routes (Require client credentials, or a public client's client_id)
+	oauthClients := api.Group("/")
+	oauthClients.Use(middleware.RequireClient())
+	{
+		oauthClients.POST("/token", handlers.IssueToken)              // Service tokens, device sign-in polls and refreshes
+		oauthClients.POST("/device/code", handlers.RequestDeviceCode) // Start a device sign-in (RFC 8628)
+	}
+
+	// ✅ OAuth Routes (Require service client credentials)
+	oauth := api.Group("/")
+	oauth.Use(middleware.RequireServiceClient())
+	{
+		oauth.POST("/introspect", handlers.IntrospectToken) // Is this token still active? (RFC 7662)
+		oauth.POST("/revoke", handlers.RevokeToken)         // Sign a token's session out (RFC 7009)
+	}
+
+	// ✅ Protected Routes (Require Authentication; all an impersonation token may call)
+	authenticated := api.Group("/")
+	authenticated.Use(middleware.AuthMiddleware()) // Secure all endpoints below
+	{
+		// User Profile
+		authenticated.GET("/user", handlers.GetUserProfile)
+		authenticated.GET("/users/:username", handlers.GetProfileByUsername) // Public profile (redirects from recently changed names)
+
+		// Session Management
+		authenticated.GET("/active-sessions", handlers.GetActiveSessions)     // List active sessions
+		authenticated.GET("/devices", handlers.ListTrustedDevices)            // List trusted devices
+		authenticated.GET("/device", handlers.GetDeviceAuthorization)         // Check a device sign-in by its user code
+		authenticated.POST("/impersonation/stop", handlers.StopImpersonation) // End an impersonation (with its token)
+
+		// Organizations
+		authenticated.GET("/orgs", handlers.ListMyOrganizations) // List my organizations
+	}
+
+	// ✅ Guarded Routes (Everything that changes the account or its organizations, exports data or issues tokens)
+	//
+	// Impersonation tokens are for looking at an account as its user does, so
+	// none of these accept them.
+	guarded := authenticated.Group("/")
+	guarded.Use(middleware.DenyImpersonation())
+	{
+		guarded.POST("/refresh", handlers.RefreshToken)
+		guarded.POST("/reauthenticate", handlers.Reauthenticate)  // Enter sudo mode
+		guarded.POST("/update-password", handlers.UpdatePassword) // Change password
+
+		// User Profile
+		guarded.PATCH("/user", handlers.UpdateUserProfile)
+		guarded.POST("/user/avatar", handlers.UploadAvatar)   // Upload profile picture (multipart "avatar")
+		guarded.DELETE("/user/avatar", handlers.DeleteAvatar) // Remove profile picture
+		guarded.GET("/user/export", handlers.ExportUserData)  // Download all personal data (zip)
+
+		// User Management
+		guarded.POST("/update-username", handlers.UpdateUsername) // Change username
+
+		// Session Management
+		guarded.POST("/logout-session", handlers.LogoutSession) // Logout from a specific session
+		guarded.PATCH("/devices/:device_id", handlers.RenameTrustedDevice)
+		guarded.DELETE("/devices/:device_id", handlers.RemoveTrustedDevice)
+		guarded.POST("/device", handlers.AnswerDeviceAuthorization) // Approve or decline a device sign-in
+
+		// Organizations
+		guarded.POST("/orgs", handlers.CreateOrganization)        // Create organization (caller becomes owner)
+		guarded.POST("/orgs/switch", handlers.SwitchOrganization) // Switch active organization
+	}
+
+	// ✅ Sensitive Routes (Require recent re-authentication / sudo mode)
+	sudo := guarded.Group("/")
+	sudo.Use(middleware.RequireRecentAuth(middleware.SudoModeWindow()))
+	{
+		sudo.POST("/update-email", handlers.RequestEmailChange) // Request email change
+		sudo.POST("/delete-account", handlers.SoftDeleteUser)   // Soft delete account
+	}
+
+	// ✅ Admin Routes (Support staff, in sudo mode)
+	admin := sudo.Group("/admin")
+	admin.Use(middleware.RequireAdmin())
+	{
+		admin.POST("/impersonations", handlers.StartImpersonation) // Act as a user (short-lived token)
+		admin.POST("/webhooks", handlers.CreateWebhook)            // Register an endpoint (secret shown once)
+		admin.GET("/webhooks", handlers.ListWebhooks)
+		admin.PATCH("/webhooks/:webhook_id", handlers.UpdateWebhook)                  // URL, filter, enable/disable
+		admin.DELETE("/webhooks/:webhook_id", handlers.DeleteWebhook)                 // Remove with its delivery log
+		admin.GET("/webhooks/:webhook_id/deliveries", handlers.ListWebhookDeliveries) // Delivery log
+		admin.POST("/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", handlers.RedeliverWebhook)
+		admin.GET("/events/stats", handlers.GetEventStats) // Event bus counters and backlog
+		admin.GET("/jobs", handlers.ListJobs)              // Maintenance jobs and their last run
+		admin.GET("/jobs/:job/runs", handlers.ListJobRuns)
+		admin.POST("/jobs/:job/run", handlers.RunJob) // Run now
+	}
+
+	// ✅ Organization Admin Routes (Require admin role inside the organization)
+	orgAdmin := authenticated.Group("/orgs/:org_id")
+	orgAdmin.Use(middleware.RequireOrgRole(models.OrgRoleAdmin))
+	{
+		orgAdmin.GET("/members", handlers.ListOrgMembers)         // List members
+		orgAdmin.GET("/invitations", handlers.ListOrgInvitations) // List pending invitations
+	}
+
+	// ✅ Organization Management Routes (Admin role, and not while impersonating)
+	orgManage := guarded.Group("/orgs/:org_id")
+	orgManage.Use(middleware.RequireOrgRole(models.OrgRoleAdmin))
+	{
+		orgManage.POST("/members", handlers.AddOrgMember)                  // Add existing user by email
+		orgManage.PATCH("/members/:user_id", handlers.UpdateOrgMemberRole) // Change member role
+		orgManage.DELETE("/members/:user_id", handlers.RemoveOrgMember)    // Remove member
+
+		orgManage.POST("/invitations", handlers.CreateOrgInvitation)                   // Invite by email
+		orgManage.POST("/invitations/:invite_id/resend", handlers.ResendOrgInvitation) // Resend with a fresh link
+		orgManage.DELETE("/invitations/:invite_id", handlers.RevokeOrgInvitation)      // Revoke invitation
+	}
+}
@@ -0,0 +1,262 @@
+// Package server builds the ArcadiaGo API so it can run on its own or be
+// embedded in another Go service.
+//
+// Standalone (what the arcadiago binary does):
+//
+//	srv := server.New(server.ConfigFromEnv(), server.Deps{DB: db})
+//	go srv.Start()
+//	defer srv.Shutdown(ctx)
+//
+// Embedded under a prefix of the host's own Gin engine:
+//
+//	server.New(cfg, server.Deps{DB: db}, server.WithEngine(r), server.WithPrefix("/auth"))
+//
+// The handlers share process-wide state (database, mailer, storage), so only
+// one Server should be created per process.
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thejpness/ArcadiaGo/internal/apierror"
+	"github.com/thejpness/ArcadiaGo/internal/database"
+	"github.com/thejpness/ArcadiaGo/internal/events"
+	"github.com/thejpness/ArcadiaGo/internal/handlers"
+	"github.com/thejpness/ArcadiaGo/internal/jobs"
+	"github.com/thejpness/ArcadiaGo/internal/lifecycle"
+	"github.com/thejpness/ArcadiaGo/internal/mailer"
+	"github.com/thejpness/ArcadiaGo/internal/storage"
+	"github.com/thejpness/ArcadiaGo/internal/webhooks"
+	"gorm.io/gorm"
+)
+
+// ✅ Mailer delivers plain-text emails (Send(to, subject, body string) error)
+type Mailer = mailer.Mailer
+
+// ✅ Storage keeps uploaded objects such as avatars
+type Storage = storage.Storage
+
+// ✅ Config holds the server settings
+type Config struct {
+	Addr               string  // Listen address for Start (default ":8080")
+	RateLimitPerSecond float64 // Requests per second per IP (default 10)
+	Migrate            bool    // Run database migrations in New
+	JobRunner          bool    // Run the scheduled maintenance jobs (account purge, pruning) in the background
+	WebhookDispatcher  bool    // Send queued webhook deliveries in the background
+	EventDispatcher    bool    // Hand published domain events to their subscribers in the background
+}
+
+// ✅ Read the Config from PORT and RATE_LIMIT_PER_SECOND (migrations and background workers on)
+func ConfigFromEnv() Config {
+	cfg := Config{Addr: ":8080", Migrate: true, JobRunner: true, WebhookDispatcher: true, EventDispatcher: true}
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Addr = ":" + port
+	}
+	if value, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_PER_SECOND"), 64); err == nil && value > 0 {
+		cfg.RateLimitPerSecond = value
+	}
+	return cfg
+}
+
+// ✅ Deps are the server's collaborators (nil ones keep the environment-configured defaults)
+type Deps struct {
+	DB      *gorm.DB // Required unless database.InitDB already ran
+	Mailer  Mailer   // SMTP from SMTP_HOST / SMTP_PORT by default
+	Storage Storage  // STORAGE_BACKEND by default
+}
+
+// ✅ Option customises how the server is mounted
+type Option func(*Server)
+
+// ✅ Register the routes on a host application's engine instead of a new one
+//
+// The host keeps its own logging, recovery, CORS and 404 handling; the API's
+// request IDs, problem rendering, security headers and rate limiting apply to
+// its own routes only. Handler returns the host engine and serving it is left
+// to the host; Shutdown still stops the background workers.
+func WithEngine(engine *gin.Engine) Option {
+	return func(s *Server) {
+		s.engine = engine
+		s.embedded = true
+	}
+}
+
+// ✅ Mount every route under a path prefix (e.g. "/auth")
+//
+// Problem type URIs, redirects and the OpenAPI servers entry follow the
+// prefix. Emailed links use APP_BASE_URL when set, so include the prefix there
+// (and in LOCAL_STORAGE_URL for locally stored avatars).
+func WithPrefix(prefix string) Option {
+	return func(s *Server) {
+		s.prefix = normalizePrefix(prefix)
+	}
+}
+
+// ✅ Server is a configured ArcadiaGo API
+type Server struct {
+	cfg      Config
+	engine   *gin.Engine
+	embedded bool
+	prefix   string
+
+	mu   sync.Mutex
+	http *http.Server
+
+	stopWorkers context.CancelFunc // Stops the job runner and the dispatchers
+	workers     []<-chan struct{}  // Closed as each background worker stops
+}
+
+// ✅ Build the server: install the dependencies and register every route
+func New(cfg Config, deps Deps, opts ...Option) *Server {
+	s := &Server{cfg: cfg}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.cfg.Addr == "" {
+		s.cfg.Addr = ":8080"
+	}
+	if s.cfg.RateLimitPerSecond <= 0 {
+		s.cfg.RateLimitPerSecond = 10
+	}
+
+	// Shared state used by the handlers
+	if deps.DB != nil {
+		database.DB = deps.DB
+	}
+	if deps.Mailer != nil {
+		mailer.SetMailer(deps.Mailer)
+	}
+	if deps.Storage != nil {
+		storage.SetStorage(deps.Storage)
+	}
+	subscribeEvents.Do(subscribe) // Event bus subscribers (pending deliveries name them)
+	registerJobs.Do(schedule)     // Maintenance jobs (each replica must know them all)
+	handlers.SetBasePath(s.prefix)
+	apierror.SetCataloguePath(s.prefix + "/errors")
+
+	if s.cfg.Migrate {
+		database.Migrate()
+	}
+
+	// Background workers, until Shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopWorkers = cancel
+	if s.cfg.JobRunner {
+		s.workers = append(s.workers, jobs.Start(ctx))
+	}
+	if s.cfg.WebhookDispatcher {
+		s.workers = append(s.workers, webhooks.StartDispatcher(ctx))
+	}
+	if s.cfg.EventDispatcher {
+		s.workers = append(s.workers, events.Start(ctx))
+	}
+
+	if s.engine == nil {
+		s.engine = gin.New()
+	}
+	s.registerRoutes()
+	return s
+}
+
+var (
+	subscribeEvents sync.Once
+	registerJobs    sync.Once
+)
+
+// ✅ Subscribe the audit, mailer, webhooks and metrics subscribers to the event bus
+func subscribe() {
+	handlers.SubscribeEvents()
+	webhooks.Subscribe()
+	events.SubscribeMetrics()
+}
+
+// ✅ Register the maintenance jobs with their default schedules (UTC)
+func schedule() {
+	jobs.Register(jobs.Job{Name: "expire-email-changes", Schedule: "*/15 * * * *", Run: lifecycle.ExpireEmailChanges})
+	jobs.Register(jobs.Job{Name: "prune-sessions", Schedule: "5 * * * *", Run: lifecycle.PruneSessions})
+	jobs.Register(jobs.Job{Name: "prune-used-action-tokens", Schedule: "10 * * * *", Run: lifecycle.PruneUsedActionTokens})
+	jobs.Register(jobs.Job{Name: "prune-device-authorizations", Schedule: "20 * * * *", Run: lifecycle.PruneDeviceAuthorizations})
+	jobs.Register(jobs.Job{Name: "purge-deleted-users", Schedule: "30 * * * *", Run: lifecycle.RunPurge})
+	jobs.Register(jobs.Job{Name: "prune-audit-events", Schedule: "15 3 * * *", Run: lifecycle.PruneAuditEvents})
+	jobs.Register(jobs.Job{Name: "prune-webhook-deliveries", Schedule: "45 * * * *", Run: webhooks.Prune})
+	jobs.Register(jobs.Job{Name: "prune-events", Schedule: "50 * * * *", Run: events.Prune})
+	jobs.Register(jobs.Job{Name: "prune-sent-emails", Schedule: "55 * * * *", Run: lifecycle.PruneSentEmails})
+	jobs.Register(jobs.Job{Name: "prune-job-runs", Schedule: "30 3 * * *", Run: jobs.PruneRuns})
+}
+
+// ✅ The http.Handler serving the API (the host engine when embedded)
+func (s *Server) Handler() http.Handler {
+	return s.engine
+}
+
+// ✅ Routes registered by this server
+func (s *Server) Routes() gin.RoutesInfo {
+	var routes gin.RoutesInfo
+	for _, route := range s.engine.Routes() {
+		if s.prefix == "" || route.Path == s.prefix || strings.HasPrefix(route.Path, s.prefix+"/") {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// ✅ Listen on Config.Addr until Shutdown (returns nil after a clean shutdown)
+func (s *Server) Start() error {
+	if s.embedded {
+		return errors.New("server: mounted on a host engine, which serves it")
+	}
+	if database.DB == nil {
+		return errors.New("server: no database configured")
+	}
+
+	s.mu.Lock()
+	s.http = &http.Server{Addr: s.cfg.Addr, Handler: s.engine}
+	srv := s.http
+	s.mu.Unlock()
+
+	log.Printf("🚀 Server running on %s", s.cfg.Addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ✅ Stop accepting connections and the background workers, and wait for in-flight work (until ctx expires)
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.http
+	s.mu.Unlock()
+
+	log.Println("🛑 Shutting down server")
+	s.stopWorkers()
+
+	var err error
+	if srv != nil {
+		err = srv.Shutdown(ctx)
+	}
+	for _, done := range s.workers {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// ✅ "/auth/" and "auth" both become "/auth"; "/" becomes ""
+func normalizePrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
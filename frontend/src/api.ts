@@ -1,10 +1,49 @@
 export const API_URL = "http://localhost:8080";
 
+// Request and response shapes follow the server's OpenAPI document (GET /openapi.json).
+
+export interface FieldError {
+  field: string;
+  code: string;
+  message: string;
+}
+
+/**
+ * RFC 7807 problem returned by the API; branch on `code` (catalogue at GET /errors)
+ */
+export class ApiError extends Error {
+  code: string;
+  status: number;
+  fields: FieldError[];
+  requestId?: string;
+
+  constructor(problem: { code?: string; status?: number; title?: string; detail?: string; errors?: FieldError[]; request_id?: string }, fallback: string) {
+    super(problem.detail || problem.errors?.map((e) => e.message).join(". ") || problem.title || fallback);
+    this.name = "ApiError";
+    this.code = problem.code || "unknown";
+    this.status = problem.status || 0;
+    this.fields = problem.errors || [];
+    this.requestId = problem.request_id;
+  }
+}
+
+/**
+ * Builds an ApiError from a failed response (tolerates non-JSON bodies)
+ */
+async function apiError(response: Response, fallback: string): Promise<ApiError> {
+  const text = await response.text();
+  try {
+    return new ApiError({ status: response.status, ...(text ? JSON.parse(text) : {}) }, fallback);
+  } catch {
+    return new ApiError({ status: response.status }, fallback);
+  }
+}
+
 /**
  * Logs in the user and stores session via cookies
  * @param email - User email
  * @param password - User password
- * @returns Token string
+ * @returns Success message (the session itself lives in httpOnly cookies)
  */
 export async function loginUser(email: string, password: string): Promise<string> {
   const response = await fetch(`${API_URL}/login`, {
@@ -14,33 +53,34 @@ export async function loginUser(email: string, password: string): Promise<string
     body: JSON.stringify({ email, password }),
   });
 
+  if (!response.ok) throw await apiError(response, "Login failed");
+
   const text = await response.text();
   const data = text ? JSON.parse(text) : {};
 
-  if (!response.ok) throw new Error(data.error || "Login failed");
-
-  return data.token; // ✅ Ensure backend returns a token
+  return data.message;
 }
 
 /**
  * Registers a new user
  * @param email - New user's email
+ * @param username - New user's username
  * @param password - New user's password
  * @returns Success message
  */
-export async function registerUser(email: string, password: string): Promise<string> {
+export async function registerUser(email: string, username: string, password: string): Promise<string> {
   const response = await fetch(`${API_URL}/register`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     credentials: "include", // ✅ Ensures cookies are sent
-    body: JSON.stringify({ email, password }),
+    body: JSON.stringify({ email, username, password }),
   });
 
+  if (!response.ok) throw await apiError(response, "Registration failed");
+
   const text = await response.text();
   const data = text ? JSON.parse(text) : {};
 
-  if (!response.ok) throw new Error(data.error || "Registration failed");
-
   return data.message;
 }
 
@@ -54,16 +94,32 @@ export async function logoutUser(): Promise<string> {
     credentials: "include", // ✅ Ensures cookies are sent for logout
   });
 
-  if (!response.ok) throw new Error("Logout failed");
+  if (!response.ok) throw await apiError(response, "Logout failed");
 
   return "Logged out successfully";
 }
 
+export interface UserProfile {
+  id: string;
+  username: string;
+  email: string;
+  email_verified: boolean;
+  display_name: string;
+  avatar_url: string;
+  locale: string;
+  timezone: string;
+  bio: string;
+  joined: string;
+  updated_at: string;
+}
+
+export type ProfilePatch = Partial<Record<"display_name" | "avatar_url" | "locale" | "timezone" | "bio", string | null>>;
+
 /**
- * Fetches the authenticated user's details (now includes username)
+ * Fetches the authenticated user's profile
  * @returns User data or null if not authenticated
  */
-export async function fetchUser(): Promise<{ username: string; email: string; joined: string } | null> {
+export async function fetchUser(): Promise<UserProfile | null> {
   try {
     const response = await fetch(`${API_URL}/user`, {
       method: "GET",
@@ -82,6 +138,22 @@ export async function fetchUser(): Promise<{ username: string; email: string; jo
   }
 }
 
+/**
+ * Updates profile fields (omitted fields are unchanged, null clears a field)
+ * @returns The updated profile
+ */
+export async function updateProfile(patch: ProfilePatch): Promise<UserProfile> {
+  const response = await fetch(`${API_URL}/user`, {
+    method: "PATCH",
+    headers: { "Content-Type": "application/json" },
+    credentials: "include",
+    body: JSON.stringify(patch),
+  });
+
+  if (!response.ok) throw await apiError(response, "Failed to update profile");
+
+  return response.json();
+}
 
 export async function updateUsername(newUsername: string): Promise<void> {
   const response = await fetch(`${API_URL}/update-username`, {
@@ -91,7 +163,7 @@ export async function updateUsername(newUsername: string): Promise<void> {
     body: JSON.stringify({ new_username: newUsername }),
   });
 
-  if (!response.ok) throw new Error("Failed to update username");
+  if (!response.ok) throw await apiError(response, "Failed to update username");
 }
 
 export async function updateEmail(newEmail: string): Promise<void> {
@@ -102,7 +174,7 @@ export async function updateEmail(newEmail: string): Promise<void> {
     body: JSON.stringify({ new_email: newEmail }),
   });
 
-  if (!response.ok) throw new Error("Failed to request email change");
+  if (!response.ok) throw await apiError(response, "Failed to request email change");
 }
 
 export async function updatePassword(oldPassword: string, newPassword: string): Promise<void> {
@@ -113,18 +185,29 @@ export async function updatePassword(oldPassword: string, newPassword: string):
     body: JSON.stringify({ old_password: oldPassword, new_password: newPassword }),
   });
 
-  if (!response.ok) throw new Error("Failed to update password");
+  if (!response.ok) throw await apiError(response, "Failed to update password");
 }
 
-export async function fetchActiveSessions(): Promise<{ id: string, userAgent: string, ipAddress: string }[]> {
+export interface ActiveSession {
+  id: string;
+  ip_address: string;
+  user_agent: string;
+  created_at: string;
+  expires_at: string;
+  current: boolean; // The session making the request
+  device_name?: string; // Set for device sign-ins (CLI, TV)
+}
+
+export async function fetchActiveSessions(): Promise<ActiveSession[]> {
   const response = await fetch(`${API_URL}/active-sessions`, {
     method: "GET",
     credentials: "include",
   });
 
-  if (!response.ok) throw new Error("Failed to fetch active sessions");
+  if (!response.ok) throw await apiError(response, "Failed to fetch active sessions");
 
-  return response.json();
+  const data: { sessions: ActiveSession[] | null } = await response.json();
+  return data.sessions || [];
 }
 
 export async function logoutSession(sessionId: string): Promise<void> {
@@ -135,5 +218,96 @@ export async function logoutSession(sessionId: string): Promise<void> {
     body: JSON.stringify({ session_id: sessionId }),
   });
 
-  if (!response.ok) throw new Error("Failed to logout session");
+  if (!response.ok) throw await apiError(response, "Failed to logout session");
+}
+
+export interface DeviceAuthorization {
+  user_code: string;
+  client_name: string; // App asking to sign in
+  device_name: string;
+  ip_address: string;
+  created_at: string;
+  expires_at: string;
+}
+
+/**
+ * Looks up a pending device sign-in by the code the device shows
+ * @param userCode - Code as typed (case and dashes don't matter)
+ */
+export async function fetchDeviceAuthorization(userCode: string): Promise<DeviceAuthorization> {
+  const response = await fetch(`${API_URL}/device?user_code=${encodeURIComponent(userCode)}`, {
+    method: "GET",
+    credentials: "include",
+  });
+
+  if (!response.ok) throw await apiError(response, "Failed to look up the code");
+
+  return response.json();
+}
+
+/**
+ * Approves (signs the device in as the current user) or declines a device sign-in
+ */
+export async function answerDeviceAuthorization(userCode: string, approve: boolean): Promise<void> {
+  const response = await fetch(`${API_URL}/device`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    credentials: "include",
+    body: JSON.stringify({ user_code: userCode, approve }),
+  });
+
+  if (!response.ok) throw await apiError(response, "Failed to answer the device sign-in");
+}
+
+export interface RestoreAccountInfo {
+  username: string;
+  email: string;
+  deleted_at: string;
+  restore_before: string; // The account is erased for good after this
+}
+
+/**
+ * Checks an emailed restore link (changes nothing)
+ * @param token - Token from the link
+ */
+export async function fetchRestoreAccount(token: string): Promise<RestoreAccountInfo> {
+  const response = await fetch(`${API_URL}/restore-account?token=${encodeURIComponent(token)}`, {
+    method: "GET",
+    credentials: "include",
+  });
+
+  if (!response.ok) throw await apiError(response, "Failed to check the restore link");
+
+  return response.json();
+}
+
+/**
+ * Restores a deleted account with the token from the emailed link
+ */
+export async function restoreAccount(token: string): Promise<void> {
+  const response = await fetch(`${API_URL}/restore-account`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    credentials: "include",
+    body: JSON.stringify({ token }),
+  });
+
+  if (!response.ok) throw await apiError(response, "Failed to restore the account");
+}
+
+/**
+ * Signs out a device reported from a new-device email and emails a password reset link
+ * @param token - Token from the "this wasn't me" link (works once)
+ */
+export async function reportNotMe(token: string): Promise<string> {
+  const response = await fetch(`${API_URL}/not-me`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    credentials: "include",
+    body: JSON.stringify({ token }),
+  });
+
+  if (!response.ok) throw await apiError(response, "Failed to sign the device out");
+
+  return (await response.json()).message;
 }
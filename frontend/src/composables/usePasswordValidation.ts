@@ -1,31 +1,47 @@
-import { ref, computed, type Ref } from "vue";
+import { ref, computed, watch, type Ref } from "vue";
+import { ApiError } from "@/api";
 
 /**
- * Password validation composable for ensuring password security and confirmation match.
- * 
+ * Password validation composable mirroring the server's password policy.
+ *
+ * The server only requires a length by default (character classes are off, so
+ * passphrases are fine) and decides on strength and breached passwords itself;
+ * its verdict arrives as the problem's errors[] and is shown with the live rules.
+ *
  * @param passwordRef - A Vue ref containing the user's password.
  * @param confirmPasswordRef - A Vue ref containing the confirmed password.
- * @returns Computed properties for password errors, match validation, and overall validity.
+ * @param field - The request field the server reports password errors under.
+ * @returns Computed properties for password errors, a strength hint, match validation and overall validity.
  */
-export function usePasswordValidation(passwordRef: Ref<string>, confirmPasswordRef: Ref<string>) {
-  // ✅ Password security rules
+export function usePasswordValidation(passwordRef: Ref<string>, confirmPasswordRef: Ref<string>, field = "password") {
+  // ✅ Password length rules (PASSWORD_MIN_LENGTH / PASSWORD_MAX_LENGTH defaults, counted in characters)
   const passwordRules = {
     minLength: 8,
-    hasUppercase: /[A-Z]/,
-    hasLowercase: /[a-z]/,
-    hasNumber: /\d/,
-    hasSpecial: /[@$!%*?&]/,
+    maxLength: 64,
   };
 
-  // ✅ Live validation for password security
+  // ✅ Errors the server reported for the password on the last submit (cleared when it changes)
+  const serverErrors = ref<string[]>([]);
+  watch(passwordRef, () => {
+    serverErrors.value = [];
+  });
+
+  const length = computed(() => Array.from(passwordRef.value).length);
+
+  // ✅ Live validation for password length, plus the server's verdict
   const passwordErrors = computed(() => {
     const errors: string[] = [];
-    if (passwordRef.value.length < passwordRules.minLength) errors.push("At least 8 characters.");
-    if (!passwordRules.hasUppercase.test(passwordRef.value)) errors.push("At least 1 uppercase letter.");
-    if (!passwordRules.hasLowercase.test(passwordRef.value)) errors.push("At least 1 lowercase letter.");
-    if (!passwordRules.hasNumber.test(passwordRef.value)) errors.push("At least 1 number.");
-    if (!passwordRules.hasSpecial.test(passwordRef.value)) errors.push("At least 1 special character (@$!%*?&).");
-    return errors;
+    if (length.value < passwordRules.minLength) errors.push(`At least ${passwordRules.minLength} characters.`);
+    if (length.value > passwordRules.maxLength) errors.push(`At most ${passwordRules.maxLength} characters.`);
+    return errors.concat(serverErrors.value);
+  });
+
+  // ✅ Rough strength hint (the server has the final say)
+  const passwordStrengthHint = computed(() => {
+    if (!passwordRef.value || length.value < passwordRules.minLength) return "";
+    const classes = [/\p{Ll}/u, /\p{Lu}/u, /\p{N}/u, /[^\p{L}\p{N}]/u].filter((re) => re.test(passwordRef.value)).length;
+    if (length.value >= 16 || (length.value >= 12 && classes >= 2)) return "";
+    return "Tip: a longer passphrase of a few unrelated words is stronger.";
   });
 
   // ✅ Live validation for password match
@@ -35,15 +51,17 @@ export function usePasswordValidation(passwordRef: Ref<string>, confirmPasswordR
 
   // ✅ Computed property to check if the password meets all criteria
   const isPasswordValid = computed(() => {
-    return (
-      passwordRef.value.length >= passwordRules.minLength &&
-      passwordRules.hasUppercase.test(passwordRef.value) &&
-      passwordRules.hasLowercase.test(passwordRef.value) &&
-      passwordRules.hasNumber.test(passwordRef.value) &&
-      passwordRules.hasSpecial.test(passwordRef.value) &&
-      passwordRef.value === confirmPasswordRef.value
-    );
+    return passwordErrors.value.length === 0 && passwordRef.value === confirmPasswordRef.value;
   });
 
-  return { passwordErrors, passwordMatchError, isPasswordValid };
+  /**
+   * Shows the password errors of a rejected request; returns whether there were any
+   */
+  function applyServerErrors(err: unknown): boolean {
+    if (!(err instanceof ApiError)) return false;
+    serverErrors.value = err.fields.filter((e) => e.field === field).map((e) => e.message);
+    return serverErrors.value.length > 0;
+  }
+
+  return { passwordErrors, passwordStrengthHint, passwordMatchError, isPasswordValid, applyServerErrors };
 }
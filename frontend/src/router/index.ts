@@ -7,6 +7,9 @@ const Register = () => import("@/views/Register.vue");
 const Dashboard = () => import("@/views/Dashboard.vue");
 const Profile = () => import("@/views/Profile.vue");
 const Settings = () => import("@/views/Settings.vue");
+const Device = () => import("@/views/Device.vue");
+const RestoreAccount = () => import("@/views/RestoreAccount.vue");
+const NotMe = () => import("@/views/NotMe.vue");
 
 const routes = [
   { path: "/", redirect: "/dashboard" }, // ✅ Redirect root to Dashboard (if logged in)
@@ -15,6 +18,9 @@ const routes = [
   { path: "/dashboard", component: Dashboard, meta: { requiresAuth: true } },
   { path: "/profile", component: Profile, meta: { requiresAuth: true } },
   { path: "/settings", component: Settings, meta: { requiresAuth: true } },
+  { path: "/device", component: Device, meta: { requiresAuth: true } }, // ✅ Approve a CLI / TV sign-in (RFC 8628 verification URI)
+  { path: "/restore-account", component: RestoreAccount, meta: { requiresAuth: false } }, // ✅ Confirm the emailed restore link
+  { path: "/not-me", component: NotMe, meta: { requiresAuth: false } }, // ✅ "This wasn't me" link from new-device emails
 ];
 
 export const router = createRouter({
@@ -22,8 +22,8 @@ export const useAuthStore = defineStore("auth", () => {
     errorMessage.value = null;
 
     try {
-      const token = await loginUser(email, password);
-      localStorage.setItem("authToken", token); // ✅ Store token on successful login
+      await loginUser(email, password); // ✅ Session cookies are set by the server
+      localStorage.setItem("signedIn", "true"); // ✅ Remember to restore the session on reload
       await loadUser(); // ✅ Fetch user data after login
       router.push("/dashboard"); // ✅ Redirect to dashboard on success
     } catch (error) {
@@ -44,7 +44,7 @@ export const useAuthStore = defineStore("auth", () => {
 
     try {
       await logoutUser();
-      localStorage.removeItem("authToken"); // ✅ Clear token on logout
+      localStorage.removeItem("signedIn"); // ✅ Forget the session on logout
       isAuthenticated.value = false;
       userEmail.value = null;
       router.push("/login"); // ✅ Redirect to login on logout
@@ -84,9 +84,8 @@ export const useAuthStore = defineStore("auth", () => {
   
   // ✅ Auto-fetch user data when the store is initialized
   watchEffect(() => {
-    const token = localStorage.getItem("authToken");
-    if (token) {
-      loadUser(); // ✅ Only fetch user if token exists
+    if (localStorage.getItem("signedIn")) {
+      loadUser(); // ✅ Only fetch user after a sign-in
     }
   });
 